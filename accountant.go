@@ -0,0 +1,87 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "errors"
+
+// ErrReleased is returned when using a ring buffer after Release.
+var ErrReleased = errors.New("ringbuffer is released")
+
+// Accountant is notified when a ring buffer allocates or releases memory,
+// so applications can track the memory used by many ring buffers
+// against a global budget.
+//
+// The methods are called with the ring buffer locked,
+// so they must not call back into the ring buffer.
+type Accountant interface {
+	// OnAlloc is called when bytes of memory are allocated.
+	OnAlloc(bytes int)
+	// OnFree is called when bytes of memory are released.
+	OnFree(bytes int)
+}
+
+// WithAccountant sets an accountant that is notified of memory
+// allocated and released by the ring buffer.
+// The memory of the current buffer is reported to the accountant immediately
+// and credited back to the previous accountant, if any.
+// A nil accountant disables accounting.
+func (r *RingBuffer) WithAccountant(a Accountant) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.freed(len(r.buf))
+	r.acct = a
+	r.allocated(len(r.buf))
+	return r
+}
+
+// Release closes the ring buffer and drops its memory,
+// reporting it as freed to the accountant.
+// The file of a ring buffer created by NewMmap is unmapped.
+// All further operations return ErrReleased.
+// Unread data is discarded.
+func (r *RingBuffer) Release() {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.buf == nil {
+		return
+	}
+	r.err = nil
+	r.setErr(ErrReleased, true)
+	r.freed(len(r.buf))
+	// Unlocked reads and writes must be done with the buffer.
+	r.waitUnlent()
+	if r.mapped != nil {
+		munmapFile(r.mapped)
+		r.mapped = nil
+	}
+	r.buf = nil
+	r.size = 0
+	r.r = 0
+	r.w = 0
+	r.isFull = false
+}
+
+// released returns true if the buffer has been dropped by Release,
+// or is being dropped by a Release waiting for unlocked reads and writes.
+// Must be called when locked.
+func (r *RingBuffer) released() bool {
+	return r.buf == nil || r.err == ErrReleased
+}
+
+// allocated reports n allocated bytes to the accountant.
+// Must be called when locked.
+func (r *RingBuffer) allocated(n int) {
+	if r.acct != nil && n > 0 {
+		r.acct.OnAlloc(n)
+	}
+}
+
+// freed reports n released bytes to the accountant.
+// Must be called when locked.
+func (r *RingBuffer) freed(n int) {
+	if r.acct != nil && n > 0 {
+		r.acct.OnFree(n)
+	}
+}
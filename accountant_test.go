@@ -0,0 +1,90 @@
+package ringbuffer
+
+import (
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testAccountant struct {
+	used atomic.Int64
+}
+
+func (a *testAccountant) OnAlloc(bytes int) { a.used.Add(int64(bytes)) }
+func (a *testAccountant) OnFree(bytes int)  { a.used.Add(-int64(bytes)) }
+
+func TestRingBuffer_Accountant(t *testing.T) {
+	acct := &testAccountant{}
+	rb1 := New(100).WithAccountant(acct)
+	rb2 := NewBuffer(make([]byte, 50)).WithAccountant(acct)
+	if got := acct.used.Load(); got != 150 {
+		t.Fatalf("expected 150 bytes used, got %d", got)
+	}
+
+	rb1.Write([]byte("hello"))
+	rb1.Release()
+	rb1.Release()
+	if got := acct.used.Load(); got != 50 {
+		t.Fatalf("expected 50 bytes used, got %d", got)
+	}
+	if _, err := rb1.Write([]byte("x")); err != ErrReleased {
+		t.Fatalf("expected ErrReleased, got %v", err)
+	}
+	if _, err := rb1.Read(make([]byte, 1)); err != ErrReleased {
+		t.Fatalf("expected ErrReleased, got %v", err)
+	}
+	if rb1.Length() != 0 || rb1.Free() != 0 || rb1.Bytes(nil) != nil {
+		t.Fatalf("released buffer is not empty")
+	}
+
+	other := &testAccountant{}
+	rb2.WithAccountant(other)
+	if acct.used.Load() != 0 || other.used.Load() != 50 {
+		t.Fatalf("expected 0 and 50 bytes used, got %d and %d", acct.used.Load(), other.used.Load())
+	}
+}
+
+func TestRingBuffer_ReleaseReset(t *testing.T) {
+	rb := New(10)
+	rb.Release()
+	rb.Reset()
+	if _, err := rb.Write([]byte("x")); err != ErrReleased {
+		t.Fatalf("expected ErrReleased, got %v", err)
+	}
+}
+
+func TestRingBuffer_ReleaseDuringReadFrom(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8)
+	rb.SetEncryptionKey([]byte("0123456789abcdef"))
+	pr, pw := io.Pipe()
+	done := make(chan error)
+	go func() {
+		_, err := rb.ReadFrom(pr)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	released := make(chan struct{})
+	go func() {
+		rb.Release()
+		close(released)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case <-released:
+		t.Fatalf("expected Release to wait for ReadFrom")
+	default:
+	}
+	pw.Write([]byte("abc"))
+	if err := <-done; err != ErrReleased {
+		t.Fatalf("expected ErrReleased, got %v", err)
+	}
+	<-released
+	rb.mu.Lock()
+	defer rb.mu.Unlock()
+	if err := rb.checkInvariants(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
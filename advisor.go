@@ -0,0 +1,72 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// HighWaterMark returns the highest number of bytes that has been buffered
+// at any time since the ring buffer was created.
+func (r *RingBuffer) HighWaterMark() int {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.highWater
+}
+
+// Stalls returns the number of times a write found the buffer full,
+// either blocking until a read made space or returning ErrIsFull/ErrTooMuchDataToWrite.
+func (r *RingBuffer) Stalls() int64 {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.stalls
+}
+
+// usage is the usage of the buffer observed since its size last changed.
+type usage struct {
+	stalls    int64 // Value of stalls when the size changed.
+	writes    int64 // Number of writes when the size changed.
+	highWater int   // Highest number of buffered bytes seen since.
+}
+
+// RecommendedCapacity returns a suggested buffer size based on the usage observed
+// since the capacity last changed, or since the ring buffer was created.
+// If writes have stalled on a full buffer, double the current capacity is recommended.
+// Otherwise the high-water mark rounded up to the next power of two is recommended,
+// which may be smaller than the current capacity.
+// If nothing has been written yet the current capacity is returned.
+//
+// The usage is not cleared by Reset, only by changes of the capacity
+// with Resize, Compact, SwapBuffer or SetAutoGrow, so following the recommendation
+// starts observing anew.
+func (r *RingBuffer) RecommendedCapacity() int {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.stalls > r.usage.stalls {
+		return r.size * 2
+	}
+	if r.ctr.writes == r.usage.writes || r.usage.highWater == 0 {
+		return r.size
+	}
+	n := 1
+	for n < r.usage.highWater {
+		n <<= 1
+	}
+	return n
+}
+
+// updateHighWater records the current length if it is the highest seen.
+// Must be called when locked.
+func (r *RingBuffer) updateHighWater() {
+	n := r.length()
+	if n > r.highWater {
+		r.highWater = n
+	}
+	if n > r.usage.highWater {
+		r.usage.highWater = n
+	}
+}
+
+// resized starts observing the usage for RecommendedCapacity anew.
+// Must be called when locked, after the size has changed.
+func (r *RingBuffer) resized() {
+	r.usage = usage{stalls: r.stalls, writes: r.ctr.writes}
+}
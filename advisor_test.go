@@ -0,0 +1,60 @@
+package ringbuffer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer_RecommendedCapacity(t *testing.T) {
+	rb := New(1024)
+	if got := rb.RecommendedCapacity(); got != 1024 {
+		t.Fatalf("expected 1024, got %d", got)
+	}
+
+	rb.Write([]byte(strings.Repeat("a", 100)))
+	rb.Read(make([]byte, 100))
+	rb.Write([]byte(strings.Repeat("a", 50)))
+	if got := rb.HighWaterMark(); got != 100 {
+		t.Fatalf("expected high-water mark 100, got %d", got)
+	}
+	if got := rb.RecommendedCapacity(); got != 128 {
+		t.Fatalf("expected 128, got %d", got)
+	}
+
+	rb.Write([]byte(strings.Repeat("a", 1000)))
+	if got := rb.Stalls(); got != 1 {
+		t.Fatalf("expected 1 stall, got %d", got)
+	}
+	if err := rb.WriteByte('a'); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	if got := rb.Stalls(); got != 2 {
+		t.Fatalf("expected 2 stalls, got %d", got)
+	}
+	if got := rb.HighWaterMark(); got != 1024 {
+		t.Fatalf("expected high-water mark 1024, got %d", got)
+	}
+	if got := rb.RecommendedCapacity(); got != 2048 {
+		t.Fatalf("expected 2048, got %d", got)
+	}
+
+	rb.Reset()
+	if got := rb.RecommendedCapacity(); got != 2048 {
+		t.Fatalf("expected 2048 after reset, got %d", got)
+	}
+
+	// Following the recommendation starts observing anew.
+	if err := rb.Resize(2048); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if got := rb.RecommendedCapacity(); got != 2048 {
+		t.Fatalf("expected 2048 after resizing, got %d", got)
+	}
+	rb.Write([]byte(strings.Repeat("a", 300)))
+	if got := rb.RecommendedCapacity(); got != 512 {
+		t.Fatalf("expected 512, got %d", got)
+	}
+	if got := rb.Stalls(); got != 2 {
+		t.Fatalf("expected 2 stalls, got %d", got)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrAuthentication is returned when a sealed record fails authentication,
+// because it has been tampered with or is out of sequence.
+var ErrAuthentication = errors.New("ringbuffer: record authentication failed")
+
+// maxSealedRecord is the maximum plaintext size of a sealed record.
+const maxSealedRecord = 16 << 10
+
+// sealedHeader is the size of the length prefix of a sealed record.
+const sealedHeader = 4
+
+// sealedSeq is the size of the sequence number of a sealed record.
+const sealedSeq = 8
+
+// SealedWriter encrypts and authenticates data written to a ring buffer.
+// Each Write is split into records of at most 16KB that are sealed
+// with an AEAD, such as AES-GCM or ChaCha20-Poly1305,
+// so the ring buffer only ever holds ciphertext.
+//
+// Each SealedWriter picks a random nonce base, and the nonce of a record is the base
+// xored with the number of the record. Both are carried in the clear in every record,
+// so reordered, replayed or modified records are detected by the SealedReader.
+// Since the base is random, nonces are not reused when a SealedWriter
+// is created again with the same key, for example when the writing process
+// of a ring buffer created by NewMmap or NewShared restarts.
+// The SealedReader then continues with the records of the new SealedWriter,
+// and a restarted SealedReader starts with the next complete record in the ring buffer.
+// What cannot be detected without persistent state is the replay of the records
+// of an earlier SealedWriter from its first record on, or of records
+// before the first one a SealedReader reads.
+//
+// A SealedWriter must be the only writer of the ring buffer.
+type SealedWriter struct {
+	mu    sync.Mutex
+	rb    *RingBuffer
+	aead  cipher.AEAD
+	base  []byte // Random nonce base, set by the first write.
+	seq   uint64
+	nonce []byte
+	buf   []byte
+}
+
+// NewSealedWriter returns a SealedWriter that writes sealed records to rb.
+// The nonce size of aead must be at least 8 bytes, and should be at least 12 bytes
+// so random nonce bases of different SealedWriters with the same key do not collide.
+func NewSealedWriter(rb *RingBuffer, aead cipher.AEAD) *SealedWriter {
+	return &SealedWriter{rb: rb, aead: aead}
+}
+
+// Write seals p and writes it to the ring buffer.
+// If not blocking ErrIsFull is returned if a record does not fit in the free space,
+// in which case no part of the record is written.
+func (w *SealedWriter) Write(p []byte) (n int, err error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.base == nil {
+		base := make([]byte, w.aead.NonceSize())
+		if _, err := rand.Read(base); err != nil {
+			return 0, err
+		}
+		w.base = base
+		w.nonce = make([]byte, len(base))
+	}
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxSealedRecord {
+			chunk = chunk[:maxSealedRecord]
+		}
+		if err = w.writeRecord(chunk); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		p = p[len(chunk):]
+	}
+	return n, nil
+}
+
+func (w *SealedWriter) writeRecord(p []byte) error {
+	hdr := sealedHeader + len(w.base) + sealedSeq
+	size := hdr + len(p) + w.aead.Overhead()
+	if !w.rb.block {
+		if size > w.rb.Capacity() {
+			return ErrTooMuchDataToWrite
+		}
+		if size > w.rb.Free() {
+			return ErrIsFull
+		}
+	}
+	if cap(w.buf) < size {
+		w.buf = make([]byte, size)
+	}
+	buf := w.buf[:hdr]
+	binary.BigEndian.PutUint32(buf, uint32(size-sealedHeader))
+	copy(buf[sealedHeader:], w.base)
+	binary.BigEndian.PutUint64(buf[hdr-sealedSeq:], w.seq)
+	sealedNonce(w.nonce, w.base, w.seq)
+	buf = w.aead.Seal(buf, w.nonce, p, buf[:hdr])
+	if _, err := w.rb.Write(buf); err != nil {
+		return err
+	}
+	w.seq++
+	return nil
+}
+
+// SealedReader reads and authenticates records written by a SealedWriter.
+//
+// A SealedReader must be the only reader of the ring buffer.
+type SealedReader struct {
+	mu        sync.Mutex
+	rb        *RingBuffer
+	aead      cipher.AEAD
+	base      []byte // Nonce base of the SealedWriter, set by the first record.
+	seq       uint64 // Number of the next record of the SealedWriter.
+	nonce     []byte
+	buf       []byte
+	plaintext []byte // Decrypted data not yet returned.
+}
+
+// NewSealedReader returns a SealedReader that reads sealed records from rb.
+// aead must use the same key as the SealedWriter.
+func NewSealedReader(rb *RingBuffer, aead cipher.AEAD) *SealedReader {
+	return &SealedReader{rb: rb, aead: aead}
+}
+
+// Read reads and decrypts data from the ring buffer.
+// ErrAuthentication is returned if a record fails authentication.
+// If not blocking ErrIsEmpty is returned if no complete record is buffered.
+func (r *SealedReader) Read(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.plaintext) == 0 {
+		if err = r.readRecord(); err != nil {
+			return 0, err
+		}
+	}
+	n = copy(p, r.plaintext)
+	r.plaintext = r.plaintext[n:]
+	return n, nil
+}
+
+func (r *SealedReader) readRecord() error {
+	var hdr [sealedHeader]byte
+	if !r.rb.block {
+		if _, err := r.rb.Peek(hdr[:]); err != nil {
+			return err
+		}
+		if r.rb.Length() < sealedHeader+int(binary.BigEndian.Uint32(hdr[:])) {
+			return ErrIsEmpty
+		}
+	}
+	if _, err := io.ReadFull(r.rb, hdr[:]); err != nil {
+		return err
+	}
+	size := int(binary.BigEndian.Uint32(hdr[:]))
+	ns := r.aead.NonceSize()
+	overhead := ns + sealedSeq + r.aead.Overhead()
+	if size < overhead || size > maxSealedRecord+overhead {
+		return ErrAuthentication
+	}
+	if cap(r.buf) < sealedHeader+size {
+		r.buf = make([]byte, sealedHeader+size)
+	}
+	buf := r.buf[:sealedHeader+size]
+	copy(buf, hdr[:])
+	if _, err := io.ReadFull(r.rb, buf[sealedHeader:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	n := sealedHeader + ns + sealedSeq
+	base, seq := buf[sealedHeader:n-sealedSeq], binary.BigEndian.Uint64(buf[n-sealedSeq:n])
+	switch {
+	case r.base == nil:
+		// The first record read, of any number.
+	case bytes.Equal(base, r.base):
+		if seq != r.seq {
+			return ErrAuthentication
+		}
+	case seq != 0:
+		// A new SealedWriter starts with its first record.
+		return ErrAuthentication
+	}
+	if r.nonce == nil {
+		r.nonce = make([]byte, ns)
+	}
+	sealedNonce(r.nonce, base, seq)
+	plaintext, err := r.aead.Open(buf[n:n], r.nonce, buf[n:], buf[:n])
+	if err != nil {
+		return ErrAuthentication
+	}
+	r.base = append(r.base[:0], base...)
+	r.seq = seq + 1
+	r.plaintext = plaintext
+	return nil
+}
+
+// sealedNonce stores the nonce of record seq of the SealedWriter with the given base in nonce.
+func sealedNonce(nonce, base []byte, seq uint64) {
+	copy(nonce, base)
+	tail := nonce[len(nonce)-8:]
+	binary.BigEndian.PutUint64(tail, binary.BigEndian.Uint64(tail)^seq)
+}
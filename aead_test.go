@@ -0,0 +1,139 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"io"
+	"testing"
+	"time"
+)
+
+func newTestAEAD(t *testing.T) cipher.AEAD {
+	t.Helper()
+	block, err := aes.NewCipher(bytes.Repeat([]byte{7}, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return aead
+}
+
+func TestSealed(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(1 << 10).SetBlocking(true)
+	w := NewSealedWriter(rb, newTestAEAD(t))
+	r := NewSealedReader(rb, newTestAEAD(t))
+
+	data := bytes.Repeat([]byte("secret payload "), 5000)
+	go func() {
+		w.Write([]byte("hello"))
+		w.Write(data)
+		rb.CloseWriter()
+	}()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !bytes.Equal(got, append([]byte("hello"), data...)) {
+		t.Fatalf("unexpected data")
+	}
+}
+
+func TestSealedNonBlocking(t *testing.T) {
+	rb := New(128)
+	w := NewSealedWriter(rb, newTestAEAD(t))
+	r := NewSealedReader(rb, newTestAEAD(t))
+
+	if _, err := r.Read(make([]byte, 10)); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bytes.Contains(rb.Bytes(nil), []byte("hello")) {
+		t.Fatalf("plaintext found in ring buffer")
+	}
+	if _, err := w.Write(bytes.Repeat([]byte("x"), 60)); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	if _, err := w.Write(bytes.Repeat([]byte("x"), 100)); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+
+	buf := make([]byte, 3)
+	n, err := r.Read(buf)
+	if err != nil || string(buf[:n]) != "hel" {
+		t.Fatalf("expected hel, nil; got %q, %v", buf[:n], err)
+	}
+	n, err = r.Read(buf)
+	if err != nil || string(buf[:n]) != "lo" {
+		t.Fatalf("expected lo, nil; got %q, %v", buf[:n], err)
+	}
+}
+
+func TestSealedTampering(t *testing.T) {
+	rb := New(64)
+	w := NewSealedWriter(rb, newTestAEAD(t))
+	r := NewSealedReader(rb, newTestAEAD(t))
+
+	w.Write([]byte("hello"))
+	rb.buf[6] ^= 1
+	if _, err := r.Read(make([]byte, 10)); err != ErrAuthentication {
+		t.Fatalf("expected ErrAuthentication, got %v", err)
+	}
+
+	// Replayed records are detected.
+	rb = New(64)
+	w = NewSealedWriter(rb, newTestAEAD(t))
+	r = NewSealedReader(rb, newTestAEAD(t))
+	w.Write([]byte("hello"))
+	record := rb.Bytes(nil)
+	if _, err := r.Read(make([]byte, 10)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rb.Write(record)
+	if _, err := r.Read(make([]byte, 10)); err != ErrAuthentication {
+		t.Fatalf("expected ErrAuthentication, got %v", err)
+	}
+}
+
+func TestSealedRestart(t *testing.T) {
+	rb := New(1 << 10)
+	r := NewSealedReader(rb, newTestAEAD(t))
+	buf := make([]byte, 10)
+
+	// A restarted writer uses different nonces with the same key.
+	NewSealedWriter(rb, newTestAEAD(t)).Write([]byte("one"))
+	first := rb.Bytes(nil)
+	w := NewSealedWriter(rb, newTestAEAD(t))
+	w.Write([]byte("two"))
+	if bytes.Equal(rb.Bytes(nil)[len(first):][4:16], first[4:16]) {
+		t.Fatalf("expected different nonce bases")
+	}
+	for _, want := range []string{"one", "two"} {
+		n, err := r.Read(buf)
+		if err != nil || string(buf[:n]) != want {
+			t.Fatalf("expected %s, got %q, %v", want, buf[:n], err)
+		}
+	}
+
+	// A restarted reader continues with the next record.
+	w.Write([]byte("three"))
+	r = NewSealedReader(rb, newTestAEAD(t))
+	if n, err := r.Read(buf); err != nil || string(buf[:n]) != "three" {
+		t.Fatalf("expected three, got %q, %v", buf[:n], err)
+	}
+
+	// A new writer must start with its first record.
+	w = NewSealedWriter(rb, newTestAEAD(t))
+	w.Write([]byte("four"))
+	rb.Reset()
+	w.Write([]byte("five"))
+	if _, err := r.Read(buf); err != ErrAuthentication {
+		t.Fatalf("expected ErrAuthentication, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_ZeroAllocs(t *testing.T) {
+	data := []byte("hello world")
+	buf := make([]byte, 64)
+	for _, block := range []bool{false, true} {
+		// A timeout only allocates when waiting.
+		rb := New(64).SetBlocking(block).WithTimeout(time.Minute)
+		empty := New(64).SetBlocking(block)
+		full := New(4).SetBlocking(block)
+		full.Write([]byte("full"))
+		tests := map[string]func(){
+			"Write/Read": func() {
+				rb.Write(data)
+				rb.Read(buf)
+			},
+			"TryWrite/TryRead": func() {
+				rb.TryWrite(data)
+				rb.TryRead(buf)
+			},
+			"WriteByte/ReadByte": func() {
+				rb.WriteByte('a')
+				rb.ReadByte()
+			},
+			"TryWriteByte/TryReadByte": func() {
+				rb.TryWriteByte('a')
+				rb.TryReadByte()
+			},
+			"WriteString/Peek": func() {
+				rb.WriteString("hello")
+				rb.Peek(buf)
+				rb.Read(buf)
+			},
+			"TryRead empty": func() {
+				empty.TryRead(buf)
+				empty.TryReadByte()
+			},
+			"TryWrite full": func() {
+				full.TryWrite(data)
+				full.TryWriteByte('a')
+			},
+		}
+		for name, f := range tests {
+			if allocs := testing.AllocsPerRun(100, f); allocs != 0 {
+				t.Errorf("%s (blocking %v): expected 0 allocations, got %v", name, block, allocs)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "time"
+
+// SetAtomicWrites sets whether each write is written completely or not at all,
+// so the data of concurrent writers never interleaves.
+// In blocking mode a write waits until there is enough free space for all of it,
+// even when smaller amounts of space are freed in the meantime.
+// Otherwise a write that does not fit returns ErrIsFull without writing anything.
+// A write larger than the buffer can never be written and returns ErrTooMuchDataToWrite.
+//
+// Atomic writes apply to Write, WriteString and TryWrite,
+// and have no effect in overwrite mode, where writes never wait.
+// A large write waiting for space may be overtaken by smaller writes.
+func (r *RingBuffer) SetAtomicWrites(atomic bool) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.atomicWrites = atomic
+	return r
+}
+
+// waitFree checks that n bytes can be written at once, if atomic writes are enabled,
+// waiting for free space if wait is true, bounded like waitReadDeadline.
+// Must be called when locked.
+func (r *RingBuffer) waitFree(n int, wait bool, deadline func() time.Time) error {
+	if !r.atomicWrites {
+		return nil
+	}
+	return r.waitSpace(n, wait, deadline)
+}
+
+// waitSpace checks that n bytes can be written at once like waitFree,
+// whether or not atomic writes are enabled.
+// Must be called when locked.
+func (r *RingBuffer) waitSpace(n int, wait bool, deadline func() time.Time) error {
+	if r.overwrite {
+		return nil
+	}
+	for {
+		if err := r.writeErr(); err != nil {
+			return err
+		}
+		if r.sealed {
+			return ErrSealed
+		}
+		r.grow(n)
+		if n > r.size {
+			return ErrTooMuchDataToWrite
+		}
+		if r.free() >= n {
+			return nil
+		}
+		r.stalls++
+		if !wait {
+			return ErrIsFull
+		}
+		if err := r.waitReadDeadline(deadline); err != nil {
+			return err
+		}
+	}
+}
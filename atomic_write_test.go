@@ -0,0 +1,71 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_AtomicWrites(t *testing.T) {
+	rb := New(8).SetAtomicWrites(true)
+	rb.Write([]byte("abcde"))
+	if n, err := rb.Write([]byte("fghi")); err != ErrIsFull || n != 0 {
+		t.Fatalf("expected ErrIsFull and nothing written, got %d, %v", n, err)
+	}
+	if n, err := rb.TryWrite([]byte("fghi")); err != ErrIsFull || n != 0 {
+		t.Fatalf("expected ErrIsFull and nothing written, got %d, %v", n, err)
+	}
+	if n, err := rb.Write(make([]byte, 9)); err != ErrTooMuchDataToWrite || n != 0 {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %d, %v", n, err)
+	}
+	if n, err := rb.WriteString("fgh"); err != nil || n != 3 {
+		t.Fatalf("expected 3 bytes written, got %d, %v", n, err)
+	}
+	if rb.Length() != 8 {
+		t.Fatalf("expected length 8, got %d", rb.Length())
+	}
+}
+
+func TestRingBuffer_AtomicWritesConcurrent(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(32).SetBlocking(true).SetAtomicWrites(true)
+	const writers, records = 4, 200
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rec := bytes.Repeat([]byte{'a' + byte(i)}, 5+i*3)
+			for j := 0; j < records; j++ {
+				if _, err := rb.Write(rec); err != nil {
+					t.Errorf("expected nil, got %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	go func() {
+		wg.Wait()
+		rb.CloseWriter()
+	}()
+
+	data, err := io.ReadAll(rb)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	// Every run of a writer's byte must be a whole number of its records.
+	for len(data) > 0 {
+		c := data[0]
+		i := 0
+		for i < len(data) && data[i] == c {
+			i++
+		}
+		if size := 5 + int(c-'a')*3; i%size != 0 {
+			t.Fatalf("expected runs of %c to be multiples of %d, got %d", c, size, i)
+		}
+		data = data[i:]
+	}
+}
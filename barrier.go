@@ -0,0 +1,76 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"io"
+)
+
+// Offset returns the current absolute write offset,
+// the total number of bytes written since the ring buffer was created or Reset.
+// Pass it to WaitConsumed to wait until everything written so far has been read.
+// It is the same as WriteOffset.
+func (r *RingBuffer) Offset() int64 {
+	return r.WriteOffset()
+}
+
+// WriteOffset returns the total number of bytes written
+// since the ring buffer was created or Reset.
+// Unlike the write position it keeps increasing when the buffer wraps.
+func (r *RingBuffer) WriteOffset() int64 {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.written
+}
+
+// ReadOffset returns the total number of bytes read
+// since the ring buffer was created or Reset.
+// Unlike the read position it keeps increasing when the buffer wraps.
+// WriteOffset minus ReadOffset is always the buffered length.
+// Rewind moves it back to the offset at which the ring buffer was sealed.
+func (r *RingBuffer) ReadOffset() int64 {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.consumed()
+}
+
+// consumed returns the absolute offset of the next byte to be read.
+// Must be called when locked.
+func (r *RingBuffer) consumed() int64 {
+	return r.written - int64(r.length())
+}
+
+// WaitConsumed waits until the reader has consumed all bytes before offset,
+// as returned by Offset. Unlike Flush it does not wait for the buffer to be empty,
+// so data written after offset does not delay it.
+// If ctx is done first, ctx.Err() is returned; ctx must not be nil.
+// If not blocking ErrIsNotEmpty will be returned if the bytes have not been consumed yet.
+// If the writer is closed and all data has been read before offset is reached, io.EOF is returned.
+func (r *RingBuffer) WaitConsumed(ctx context.Context, offset int64) error {
+	r.mu.Lock()
+	defer r.unlock()
+	r.begin(OpFlush)
+	defer r.end(OpFlush)
+	if r.block {
+		defer r.wakeReadOnDone(ctx)()
+	}
+	for r.consumed() < offset {
+		if err := r.readErr(true); err != nil {
+			return err
+		}
+		if !r.block {
+			return ErrIsNotEmpty
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.readCond.Wait()
+	}
+	if err := r.readErr(true); err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
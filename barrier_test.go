@@ -0,0 +1,86 @@
+package ringbuffer
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_WaitConsumed(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(16).SetBlocking(true)
+	rb.Write([]byte("hello"))
+	off := rb.Offset()
+	if off != 5 {
+		t.Fatalf("expected offset 5, got %d", off)
+	}
+	rb.Write([]byte("world"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := rb.WaitConsumed(ctx, off); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		buf := make([]byte, 5)
+		io.ReadFull(rb, buf)
+	}()
+	if err := rb.WaitConsumed(context.Background(), off); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if rb.Length() != 5 {
+		t.Fatalf("expected 5 bytes left unread, got %d", rb.Length())
+	}
+
+	rb.CloseWriter()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		io.ReadAll(rb)
+	}()
+	if err := rb.WaitConsumed(context.Background(), rb.Offset()+1); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestRingBuffer_WaitConsumedNonBlocking(t *testing.T) {
+	rb := New(16)
+	rb.Write([]byte("hello"))
+	if err := rb.WaitConsumed(context.Background(), rb.Offset()); err != ErrIsNotEmpty {
+		t.Fatalf("expected ErrIsNotEmpty, got %v", err)
+	}
+	rb.Read(make([]byte, 5))
+	if err := rb.WaitConsumed(context.Background(), rb.Offset()); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+}
+
+func TestRingBuffer_Offsets(t *testing.T) {
+	rb := New(8)
+	buf := make([]byte, 6)
+	for i := 0; i < 5; i++ {
+		if _, err := rb.Write([]byte("abcdef")); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		if _, err := rb.Read(buf); err != nil {
+			t.Fatalf("read failed: %v", err)
+		}
+	}
+	rb.Write([]byte("abc"))
+	if got := rb.WriteOffset(); got != 33 {
+		t.Fatalf("expected write offset 33, got %d", got)
+	}
+	if got := rb.ReadOffset(); got != 30 {
+		t.Fatalf("expected read offset 30, got %d", got)
+	}
+	if got := rb.Offset(); got != rb.WriteOffset() {
+		t.Fatalf("expected Offset to equal WriteOffset, got %d", got)
+	}
+
+	rb.Reset()
+	if rb.WriteOffset() != 0 || rb.ReadOffset() != 0 {
+		t.Fatalf("expected offsets to be reset, got %d and %d", rb.WriteOffset(), rb.ReadOffset())
+	}
+}
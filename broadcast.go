@@ -0,0 +1,145 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"io"
+)
+
+// A BroadcastReader is an independent read cursor on a ring buffer.
+// Every BroadcastReader of a ring buffer reads every byte written to it,
+// and buffered data is only released to writers once all of them have read it,
+// so the slowest reader holds back the writers.
+type BroadcastReader struct {
+	rb      *RingBuffer
+	off     int64 // Absolute offset of the next byte to read.
+	skipped int64 // Bytes discarded before this reader read them.
+	closed  bool
+}
+
+// NewReader returns a new BroadcastReader that starts at the oldest buffered data.
+// A reader added before anything is written sees every byte written.
+// While readers are attached, the ring buffer itself should not be read:
+// data read directly is skipped by the readers that have not read it yet.
+// Readers must be closed when no longer used, so they don't hold back writers.
+func (r *RingBuffer) NewReader() *BroadcastReader {
+	r.mu.Lock()
+	defer r.unlock()
+	br := &BroadcastReader{rb: r, off: r.consumed()}
+	r.readers = append(r.readers, br)
+	return br
+}
+
+// Read reads up to len(p) bytes that this reader has not read yet into p.
+// In blocking mode it waits for data to be written,
+// and otherwise it returns ErrIsEmpty if there is none.
+// It returns io.EOF once the writer is closed and all data has been read.
+func (br *BroadcastReader) Read(p []byte) (n int, err error) {
+	r := br.rb
+	r.mu.Lock()
+	defer r.unlock()
+	if br.closed {
+		return 0, io.ErrClosedPipe
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	for {
+		if r.err != nil && r.err != io.EOF {
+			return 0, r.err
+		}
+		br.catchUp()
+		if avail := r.written - br.off; avail > 0 {
+			if int64(len(p)) > avail {
+				p = p[:avail]
+			}
+			n = r.peekAt(p, int(br.off-r.consumed()))
+			br.off += int64(n)
+			r.releaseReaders()
+			return n, nil
+		}
+		if r.err == io.EOF || r.sealed {
+			return 0, io.EOF
+		}
+		if !r.block {
+			return 0, ErrIsEmpty
+		}
+		if !r.waitWrite() {
+			return 0, context.DeadlineExceeded
+		}
+	}
+}
+
+// Lag returns the number of bytes written that this reader has not read yet.
+func (br *BroadcastReader) Lag() int64 {
+	r := br.rb
+	r.mu.Lock()
+	defer r.unlock()
+	if br.closed {
+		return 0
+	}
+	br.catchUp()
+	return r.written - br.off
+}
+
+// Skipped returns the number of bytes this reader never read,
+// because they were read from the ring buffer directly or evicted in overwrite mode.
+func (br *BroadcastReader) Skipped() int64 {
+	br.rb.mu.Lock()
+	defer br.rb.unlock()
+	br.catchUp()
+	return br.skipped
+}
+
+// Close detaches the reader from the ring buffer,
+// releasing the data only it was holding back.
+// Reads after Close return io.ErrClosedPipe.
+func (br *BroadcastReader) Close() error {
+	r := br.rb
+	r.mu.Lock()
+	defer r.unlock()
+	if br.closed {
+		return nil
+	}
+	br.closed = true
+	for i, c := range r.readers {
+		if c == br {
+			r.readers = append(r.readers[:i], r.readers[i+1:]...)
+			break
+		}
+	}
+	r.releaseReaders()
+	return nil
+}
+
+// catchUp moves the reader to the oldest buffered data
+// if the data it was about to read is gone.
+// Must be called when locked.
+func (br *BroadcastReader) catchUp() {
+	if start := br.rb.consumed(); br.off < start {
+		br.skipped += start - br.off
+		br.off = start
+	}
+}
+
+// releaseReaders consumes the data that all readers have read.
+// Must be called when locked.
+func (r *RingBuffer) releaseReaders() {
+	if len(r.readers) == 0 {
+		return
+	}
+	low := r.readers[0].off
+	for _, c := range r.readers[1:] {
+		if c.off < low {
+			low = c.off
+		}
+	}
+	if n := low - r.consumed(); n > 0 {
+		r.discard(int(n))
+	}
+}
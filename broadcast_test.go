@@ -0,0 +1,107 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBroadcastReader(t *testing.T) {
+	rb := New(8)
+	a, b := rb.NewReader(), rb.NewReader()
+	rb.Write([]byte("abcdef"))
+
+	buf := make([]byte, 8)
+	if n, err := a.Read(buf); err != nil || string(buf[:n]) != "abcdef" {
+		t.Fatalf("expected abcdef, got %q, %v", buf[:n], err)
+	}
+	if a.Lag() != 0 || b.Lag() != 6 {
+		t.Fatalf("expected lags 0 and 6, got %d and %d", a.Lag(), b.Lag())
+	}
+	// b holds back the data.
+	if rb.Free() != 2 {
+		t.Fatalf("expected 2 bytes free, got %d", rb.Free())
+	}
+	if _, err := a.Read(buf); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	if n, err := b.Read(buf[:4]); err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", buf[:n], err)
+	}
+	if rb.Free() != 6 {
+		t.Fatalf("expected 6 bytes free, got %d", rb.Free())
+	}
+
+	// Closing b releases its data.
+	b.Close()
+	if !rb.IsEmpty() {
+		t.Fatalf("expected empty buffer, got length %d", rb.Length())
+	}
+	if _, err := b.Read(buf); err != io.ErrClosedPipe {
+		t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+	}
+
+	rb.Write([]byte("gh"))
+	rb.CloseWriter()
+	if n, err := a.Read(buf); err != nil || string(buf[:n]) != "gh" {
+		t.Fatalf("expected gh, got %q, %v", buf[:n], err)
+	}
+	if _, err := a.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestBroadcastReader_Skipped(t *testing.T) {
+	rb := New(8)
+	br := rb.NewReader()
+	rb.Write([]byte("abcdef"))
+	buf := make([]byte, 4)
+	rb.Read(buf)
+	if br.Skipped() != 4 || br.Lag() != 2 {
+		t.Fatalf("expected 4 bytes skipped and lag 2, got %d and %d", br.Skipped(), br.Lag())
+	}
+	if n, err := br.Read(buf); err != nil || string(buf[:n]) != "ef" {
+		t.Fatalf("expected ef, got %q, %v", buf[:n], err)
+	}
+}
+
+func TestBroadcastReader_Blocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(16).SetBlocking(true)
+	readers := []*BroadcastReader{rb.NewReader(), rb.NewReader(), rb.NewReader()}
+	data := bytes.Repeat([]byte("0123456789"), 100)
+
+	var wg sync.WaitGroup
+	got := make([][]byte, len(readers))
+	for i, br := range readers {
+		wg.Add(1)
+		go func(i int, br *BroadcastReader) {
+			defer wg.Done()
+			got[i], _ = io.ReadAll(br)
+		}(i, br)
+	}
+	if _, err := rb.Write(data); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	rb.CloseWriter()
+	wg.Wait()
+	for i := range got {
+		if !bytes.Equal(got[i], data) {
+			t.Fatalf("expected reader %d to read all data, got %d bytes", i, len(got[i]))
+		}
+	}
+}
+
+func TestBroadcastReader_Reset(t *testing.T) {
+	rb := New(8)
+	br := rb.NewReader()
+	rb.Write([]byte("abc"))
+	rb.Reset()
+	rb.Write([]byte("de"))
+	buf := make([]byte, 8)
+	if n, err := br.Read(buf); err != nil || string(buf[:n]) != "de" {
+		t.Fatalf("expected de, got %q, %v", buf[:n], err)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// A Stage is a transform step of a pipeline built with Chain.
+// It reads its input from r and writes its output to w.
+// The stage should return when r returns io.EOF.
+type Stage func(r io.Reader, w io.Writer) error
+
+// Chain runs the stages concurrently as a pipeline.
+// The first stage reads from src and the last stage writes to dst.
+// Consecutive stages are connected through a ring buffer of the given size.
+// With no stages, src is copied to dst through a ring buffer.
+//
+// When a stage returns, its output is closed, so the next stage sees io.EOF,
+// or the error returned by the stage.
+// Its input is closed as well, so the previous stage will fail on further writes.
+// If ctx is canceled all ring buffers are closed with the context error.
+//
+// Chain returns when all stages have returned.
+// The first error encountered is returned.
+func Chain(ctx context.Context, size int, src io.Reader, dst io.Writer, stages ...Stage) error {
+	if len(stages) == 0 {
+		rb := New(size)
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			select {
+			case <-ctx.Done():
+				rb.CloseWithError(ctx.Err())
+			case <-done:
+			}
+		}()
+		_, err := rb.Copy(dst, src)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		return err
+	}
+
+	buffers := make([]*RingBuffer, len(stages)-1)
+	readers := make([]*PipeReader, len(buffers))
+	writers := make([]*PipeWriter, len(buffers))
+	for i := range buffers {
+		buffers[i] = New(size)
+		readers[i], writers[i] = buffers[i].Pipe()
+	}
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	setErr := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			setErr(ctx.Err())
+			for _, rb := range buffers {
+				rb.CloseWithError(ctx.Err())
+			}
+		case <-done:
+		}
+	}()
+
+	for i, stage := range stages {
+		var (
+			in  io.Reader = src
+			out io.Writer = dst
+		)
+		if i > 0 {
+			in = readers[i-1]
+		}
+		if i < len(writers) {
+			out = writers[i]
+		}
+		wg.Add(1)
+		go func(i int, stage Stage, in io.Reader, out io.Writer) {
+			defer wg.Done()
+			err := stage(in, out)
+			if err != nil {
+				setErr(err)
+			}
+			if i < len(writers) {
+				writers[i].CloseWithError(err)
+			}
+			if i > 0 {
+				if err == nil {
+					err = io.ErrClosedPipe
+				}
+				readers[i-1].CloseWithError(err)
+			}
+		}(i, stage, in, out)
+	}
+	wg.Wait()
+	close(done)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if firstErr == nil {
+		return ctx.Err()
+	}
+	return firstErr
+}
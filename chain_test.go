@@ -0,0 +1,104 @@
+package ringbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func upperStage(r io.Reader, w io.Writer) error {
+	buf := make([]byte, 7)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(bytes.ToUpper(buf[:n])); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func grepStage(substr string) Stage {
+	return func(r io.Reader, w io.Writer) error {
+		s := bufio.NewScanner(r)
+		for s.Scan() {
+			if strings.Contains(s.Text(), substr) {
+				if _, err := io.WriteString(w, s.Text()+"\n"); err != nil {
+					return err
+				}
+			}
+		}
+		return s.Err()
+	}
+}
+
+func TestChain(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	var input strings.Builder
+	for i := 0; i < 1000; i++ {
+		input.WriteString("keep this line\ndrop this line\n")
+	}
+
+	var out bytes.Buffer
+	err := Chain(context.Background(), 64, strings.NewReader(input.String()), &out, upperStage, grepStage("KEEP"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := strings.Repeat("KEEP THIS LINE\n", 1000); out.String() != want {
+		t.Fatalf("unexpected output: %q", out.String())
+	}
+
+	out.Reset()
+	err = Chain(context.Background(), 64, strings.NewReader(input.String()), &out)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != input.String() {
+		t.Fatalf("unexpected output")
+	}
+}
+
+func TestChainError(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	testErr := errors.New("test error")
+	failing := func(r io.Reader, w io.Writer) error {
+		r.Read(make([]byte, 10))
+		return testErr
+	}
+	src := strings.NewReader(strings.Repeat("x", 1<<20))
+	err := Chain(context.Background(), 64, src, io.Discard, upperStage, failing, upperStage)
+	if err != testErr {
+		t.Fatalf("expected %v, got %v", testErr, err)
+	}
+}
+
+func TestChainCancel(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	ctx, cancel := context.WithCancel(context.Background())
+	blocked := func(r io.Reader, w io.Writer) error {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+		pw.Close()
+	}()
+	err := Chain(ctx, 64, pr, io.Discard, blocked, blocked)
+	if err != context.Canceled {
+		t.Fatalf("expected %v, got %v", context.Canceled, err)
+	}
+}
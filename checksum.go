@@ -0,0 +1,83 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+)
+
+// ErrChecksum is returned by ReadMsg when a record does not match its checksum.
+var ErrChecksum = errors.New("record checksum mismatch")
+
+// msgTrailer is the size of the checksum appended to records in checksum mode.
+const msgTrailer = 4
+
+// crcTable is the CRC-32C table of record checksums.
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SetChecksum sets whether the records written by WriteMsg and PacketConns
+// carry a CRC-32C checksum of their length prefix and payload,
+// which ReadMsg verifies, so corrupted records of a memory-mapped
+// or shared ring buffer are detected instead of parsed.
+// A record that does not match its checksum is discarded and ErrChecksum is returned;
+// if its length prefix was corrupted, the records after it cannot be read either.
+// Each record takes 4 more bytes of buffer space.
+//
+// Writers and readers must agree on the mode, so it can only be changed
+// while the buffer is empty, ErrIsNotEmpty is returned otherwise.
+// The mode is stored in the header of a memory-mapped file.
+func (r *RingBuffer) SetChecksum(enabled bool) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if enabled == r.checksum {
+		return nil
+	}
+	if r.length() > 0 || len(r.pending) > 0 {
+		return ErrIsNotEmpty
+	}
+	r.checksum = enabled
+	r.storeHeader()
+	return nil
+}
+
+// msgTrailerSize returns the size of the trailer of a record, 0 unless in checksum mode.
+// Must be called when locked.
+func (r *RingBuffer) msgTrailerSize() int {
+	if r.checksum {
+		return msgTrailer
+	}
+	return 0
+}
+
+// appendMsgChecksum appends the checksum of the record with header hdr and payload p to b.
+func appendMsgChecksum(b, hdr, p []byte) []byte {
+	crc := crc32.Update(crc32.Update(0, crcTable, hdr), crcTable, p)
+	return binary.BigEndian.AppendUint32(b, crc)
+}
+
+// checkMsg reports whether the buffered record with a payload of size bytes
+// matches its checksum. It is always true unless in checksum mode.
+// Must be called when locked.
+func (r *RingBuffer) checkMsg(size int) bool {
+	if !r.checksum {
+		return true
+	}
+	var buf [512]byte
+	var crc uint32
+	for off, n := 0, msgHeader+size; off < n; {
+		p := buf[:]
+		if len(p) > n-off {
+			p = p[:n-off]
+		}
+		c := r.peekAt(p, off)
+		crc = crc32.Update(crc, crcTable, p[:c])
+		off += c
+	}
+	var trailer [msgTrailer]byte
+	r.peekAt(trailer[:], msgHeader+size)
+	return binary.BigEndian.Uint32(trailer[:]) == crc
+}
@@ -0,0 +1,59 @@
+package ringbuffer
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRingBuffer_Checksum(t *testing.T) {
+	rb := New(32)
+	if err := rb.SetChecksum(true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rb.WriteMsg([]byte("hello"))
+	if rb.Length() != 13 {
+		t.Fatalf("expected length 13, got %d", rb.Length())
+	}
+	if err := rb.SetChecksum(false); err != ErrIsNotEmpty {
+		t.Fatalf("expected ErrIsNotEmpty, got %v", err)
+	}
+	p := make([]byte, 16)
+	n, err := rb.ReadMsg(p)
+	if err != nil || string(p[:n]) != "hello" {
+		t.Fatalf("expected hello, got %q, %v", p[:n], err)
+	}
+
+	rb.WriteMsg([]byte("abc"))
+	rb.WriteMsg([]byte("def"))
+	rb.buf[(rb.r+5)%rb.size] ^= 1
+	if _, err := rb.ReadMsg(p); err != ErrChecksum {
+		t.Fatalf("expected ErrChecksum, got %v", err)
+	}
+	n, err = rb.ReadMsg(p)
+	if err != nil || string(p[:n]) != "def" {
+		t.Fatalf("expected def after the corrupted record, got %q, %v", p[:n], err)
+	}
+
+}
+
+func TestRingBuffer_ChecksumMmap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	rb, err := NewMmap(path, 32)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rb.SetChecksum(true)
+	rb.WriteMsg([]byte("persisted"))
+	rb.Release()
+
+	rb, err = NewMmap(path, 32)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	defer rb.Release()
+	p := make([]byte, 16)
+	n, err := rb.ReadMsg(p)
+	if err != nil || string(p[:n]) != "persisted" {
+		t.Fatalf("expected persisted, got %q, %v", p[:n], err)
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "io"
+
+// Clone returns an independent ring buffer with the same size, modes and timeouts,
+// holding a copy of the unread data, without disturbing readers of r.
+// If the writer of r has been closed or r is sealed, the writer of the clone is closed,
+// and the offsets of the clone continue from those of r.
+// The clone of an encrypted ring buffer is encrypted with the same key.
+//
+// Hashes, the accountant, callbacks, broadcast readers, statistics
+// and data written out of order with WriteAtOffset are not copied.
+func (r *RingBuffer) Clone() *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	c := New(r.size).SetBlocking(r.block)
+	n := r.peekAt(c.buf, 0)
+	if n < c.size {
+		c.w = n
+	} else {
+		c.isFull = n > 0
+	}
+	c.written = r.written
+	if r.enc != nil {
+		enc, err := newEncryption(r.enc.block)
+		if err != nil {
+			panic(err)
+		}
+		c.enc = enc
+		c.cryptBuf(0, n, c.written-int64(n))
+	}
+	if r.err == io.EOF || r.sealed {
+		c.err = io.EOF
+	}
+	c.rTimeout = r.rTimeout
+	c.wTimeout = r.wTimeout
+	c.wipe = r.wipe
+	c.maxSize = r.maxSize
+	c.overwrite = r.overwrite
+	c.atomicWrites = r.atomicWrites
+	c.checksum = r.checksum
+	c.writeToChunk = r.writeToChunk
+	c.limit = r.limit
+	c.name = r.name
+	c.rLabels = r.rLabels
+	c.wLabels = r.wLabels
+	return c
+}
@@ -0,0 +1,49 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Clone(t *testing.T) {
+	rb := New(8).SetBlocking(true).WithTimeout(time.Second).SetOverwrite(true)
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghijkl"))
+
+	c := rb.Clone()
+	if c.Capacity() != 8 || !c.block || c.rTimeout != time.Second || !c.overwrite {
+		t.Fatalf("expected the size and modes to be copied, got %+v", c)
+	}
+	if c.ReadOffset() != rb.ReadOffset() || c.WriteOffset() != rb.WriteOffset() {
+		t.Fatalf("expected offsets %d and %d, got %d and %d",
+			rb.ReadOffset(), rb.WriteOffset(), c.ReadOffset(), c.WriteOffset())
+	}
+
+	buf := make([]byte, 8)
+	if n, err := c.Read(buf); err != nil || string(buf[:n]) != "efghijkl" {
+		t.Fatalf("expected efghijkl, got %q, %v", buf[:n], err)
+	}
+	// The original is not disturbed.
+	if rb.Length() != 8 {
+		t.Fatalf("expected length 8, got %d", rb.Length())
+	}
+	c.Write([]byte("x"))
+	if n, _ := rb.Peek(buf); string(buf[:n]) != "efghijkl" {
+		t.Fatalf("expected efghijkl, got %q", buf[:n])
+	}
+}
+
+func TestRingBuffer_CloneClosed(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("ab"))
+	rb.CloseWriter()
+	c := rb.Clone()
+	if b, err := io.ReadAll(c); err != nil || string(b) != "ab" {
+		t.Fatalf("expected ab, got %q, %v", b, err)
+	}
+	if _, err := c.Write([]byte("c")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// CloseReason describes how a ring buffer was closed.
+type CloseReason int
+
+const (
+	// NotClosed means the ring buffer is open.
+	NotClosed CloseReason = iota
+	// ClosedByWriter means the writer closed the buffer with CloseWriter or a nil error.
+	ClosedByWriter
+	// ClosedByReader means the reader closed the buffer,
+	// with CloseRead, ReadCloser.Close or PipeReader.Close.
+	ClosedByReader
+	// ClosedByTimeout means a blocking read or write timed out.
+	ClosedByTimeout
+	// ClosedByError means the buffer was closed with any other error.
+	ClosedByError
+)
+
+// String returns a description of the close reason.
+func (c CloseReason) String() string {
+	switch c {
+	case NotClosed:
+		return "not closed"
+	case ClosedByWriter:
+		return "closed by writer"
+	case ClosedByReader:
+		return "closed by reader"
+	case ClosedByTimeout:
+		return "closed by timeout"
+	case ClosedByError:
+		return "closed by error"
+	}
+	return "unknown"
+}
+
+// CloseState reports how the ring buffer was closed, together with the error it was closed with.
+// If the ring buffer is open, NotClosed and a nil error are returned.
+//
+// Note that a writer that has closed the buffer may still have data left to be read.
+func (r *RingBuffer) CloseState() (CloseReason, error) {
+	r.mu.Lock()
+	err, rClosed := r.err, r.rClosed
+	r.unlock()
+	switch {
+	case (err == nil || err == io.EOF) && rClosed != nil:
+		return ClosedByReader, rClosed
+	case err == nil:
+		return NotClosed, nil
+	case err == io.EOF:
+		return ClosedByWriter, err
+	case errors.Is(err, ErrReaderClosed), errors.Is(err, io.ErrClosedPipe):
+		return ClosedByReader, err
+	case errors.Is(err, context.DeadlineExceeded):
+		return ClosedByTimeout, err
+	}
+	return ClosedByError, err
+}
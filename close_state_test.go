@@ -0,0 +1,40 @@
+package ringbuffer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_CloseState(t *testing.T) {
+	testErr := errors.New("test error")
+	tests := []struct {
+		close  func(rb *RingBuffer)
+		reason CloseReason
+		err    error
+	}{
+		{close: func(rb *RingBuffer) {}, reason: NotClosed},
+		{close: func(rb *RingBuffer) { rb.CloseWriter() }, reason: ClosedByWriter, err: io.EOF},
+		{close: func(rb *RingBuffer) { rb.CloseWithError(nil) }, reason: ClosedByWriter, err: io.EOF},
+		{close: func(rb *RingBuffer) { rb.ReadCloser().Close() }, reason: ClosedByReader, err: ErrReaderClosed},
+		{close: func(rb *RingBuffer) {
+			r, _ := rb.Pipe()
+			r.Close()
+		}, reason: ClosedByReader, err: io.ErrClosedPipe},
+		{close: func(rb *RingBuffer) {
+			rb.SetBlocking(true).WithReadTimeout(time.Millisecond)
+			rb.Read(make([]byte, 1))
+		}, reason: ClosedByTimeout, err: context.DeadlineExceeded},
+		{close: func(rb *RingBuffer) { rb.CloseWithError(testErr) }, reason: ClosedByError, err: testErr},
+	}
+	for i, test := range tests {
+		rb := New(10)
+		test.close(rb)
+		reason, err := rb.CloseState()
+		if reason != test.reason || err != test.err {
+			t.Errorf("%d: expected %v, %v; got %v, %v", i, test.reason, test.err, reason, err)
+		}
+	}
+}
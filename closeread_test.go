@@ -0,0 +1,49 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_CloseRead(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abc"))
+	rb.CloseRead(nil)
+	if _, err := rb.Write([]byte("d")); err != ErrReaderClosed {
+		t.Fatalf("expected ErrReaderClosed, got %v", err)
+	}
+	if reason, err := rb.CloseState(); reason != ClosedByReader || err != ErrReaderClosed {
+		t.Fatalf("expected closed by reader, got %v, %v", reason, err)
+	}
+	buf := make([]byte, 8)
+	n, err := rb.Read(buf)
+	if err != nil || string(buf[:n]) != "abc" {
+		t.Fatalf("expected abc, got %q, %v", buf[:n], err)
+	}
+	if _, err := rb.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	rb.Reset()
+	if _, err := rb.Write([]byte("d")); err != nil {
+		t.Fatalf("expected Reset to reopen, got %v", err)
+	}
+}
+
+func TestRingBuffer_CloseReadBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true)
+	rb.Write([]byte("abcd"))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.CloseRead(io.ErrClosedPipe)
+	}()
+	if _, err := rb.Write([]byte("e")); err != io.ErrClosedPipe {
+		t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+	}
+	b, err := io.ReadAll(rb)
+	if err != nil || string(b) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", b, err)
+	}
+}
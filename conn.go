@@ -0,0 +1,139 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Conn is a net.Conn that exchanges a byte stream through ring buffers,
+// one for each direction.
+// Unlike net.Pipe, writes complete as soon as the data fits in the ring buffer
+// of the peer, without waiting for the peer to read it.
+type Conn struct {
+	rx, tx       *RingBuffer
+	laddr, raddr net.Addr
+	closed       atomic.Bool
+
+	mu        sync.Mutex
+	rDeadline time.Time
+	wDeadline time.Time
+	rdl, wdl  func() time.Time
+}
+
+// NewConnPair returns two connected Conns, each backed by a
+// ring buffer of the given size for the data sent to it.
+// Data written to one is read from the other.
+func NewConnPair(size int) (*Conn, *Conn) {
+	a, b := New(size).SetBlocking(true), New(size).SetBlocking(true)
+	addrA, addrB := PacketAddr("conn-a"), PacketAddr("conn-b")
+	return newConn(a, b, addrA, addrB), newConn(b, a, addrB, addrA)
+}
+
+func newConn(rx, tx *RingBuffer, laddr, raddr net.Addr) *Conn {
+	c := &Conn{rx: rx, tx: tx, laddr: laddr, raddr: raddr}
+	c.rdl = func() time.Time { return c.deadline(&c.rDeadline) }
+	c.wdl = func() time.Time { return c.deadline(&c.wDeadline) }
+	return c
+}
+
+// deadline returns the deadline *t, or a deadline in the past if the connection is closed,
+// so blocked operations return.
+func (c *Conn) deadline(t *time.Time) time.Time {
+	if c.closed.Load() {
+		return time.Unix(1, 0)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *t
+}
+
+// Read reads data sent by the peer into p.
+// It blocks until data is available or the read deadline passes,
+// and returns io.EOF once the peer has closed the connection and all data has been read.
+func (c *Conn) Read(p []byte) (n int, err error) {
+	if c.closed.Load() {
+		return 0, net.ErrClosed
+	}
+	c.rx.mu.Lock()
+	defer c.rx.unlock()
+	n, err = c.rx.readUntil(p, c.rdl)
+	if err != nil && err != io.EOF && c.closed.Load() {
+		err = net.ErrClosed
+	}
+	return n, err
+}
+
+// Write writes p to the peer.
+// It blocks until all of p fits in the ring buffer of the peer
+// or the write deadline passes.
+// It returns io.ErrClosedPipe if the peer has closed the connection.
+func (c *Conn) Write(p []byte) (n int, err error) {
+	if c.closed.Load() {
+		return 0, net.ErrClosed
+	}
+	c.tx.mu.Lock()
+	defer c.tx.unlock()
+	n, err = c.tx.writeUntil(p, c.wdl)
+	if err != nil && c.closed.Load() {
+		err = net.ErrClosed
+	}
+	return n, err
+}
+
+// Close closes the connection.
+// Blocked and future reads and writes on it return net.ErrClosed.
+// The peer reads the remaining data and then io.EOF,
+// and its writes return io.ErrClosedPipe.
+func (c *Conn) Close() error {
+	if c.closed.Swap(true) {
+		return net.ErrClosed
+	}
+	c.rx.CloseWithError(io.ErrClosedPipe)
+	c.tx.CloseWriter()
+	return nil
+}
+
+// LocalAddr returns the local address.
+func (c *Conn) LocalAddr() net.Addr { return c.laddr }
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr { return c.raddr }
+
+// SetDeadline sets the read and write deadlines.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for reads, including blocked reads.
+// When it passes reads return os.ErrDeadlineExceeded,
+// without closing the connection. A zero t disables the deadline.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.rDeadline = t
+	c.mu.Unlock()
+	c.rx.mu.Lock()
+	c.rx.writeCond.Broadcast()
+	c.rx.unlock()
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for writes, including blocked writes.
+// When it passes writes return os.ErrDeadlineExceeded,
+// without closing the connection. A zero t disables the deadline.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.wDeadline = t
+	c.mu.Unlock()
+	c.tx.mu.Lock()
+	c.tx.readCond.Broadcast()
+	c.tx.unlock()
+	return nil
+}
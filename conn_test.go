@@ -0,0 +1,86 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+var _ net.Conn = (*Conn)(nil)
+
+func TestConn(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, b := NewConnPair(8)
+	if a.LocalAddr() != b.RemoteAddr() || a.RemoteAddr() != b.LocalAddr() {
+		t.Fatalf("expected matching addresses, got %v %v %v %v", a.LocalAddr(), a.RemoteAddr(), b.LocalAddr(), b.RemoteAddr())
+	}
+
+	go func() {
+		a.Write([]byte("hello, world"))
+		a.Close()
+	}()
+	got, err := io.ReadAll(b)
+	if err != nil || string(got) != "hello, world" {
+		t.Fatalf("expected hello, world, got %q, %v", got, err)
+	}
+	if _, err := b.Write([]byte("x")); err != io.ErrClosedPipe {
+		t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+	}
+	if _, err := a.Read(make([]byte, 1)); err != net.ErrClosed {
+		t.Fatalf("expected net.ErrClosed, got %v", err)
+	}
+	if err := a.Close(); err != net.ErrClosed {
+		t.Fatalf("expected net.ErrClosed, got %v", err)
+	}
+}
+
+func TestConn_Deadlines(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, b := NewConnPair(4)
+	defer a.Close()
+	defer b.Close()
+
+	a.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := a.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	a.SetWriteDeadline(time.Now().Add(20 * time.Millisecond))
+	if n, err := a.Write([]byte("abcdef")); !errors.Is(err, os.ErrDeadlineExceeded) || n != 4 {
+		t.Fatalf("expected 4 bytes and os.ErrDeadlineExceeded, got %d, %v", n, err)
+	}
+
+	// The connection is still usable.
+	a.SetDeadline(time.Time{})
+	buf := make([]byte, 4)
+	if n, err := b.Read(buf); err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", buf[:n], err)
+	}
+	go b.Write([]byte("xy"))
+	if n, err := a.Read(buf); err != nil || string(buf[:n]) != "xy" {
+		t.Fatalf("expected xy, got %q, %v", buf[:n], err)
+	}
+
+	// Setting a deadline in the past unblocks a blocked read.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		a.SetReadDeadline(time.Now())
+	}()
+	if _, err := a.Read(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+}
+
+func TestConn_CloseUnblocks(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, _ := NewConnPair(4)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		a.Close()
+	}()
+	if _, err := a.Read(make([]byte, 1)); err != net.ErrClosed {
+		t.Fatalf("expected net.ErrClosed, got %v", err)
+	}
+}
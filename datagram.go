@@ -0,0 +1,336 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrMalformedRecord is returned when buffered data is not a valid record,
+// for example because it was written with Write instead of as a record.
+var ErrMalformedRecord = errors.New("malformed record")
+
+// msgHeader is the size of the length prefix of a record.
+const msgHeader = 4
+
+// writeMsg writes p as a single length-prefixed record.
+// The record is written completely or not at all.
+// If blocking it waits for enough free space, bounded by deadline if not nil,
+// or by the write timeout otherwise.
+// Must be called when locked.
+func (r *RingBuffer) writeMsg(p []byte, deadline func() time.Time) error {
+	need := msgHeader + len(p) + r.msgTrailerSize()
+	if (need > r.size && need > r.maxSize) || uint64(len(p)) > math.MaxUint32 {
+		return ErrTooMuchDataToWrite
+	}
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	for {
+		if err := r.writeErr(); err != nil {
+			return err
+		}
+		if r.remaining() < int64(need) {
+			return ErrWriteOnClosed
+		}
+		r.grow(need)
+		if r.free() >= need {
+			break
+		}
+		r.stalls++
+		if !r.block {
+			return ErrIsFull
+		}
+		if err := r.waitReadDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	var hdr [msgHeader]byte
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(p)))
+	r.write(hdr[:])
+	if len(p) > 0 {
+		r.write(p)
+	}
+	if r.checksum {
+		var trailer [msgTrailer]byte
+		r.write(appendMsgChecksum(trailer[:0], hdr[:], p))
+	}
+	if r.block {
+		r.writeCond.Broadcast()
+	}
+	return nil
+}
+
+// readMsg reads a single record written by writeMsg into p.
+// If the record is larger than p it is truncated and the rest is discarded,
+// or, if truncate is false, io.ErrShortBuffer and the size of the record are returned
+// and the record is left in the buffer.
+// If blocking it waits for a record, bounded by deadline if not nil,
+// or by the read timeout otherwise.
+// Must be called when locked.
+func (r *RingBuffer) readMsg(p []byte, truncate bool, deadline func() time.Time) (n int, err error) {
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	for r.length() < msgHeader {
+		if err := r.readErr(true); err != nil {
+			return 0, err
+		}
+		if r.length() > 0 {
+			return 0, ErrMalformedRecord
+		}
+		if !r.block {
+			return 0, ErrIsEmpty
+		}
+		if err := r.waitWriteDeadline(deadline); err != nil {
+			return 0, err
+		}
+	}
+
+	var hdr [msgHeader]byte
+	r.peekAt(hdr[:], 0)
+	size := int(binary.BigEndian.Uint32(hdr[:]))
+	trailer := r.msgTrailerSize()
+	if size > r.length()-msgHeader-trailer {
+		return 0, ErrMalformedRecord
+	}
+	if !r.checkMsg(size) {
+		r.discard(msgHeader + size + trailer)
+		return 0, ErrChecksum
+	}
+	if size > len(p) && !truncate {
+		return size, io.ErrShortBuffer
+	}
+	if size < len(p) {
+		p = p[:size]
+	}
+	n = r.peekAt(p, msgHeader)
+	r.discard(msgHeader + size + trailer)
+	return n, nil
+}
+
+// WriteMsg writes p as a single length-prefixed record, to be read with ReadMsg.
+// The record is written completely or not at all:
+// in blocking mode WriteMsg waits until there is enough free space for the whole record,
+// and otherwise it returns ErrIsFull without writing anything.
+// A record that can never fit in the buffer is rejected with ErrTooMuchDataToWrite.
+// Each record takes 4 bytes of buffer space in addition to len(p),
+// or 8 bytes in checksum mode.
+func (r *RingBuffer) WriteMsg(p []byte) error {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.writeMsg(p, nil)
+}
+
+// ReadMsg reads exactly one record written by WriteMsg into p
+// and returns its size.
+// In blocking mode it waits for a record, and otherwise it returns ErrIsEmpty if there is none.
+// If the record is larger than p, io.ErrShortBuffer and the size of the record are returned
+// and the record is left in the buffer, so it can be read with a larger p.
+// ErrMalformedRecord is returned if the buffered data is not a record,
+// and ErrChecksum if the record is corrupted, see SetChecksum.
+func (r *RingBuffer) ReadMsg(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.readMsg(p, false, nil)
+}
+
+// peekAt copies buffered data starting off bytes after the read position into p
+// without consuming it, and returns the number of bytes copied.
+// Must be called when locked.
+func (r *RingBuffer) peekAt(p []byte, off int) (n int) {
+	a, b := r.readable()
+	if off < len(a) {
+		n = copy(p, a[off:])
+		n += copy(p[n:], b)
+	} else if off-len(a) < len(b) {
+		n = copy(p, b[off-len(a):])
+	}
+	r.decrypt(p[:n], r.consumed()+int64(off))
+	return n
+}
+
+// PacketAddr is the address of a PacketConn or a Conn.
+type PacketAddr string
+
+// Network returns "ringbuffer".
+func (a PacketAddr) Network() string { return "ringbuffer" }
+
+// String returns the address.
+func (a PacketAddr) String() string { return string(a) }
+
+// A PacketConn is a net.PacketConn that exchanges datagrams through ring buffers.
+// Each WriteTo or Write becomes one datagram, and each ReadFrom or Read
+// returns exactly one datagram.
+// Datagrams are stored as length-prefixed records in the ring buffer,
+// so no memory is allocated per datagram.
+//
+// Unlike UDP, datagrams are never dropped: writes wait for free space.
+// A datagram that does not fit in the ring buffer at all is rejected
+// with ErrTooMuchDataToWrite.
+type PacketConn struct {
+	rx, tx       *RingBuffer
+	laddr, raddr net.Addr
+	truncate     atomic.Bool
+	closed       atomic.Bool
+
+	mu        sync.Mutex
+	rDeadline time.Time
+	wDeadline time.Time
+	rdl, wdl  func() time.Time
+}
+
+// PacketConn returns a datagram pipe on the ring buffer,
+// in the same way Pipe returns a byte stream pipe.
+// Datagrams written to the PacketConn are read back from it,
+// so it can be shared by producer and consumer goroutines.
+// The ring buffer is set to blocking mode.
+func (r *RingBuffer) PacketConn() *PacketConn {
+	r.SetBlocking(true)
+	return newPacketConn(r, r, PacketAddr("pipe"), PacketAddr("pipe"))
+}
+
+// NewPacketConnPair returns two connected PacketConns, each backed by a
+// ring buffer of the given size for the datagrams sent to it.
+// Datagrams written to one are read from the other.
+func NewPacketConnPair(size int) (*PacketConn, *PacketConn) {
+	a, b := New(size).SetBlocking(true), New(size).SetBlocking(true)
+	addrA, addrB := PacketAddr("pipe-a"), PacketAddr("pipe-b")
+	return newPacketConn(a, b, addrA, addrB), newPacketConn(b, a, addrB, addrA)
+}
+
+func newPacketConn(rx, tx *RingBuffer, laddr, raddr net.Addr) *PacketConn {
+	c := &PacketConn{rx: rx, tx: tx, laddr: laddr, raddr: raddr}
+	c.truncate.Store(true)
+	c.rdl = func() time.Time { return c.deadline(&c.rDeadline) }
+	c.wdl = func() time.Time { return c.deadline(&c.wDeadline) }
+	return c
+}
+
+// deadline returns the deadline *t, or a deadline in the past if the connection is closed,
+// so blocked operations return.
+func (c *PacketConn) deadline(t *time.Time) time.Time {
+	if c.closed.Load() {
+		return time.Unix(1, 0)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return *t
+}
+
+// SetTruncate sets what happens when a datagram is larger than the read buffer.
+// If truncate is true, which is the default, the datagram is truncated like UDP does
+// and the rest is discarded.
+// Otherwise io.ErrShortBuffer and the size of the datagram are returned
+// and the datagram is left to be read with a larger buffer.
+func (c *PacketConn) SetTruncate(truncate bool) *PacketConn {
+	c.truncate.Store(truncate)
+	return c
+}
+
+// ReadFrom reads one datagram into p and returns its size and the address of the peer.
+// It blocks until a datagram is available or the read deadline passes.
+func (c *PacketConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	if c.closed.Load() {
+		return 0, nil, net.ErrClosed
+	}
+	c.rx.mu.Lock()
+	defer c.rx.unlock()
+	n, err = c.rx.readMsg(p, c.truncate.Load(), c.rdl)
+	if err != nil && err != io.ErrShortBuffer {
+		if c.closed.Load() {
+			err = net.ErrClosed
+		}
+		return n, nil, err
+	}
+	return n, c.raddr, err
+}
+
+// Read reads one datagram into p, like ReadFrom.
+func (c *PacketConn) Read(p []byte) (n int, err error) {
+	n, _, err = c.ReadFrom(p)
+	return n, err
+}
+
+// WriteTo writes p as one datagram.
+// addr is ignored, since there is only one peer.
+// It blocks until there is enough free space or the write deadline passes.
+func (c *PacketConn) WriteTo(p []byte, addr net.Addr) (n int, err error) {
+	if c.closed.Load() {
+		return 0, net.ErrClosed
+	}
+	c.tx.mu.Lock()
+	defer c.tx.unlock()
+	if err := c.tx.writeMsg(p, c.wdl); err != nil {
+		if c.closed.Load() {
+			err = net.ErrClosed
+		}
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Write writes p as one datagram, like WriteTo.
+func (c *PacketConn) Write(p []byte) (n int, err error) {
+	return c.WriteTo(p, c.raddr)
+}
+
+// Close closes the connection.
+// Blocked and future reads and writes on it return net.ErrClosed,
+// as do writes of the peer.
+func (c *PacketConn) Close() error {
+	if c.closed.Swap(true) {
+		return net.ErrClosed
+	}
+	c.rx.CloseWithError(net.ErrClosed)
+	c.tx.mu.Lock()
+	c.tx.readCond.Broadcast()
+	c.tx.unlock()
+	return nil
+}
+
+// LocalAddr returns the local address.
+func (c *PacketConn) LocalAddr() net.Addr { return c.laddr }
+
+// RemoteAddr returns the address of the peer.
+func (c *PacketConn) RemoteAddr() net.Addr { return c.raddr }
+
+// SetDeadline sets the read and write deadlines.
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for reads, including blocked reads.
+// When it passes reads return os.ErrDeadlineExceeded,
+// without closing the connection. A zero t disables the deadline.
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.rDeadline = t
+	c.mu.Unlock()
+	c.rx.mu.Lock()
+	c.rx.writeCond.Broadcast()
+	c.rx.unlock()
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for writes, including blocked writes.
+// When it passes writes return os.ErrDeadlineExceeded,
+// without closing the connection. A zero t disables the deadline.
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.wDeadline = t
+	c.mu.Unlock()
+	c.tx.mu.Lock()
+	c.tx.readCond.Broadcast()
+	c.tx.unlock()
+	return nil
+}
@@ -0,0 +1,191 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+var _ net.PacketConn = (*PacketConn)(nil)
+
+func TestPacketConn(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, b := NewPacketConnPair(32)
+	for _, msg := range []string{"hello", "", "world"} {
+		if n, err := a.WriteTo([]byte(msg), b.LocalAddr()); err != nil || n != len(msg) {
+			t.Fatalf("expected %d bytes written, got %d, %v", len(msg), n, err)
+		}
+	}
+
+	buf := make([]byte, 16)
+	for _, want := range []string{"hello", "", "world"} {
+		n, addr, err := b.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+		if got := string(buf[:n]); got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+		if addr != a.LocalAddr() {
+			t.Fatalf("expected address %v, got %v", a.LocalAddr(), addr)
+		}
+	}
+
+	// A write waits for a whole datagram to fit.
+	a.Write(make([]byte, 20))
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Read(buf)
+	}()
+	if _, err := a.Write(make([]byte, 20)); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if _, err := a.Write(make([]byte, 40)); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+}
+
+func TestPacketConnTruncate(t *testing.T) {
+	c := New(32).PacketConn()
+	c.Write([]byte("hello world"))
+	c.Write([]byte("next"))
+	buf := make([]byte, 5)
+
+	c.SetTruncate(false)
+	if n, err := c.Read(buf); err != io.ErrShortBuffer || n != 11 {
+		t.Fatalf("expected io.ErrShortBuffer and size 11, got %d, %v", n, err)
+	}
+
+	c.SetTruncate(true)
+	if n, err := c.Read(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("expected hello, got %q, %v", buf[:n], err)
+	}
+	if n, err := c.Read(buf); err != nil || string(buf[:n]) != "next" {
+		t.Fatalf("expected next, got %q, %v", buf[:n], err)
+	}
+}
+
+func TestPacketConnDeadline(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, b := NewPacketConnPair(16)
+	b.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	_, _, err := b.ReadFrom(make([]byte, 8))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+
+	// The connection still works after the deadline.
+	b.SetReadDeadline(time.Time{})
+	a.Write([]byte("ping"))
+	if n, err := b.Read(make([]byte, 8)); err != nil || n != 4 {
+		t.Fatalf("expected 4 bytes, got %d, %v", n, err)
+	}
+
+	a.Write(make([]byte, 12))
+	a.SetWriteDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := a.Write([]byte("x")); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+}
+
+func TestPacketConnClose(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, b := NewPacketConnPair(16)
+	done := make(chan error)
+	go func() {
+		_, _, err := b.ReadFrom(make([]byte, 8))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := b.Close(); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if err := <-done; err != net.ErrClosed {
+		t.Fatalf("expected net.ErrClosed, got %v", err)
+	}
+	if err := b.Close(); err != net.ErrClosed {
+		t.Fatalf("expected net.ErrClosed, got %v", err)
+	}
+	if _, err := a.Write([]byte("x")); err != net.ErrClosed {
+		t.Fatalf("expected net.ErrClosed, got %v", err)
+	}
+}
+
+func TestPacketConnMalformed(t *testing.T) {
+	rb := New(16)
+	c := rb.PacketConn()
+	rb.Write([]byte("ab"))
+	if _, err := c.Read(make([]byte, 8)); err != ErrMalformedRecord {
+		t.Fatalf("expected ErrMalformedRecord, got %v", err)
+	}
+}
+
+func TestRingBuffer_WriteMsgReadMsg(t *testing.T) {
+	rb := New(16)
+	if err := rb.WriteMsg([]byte("hello")); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if err := rb.WriteMsg([]byte("world!")); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	if rb.Length() != 9 {
+		t.Fatalf("expected a partial record not to be written, got length %d", rb.Length())
+	}
+	if err := rb.WriteMsg(make([]byte, 13)); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+
+	buf := make([]byte, 2)
+	if n, err := rb.ReadMsg(buf); err != io.ErrShortBuffer || n != 5 {
+		t.Fatalf("expected io.ErrShortBuffer and 5, got %d, %v", n, err)
+	}
+	buf = make([]byte, 16)
+	if n, err := rb.ReadMsg(buf); err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("expected hello, got %q, %v", buf[:n], err)
+	}
+	if _, err := rb.ReadMsg(buf); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+
+	rb.Write([]byte("ab"))
+	if _, err := rb.ReadMsg(buf); err != ErrMalformedRecord {
+		t.Fatalf("expected ErrMalformedRecord, got %v", err)
+	}
+}
+
+func TestRingBuffer_WriteMsgBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(16).SetBlocking(true)
+	go func() {
+		for _, s := range []string{"one", "two", "three", "four"} {
+			if err := rb.WriteMsg([]byte(s)); err != nil {
+				t.Errorf("expected nil, got %v", err)
+			}
+		}
+		rb.CloseWriter()
+	}()
+
+	var got []string
+	buf := make([]byte, 16)
+	for {
+		n, err := rb.ReadMsg(buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+		got = append(got, string(buf[:n]))
+	}
+	if strings.Join(got, ",") != "one,two,three,four" {
+		t.Fatalf("expected one,two,three,four, got %v", got)
+	}
+}
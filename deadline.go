@@ -0,0 +1,134 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// ReadTimeout reads like Read, but in blocking mode gives up waiting for data after d,
+// returning os.ErrDeadlineExceeded.
+// Unlike the timeouts set with WithTimeout, this leaves the ring buffer open,
+// so later reads and writes are not affected.
+// A d of 0 or less doesn't wait.
+func (r *RingBuffer) ReadTimeout(p []byte, d time.Duration) (n int, err error) {
+	deadline := time.Now().Add(d)
+	r.mu.Lock()
+	defer r.unlock()
+	return r.readUntil(p, func() time.Time { return deadline })
+}
+
+// WriteTimeout writes like Write, but in blocking mode gives up waiting for free space after d,
+// returning the number of bytes written and os.ErrDeadlineExceeded.
+// Unlike the timeouts set with WithTimeout, this leaves the ring buffer open,
+// so later reads and writes are not affected.
+// A d of 0 or less doesn't wait.
+func (r *RingBuffer) WriteTimeout(p []byte, d time.Duration) (n int, err error) {
+	deadline := time.Now().Add(d)
+	r.mu.Lock()
+	defer r.unlock()
+	return r.writeUntil(p, func() time.Time { return deadline })
+}
+
+// readUntil reads like Read, but if blocking it waits for data until deadline,
+// and returns os.ErrDeadlineExceeded without closing the ring buffer when it passes.
+// If there is no deadline it waits like Read, bounded by the read timeout.
+// Must be called when locked.
+func (r *RingBuffer) readUntil(p []byte, deadline func() time.Time) (n int, err error) {
+	if len(p) == 0 {
+		return 0, r.readErr(true)
+	}
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	for {
+		if err := r.readErr(true); err != nil {
+			return 0, err
+		}
+		n, err = r.read(p)
+		if err != ErrIsEmpty || !r.block {
+			break
+		}
+		if err := r.waitWriteDeadline(deadline); err != nil {
+			return 0, err
+		}
+	}
+	if r.block && n > 0 {
+		r.readCond.Broadcast()
+	}
+	return n, err
+}
+
+// writeUntil writes like Write, but if blocking it waits for free space until deadline,
+// and returns the bytes written and os.ErrDeadlineExceeded
+// without closing the ring buffer when it passes.
+// If there is no deadline it waits like Write, bounded by the write timeout.
+// Must be called when locked.
+func (r *RingBuffer) writeUntil(p []byte, deadline func() time.Time) (n int, err error) {
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	if err := r.waitFree(len(p), r.block, deadline); err != nil {
+		return 0, err
+	}
+	for {
+		var m int
+		m, err = r.write(p[n:])
+		n += m
+		if r.block && m > 0 {
+			r.writeCond.Broadcast()
+		}
+		if !r.block || (err != ErrIsFull && err != ErrTooMuchDataToWrite) {
+			return n, r.setErr(err, true)
+		}
+		if err := r.waitReadDeadline(deadline); err != nil {
+			return n, err
+		}
+		if err := r.writeErr(); err != nil {
+			return n, err
+		}
+	}
+}
+
+// waitReadDeadline waits for a read until deadline, if deadline is not nil and returns a non-zero time.
+// Otherwise it waits like waitRead, bounded by the write timeout.
+// It returns os.ErrDeadlineExceeded if the deadline has passed,
+// or context.DeadlineExceeded if the write timeout expired and closed the ring buffer.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) waitReadDeadline(deadline func() time.Time) error {
+	var dl time.Time
+	if deadline != nil {
+		dl = deadline()
+	}
+	if dl.IsZero() {
+		if !r.waitRead() {
+			return context.DeadlineExceeded
+		}
+	} else if !r.waitReadUntil(dl) {
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+// waitWriteDeadline waits for a write until deadline, like waitReadDeadline,
+// or bounded by the read timeout.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) waitWriteDeadline(deadline func() time.Time) error {
+	var dl time.Time
+	if deadline != nil {
+		dl = deadline()
+	}
+	if dl.IsZero() {
+		if !r.waitWrite() {
+			return context.DeadlineExceeded
+		}
+	} else if !r.waitWriteUntil(dl) {
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package ringbuffer
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_ReadWriteTimeout(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true)
+	buf := make([]byte, 4)
+
+	start := time.Now()
+	if _, err := rb.ReadTimeout(buf, 20*time.Millisecond); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatalf("expected to wait 20ms, waited %v", d)
+	}
+	if n, err := rb.WriteTimeout([]byte("abcdef"), 20*time.Millisecond); !errors.Is(err, os.ErrDeadlineExceeded) || n != 4 {
+		t.Fatalf("expected 4 bytes and os.ErrDeadlineExceeded, got %d, %v", n, err)
+	}
+	if n, err := rb.WriteTimeout([]byte("x"), 0); !errors.Is(err, os.ErrDeadlineExceeded) || n != 0 {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %d, %v", n, err)
+	}
+
+	// The ring buffer is still healthy.
+	if n, err := rb.ReadTimeout(buf, time.Second); err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", buf[:n], err)
+	}
+	go rb.Write([]byte("ef"))
+	if n, err := rb.ReadTimeout(buf, time.Second); err != nil || string(buf[:n]) != "ef" {
+		t.Fatalf("expected ef, got %q, %v", buf[:n], err)
+	}
+}
+
+func TestRingBuffer_ReadTimeoutNonBlocking(t *testing.T) {
+	rb := New(4)
+	if _, err := rb.ReadTimeout(make([]byte, 1), time.Second); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// DebugHandler returns an http.Handler that renders the ring buffers of reg as plain text,
+// with the capacity, length, utilization, stall information and close state of each buffer.
+// If reg is nil the DefaultRegistry is used.
+//
+// The dump query parameter adds a hexdump of up to that many of the most recently
+// written unread bytes of each buffer, for example /debug/ringbuffer?dump=64.
+//
+// The handler is not registered automatically. It can be mounted with:
+//
+//	http.Handle("/debug/ringbuffer", ringbuffer.DebugHandler(nil))
+func DebugHandler(reg *Registry) http.Handler {
+	if reg == nil {
+		reg = DefaultRegistry
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		dump, _ := strconv.Atoi(req.FormValue("dump"))
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		now := time.Now()
+		reg.Range(func(name string, rb *RingBuffer) bool {
+			writeDebugInfo(w, now, name, rb, dump)
+			return true
+		})
+	})
+}
+
+func writeDebugInfo(w http.ResponseWriter, now time.Time, name string, rb *RingBuffer, dump int) {
+	length, capacity := rb.Length(), rb.Capacity()
+	utilization := 0.0
+	if capacity > 0 {
+		utilization = float64(length) * 100 / float64(capacity)
+	}
+	reason, err := rb.CloseState()
+
+	fmt.Fprintf(w, "%s:\n", name)
+	fmt.Fprintf(w, "\tcapacity: %d\n", capacity)
+	fmt.Fprintf(w, "\tlength: %d\n", length)
+	fmt.Fprintf(w, "\tutilization: %.1f%%\n", utilization)
+	fmt.Fprintf(w, "\thigh-water mark: %d\n", rb.HighWaterMark())
+	fmt.Fprintf(w, "\tstalls: %d\n", rb.Stalls())
+	fmt.Fprintf(w, "\tlast read: %s\n", since(now, rb.LastRead()))
+	fmt.Fprintf(w, "\tlast write: %s\n", since(now, rb.LastWrite()))
+	if err != nil {
+		fmt.Fprintf(w, "\tstate: %v (%v)\n", reason, err)
+	} else {
+		fmt.Fprintf(w, "\tstate: %v\n", reason)
+	}
+	if dump > 0 && length > 0 && rb.encrypted() {
+		fmt.Fprintf(w, "\ttail: %v\n", ErrEncrypted)
+	} else if dump > 0 && length > 0 {
+		data := rb.Bytes(nil)
+		if len(data) > dump {
+			data = data[len(data)-dump:]
+		}
+		fmt.Fprintf(w, "\ttail:\n%s", hex.Dump(data))
+	}
+	fmt.Fprintln(w)
+}
+
+func since(now, t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return now.Sub(t).Round(time.Millisecond).String() + " ago"
+}
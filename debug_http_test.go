@@ -0,0 +1,42 @@
+package ringbuffer
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDebugHandler(t *testing.T) {
+	var reg Registry
+	a := New(100)
+	a.Write([]byte("hello world"))
+	b := New(10)
+	b.CloseWriter()
+	reg.Register("a", a)
+	reg.Register("b", b)
+
+	rec := httptest.NewRecorder()
+	DebugHandler(&reg).ServeHTTP(rec, httptest.NewRequest("GET", "/debug/ringbuffer?dump=5", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"a:\n",
+		"\tcapacity: 100\n",
+		"\tlength: 11\n",
+		"\tutilization: 11.0%\n",
+		"\tlast read: never\n",
+		"\tstate: not closed\n",
+		"|world|",
+		"b:\n",
+		"\tstate: closed by writer (EOF)\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "a:") > strings.Index(body, "b:") {
+		t.Errorf("buffers are not sorted:\n%s", body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("unexpected content type %q", ct)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"bytes"
+	"context"
+	"io"
+)
+
+// ReadSlice reads until the first occurrence of delim in the buffered data,
+// returning the data up to and including the delimiter.
+// The returned slice is reused by the next call to ReadSlice,
+// so ReadSlice must not be called concurrently.
+// In blocking mode it waits for the delimiter to be written,
+// and otherwise it returns ErrIsEmpty, without reading anything, if it is not buffered.
+// If the buffer fills up without a delimiter, ReadSlice reads and returns
+// all buffered data and ErrIsFull.
+// If the writer is closed before the delimiter is written,
+// ReadSlice returns the remaining data and io.EOF.
+func (r *RingBuffer) ReadSlice(delim byte) (line []byte, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	r.line, err = r.readDelim(r.line[:0], delim)
+	return r.line, err
+}
+
+// ReadBytes reads until the first occurrence of delim in the buffered data,
+// returning a new slice containing the data up to and including the delimiter.
+// Unlike ReadSlice, it keeps reading when the buffer fills up without a delimiter,
+// so lines can be longer than the buffer.
+// If ReadBytes encounters an error before finding a delimiter,
+// it returns the data read before the error and the error itself.
+// ReadBytes returns err != nil if and only if the returned data does not end in delim.
+func (r *RingBuffer) ReadBytes(delim byte) (line []byte, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	for {
+		line, err = r.readDelim(line, delim)
+		if err != ErrIsFull {
+			return line, err
+		}
+	}
+}
+
+// ReadString reads like ReadBytes, but returns the data as a string.
+func (r *RingBuffer) ReadString(delim byte) (line string, err error) {
+	b, err := r.ReadBytes(delim)
+	return string(b), err
+}
+
+// ReadLine reads one line, not including the end-of-line bytes "\n" or "\r\n".
+// Lines are limited to max bytes, or to the buffer size if max is 0 or less
+// or larger than the buffer:
+// a longer line is returned in parts with isPrefix set,
+// and the rest of the line is returned by the following calls.
+// In blocking mode it waits for a complete line,
+// and otherwise it returns ErrIsEmpty, without reading anything, if none is buffered.
+// If the writer is closed, the data after the last end of line is returned as the last line,
+// and the following calls return io.EOF.
+// The returned line is a new slice.
+func (r *RingBuffer) ReadLine(max int) (line []byte, isPrefix bool, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	limit := max
+	if limit <= 0 || limit > r.size {
+		limit = r.size
+	}
+	for {
+		if err := r.readErr(true); err != nil {
+			return nil, false, err
+		}
+		n := r.length()
+		if i := r.indexByte('\n'); i >= 0 {
+			end := i
+			if end > 0 && r.byteAt(end-1) == '\r' {
+				end--
+			}
+			if end <= limit {
+				line = r.appendRead(make([]byte, 0, end), end)
+				r.discard(i + 1 - end)
+				return line, false, nil
+			}
+		}
+		if n > limit || r.isFull {
+			// Don't split a "\r\n" between two parts.
+			end := limit
+			if end > 1 && r.byteAt(end-1) == '\r' {
+				end--
+			}
+			return r.appendRead(make([]byte, 0, end), end), true, nil
+		}
+		if r.err == io.EOF || r.sealed {
+			return r.appendRead(make([]byte, 0, n), n), false, nil
+		}
+		if !r.block {
+			return nil, false, ErrIsEmpty
+		}
+		if !r.waitWrite() {
+			return nil, false, context.DeadlineExceeded
+		}
+	}
+}
+
+// readDelim reads up to and including delim and appends the data to dst.
+// If blocking it waits for the delimiter until the buffer is full.
+// It reads all buffered data and returns ErrIsFull if the buffer is full without a delimiter,
+// or io.EOF if the writer is closed.
+// Must be called when locked.
+func (r *RingBuffer) readDelim(dst []byte, delim byte) ([]byte, error) {
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	for {
+		if err := r.readErr(true); err != nil {
+			return dst, err
+		}
+		if i := r.indexByte(delim); i >= 0 {
+			return r.appendRead(dst, i+1), nil
+		}
+		if r.isFull {
+			return r.appendRead(dst, r.size), ErrIsFull
+		}
+		if r.err == io.EOF || r.sealed {
+			return r.appendRead(dst, r.length()), io.EOF
+		}
+		if !r.block {
+			return dst, ErrIsEmpty
+		}
+		if !r.waitWrite() {
+			return dst, context.DeadlineExceeded
+		}
+	}
+}
+
+// indexByte returns the offset of the first c in the buffered data,
+// or -1 if c is not buffered.
+// Must be called when locked.
+func (r *RingBuffer) indexByte(c byte) int {
+	if r.enc != nil {
+		return r.indexByteEncrypted(c)
+	}
+	a, b := r.readable()
+	if i := bytes.IndexByte(a, c); i >= 0 {
+		return i
+	}
+	if i := bytes.IndexByte(b, c); i >= 0 {
+		return len(a) + i
+	}
+	return -1
+}
+
+// indexByteEncrypted is indexByte for an encrypted buffer,
+// decrypting the data a block at a time.
+// Must be called when locked.
+func (r *RingBuffer) indexByteEncrypted(c byte) int {
+	var buf [512]byte
+	for off := 0; ; {
+		n := r.peekAt(buf[:], off)
+		if n == 0 {
+			return -1
+		}
+		if i := bytes.IndexByte(buf[:n], c); i >= 0 {
+			return off + i
+		}
+		off += n
+	}
+}
+
+// byteAt returns the buffered byte at offset i.
+// Must be called when locked.
+func (r *RingBuffer) byteAt(i int) byte {
+	var b [1]byte
+	r.peekAt(b[:], i)
+	return b[0]
+}
+
+// appendRead consumes n buffered bytes and appends them to dst.
+// Must be called when locked.
+func (r *RingBuffer) appendRead(dst []byte, n int) []byte {
+	a, b := r.readable()
+	if n <= len(a) {
+		dst = append(dst, a[:n]...)
+	} else {
+		dst = append(dst, a...)
+		dst = append(dst, b[:n-len(a)]...)
+	}
+	r.decrypt(dst[len(dst)-n:], r.consumed())
+	r.discard(n)
+	return dst
+}
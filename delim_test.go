@@ -0,0 +1,174 @@
+package ringbuffer
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_ReadSlice(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("ab\ncd"))
+	line, err := rb.ReadSlice('\n')
+	if err != nil || string(line) != "ab\n" {
+		t.Fatalf("expected ab\\n, got %q, %v", line, err)
+	}
+	if _, err := rb.ReadSlice('\n'); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	if rb.Length() != 2 {
+		t.Fatalf("expected nothing to be read, got length %d", rb.Length())
+	}
+
+	// The line wraps around the end of the buffer.
+	rb.Write([]byte("ef\ngh"))
+	line, err = rb.ReadSlice('\n')
+	if err != nil || string(line) != "cdef\n" {
+		t.Fatalf("expected cdef\\n, got %q, %v", line, err)
+	}
+
+	rb.Write([]byte("ijklmn"))
+	line, err = rb.ReadSlice('\n')
+	if err != ErrIsFull || string(line) != "ghijklmn" {
+		t.Fatalf("expected ghijklmn and ErrIsFull, got %q, %v", line, err)
+	}
+
+	rb.Write([]byte("op"))
+	rb.CloseWriter()
+	line, err = rb.ReadSlice('\n')
+	if err != io.EOF || string(line) != "op" {
+		t.Fatalf("expected op and io.EOF, got %q, %v", line, err)
+	}
+}
+
+func TestRingBuffer_ReadBytes(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8).SetBlocking(true)
+	go func() {
+		rb.Write([]byte("short\na line longer than the buffer\nlast"))
+		rb.CloseWriter()
+	}()
+
+	line, err := rb.ReadString('\n')
+	if err != nil || line != "short\n" {
+		t.Fatalf("expected short\\n, got %q, %v", line, err)
+	}
+	b, err := rb.ReadBytes('\n')
+	if err != nil || string(b) != "a line longer than the buffer\n" {
+		t.Fatalf("expected a line longer than the buffer, got %q, %v", b, err)
+	}
+	line, err = rb.ReadString('\n')
+	if err != io.EOF || line != "last" {
+		t.Fatalf("expected last and io.EOF, got %q, %v", line, err)
+	}
+	if line, err = rb.ReadString('\n'); err != io.EOF || line != "" {
+		t.Fatalf("expected io.EOF, got %q, %v", line, err)
+	}
+}
+
+func TestRingBuffer_ReadBytesLines(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(16).SetBlocking(true)
+	var want strings.Builder
+	go func() {
+		for i := 0; i < 100; i++ {
+			rb.WriteString(strings.Repeat("x", i%20) + "\n")
+		}
+		rb.CloseWriter()
+	}()
+	for i := 0; i < 100; i++ {
+		want.WriteString(strings.Repeat("x", i%20) + "\n")
+	}
+
+	var got strings.Builder
+	for {
+		line, err := rb.ReadString('\n')
+		got.WriteString(line)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+		if !strings.HasSuffix(line, "\n") || strings.Count(line, "\n") != 1 {
+			t.Fatalf("expected one line, got %q", line)
+		}
+	}
+	if got.String() != want.String() {
+		t.Fatalf("expected %d bytes of lines, got %q", want.Len(), got.String())
+	}
+}
+
+func TestRingBuffer_ReadLine(t *testing.T) {
+	rb := New(16)
+	rb.Write([]byte("ab\r\n\ncdefgh\r\nij"))
+	for _, want := range []struct {
+		line     string
+		isPrefix bool
+	}{
+		{"ab", false},
+		{"", false},
+		{"cde", true},
+		{"fgh", false},
+	} {
+		line, isPrefix, err := rb.ReadLine(3)
+		if err != nil || string(line) != want.line || isPrefix != want.isPrefix {
+			t.Fatalf("expected %q, %v, got %q, %v, %v", want.line, want.isPrefix, line, isPrefix, err)
+		}
+	}
+	if _, _, err := rb.ReadLine(3); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+
+	// "\r\n" is not split.
+	rb.Write([]byte("\r\n"))
+	if line, isPrefix, err := rb.ReadLine(3); err != nil || string(line) != "ij" || isPrefix {
+		t.Fatalf("expected ij, got %q, %v, %v", line, isPrefix, err)
+	}
+	rb.Write([]byte("abc\r\n"))
+	if line, isPrefix, err := rb.ReadLine(3); err != nil || string(line) != "abc" || isPrefix {
+		t.Fatalf("expected abc, got %q, %v, %v", line, isPrefix, err)
+	}
+	rb.Write([]byte("ab\r"))
+	rb.Write([]byte("cd\n"))
+	if line, isPrefix, err := rb.ReadLine(3); err != nil || string(line) != "ab" || !isPrefix {
+		t.Fatalf("expected prefix ab, got %q, %v, %v", line, isPrefix, err)
+	}
+	if line, isPrefix, err := rb.ReadLine(3); err != nil || string(line) != "\rcd" || isPrefix {
+		t.Fatalf("expected \\rcd, got %q, %v, %v", line, isPrefix, err)
+	}
+
+	rb.Write([]byte("last"))
+	rb.CloseWriter()
+	if line, isPrefix, err := rb.ReadLine(0); err != nil || string(line) != "last" || isPrefix {
+		t.Fatalf("expected last, got %q, %v, %v", line, isPrefix, err)
+	}
+	if _, _, err := rb.ReadLine(0); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestRingBuffer_ReadLineBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8).SetBlocking(true)
+	go func() {
+		rb.Write([]byte("0123456789abc\nxyz\n"))
+		rb.CloseWriter()
+	}()
+
+	var parts []string
+	for {
+		line, isPrefix, err := rb.ReadLine(0)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+		parts = append(parts, string(line)+map[bool]string{true: "+", false: "|"}[isPrefix])
+	}
+	if got := strings.Join(parts, ""); got != "01234567+89abc|xyz|" {
+		t.Fatalf("expected 01234567+89abc|xyz|, got %s", got)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"errors"
+	"unsafe"
+)
+
+// ErrInvalidLength is returned when completing or advancing
+// more bytes than the region allows.
+var ErrInvalidLength = errors.New("invalid length")
+
+// Descriptor describes a contiguous region of the memory of the ring buffer,
+// suitable for passing to cgo, DMA or system APIs.
+// An unused descriptor has a nil Ptr and a Len of 0.
+type Descriptor struct {
+	Ptr unsafe.Pointer
+	Len int
+}
+
+// ReadDescriptors returns the buffered data as up to two descriptors,
+// in the order the data should be consumed.
+// Call CompleteRead when the data has been consumed.
+//
+// The descriptors are only valid until the next read from the ring buffer,
+// so there should be a single reader while they are in use.
+// The ring buffer memory must not be retained by C code after CompleteRead.
+func (r *RingBuffer) ReadDescriptors() (d [2]Descriptor) {
+	r.mu.Lock()
+	defer r.unlock()
+	a, b := r.readable()
+	return descriptors(a, b)
+}
+
+// WriteDescriptors returns the free space of the buffer as up to two descriptors,
+// in the order they should be filled.
+// Call CompleteWrite when data has been written into them.
+//
+// The descriptors are only valid until the next write to the ring buffer,
+// so there should be a single writer while they are in use.
+func (r *RingBuffer) WriteDescriptors() (d [2]Descriptor) {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.writeErr() != nil {
+		return d
+	}
+	a, b := r.writable()
+	return descriptors(a, b)
+}
+
+// CompleteRead marks n bytes from the read descriptors as consumed.
+// ErrInvalidLength is returned if n is more than the buffered data.
+func (r *RingBuffer) CompleteRead(n int) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if n < 0 || n > r.length() {
+		return ErrInvalidLength
+	}
+	r.discard(n)
+	return nil
+}
+
+// CompleteWrite publishes n bytes written into the write descriptors,
+// making them available to readers.
+// ErrInvalidLength is returned if n is more than the free space.
+func (r *RingBuffer) CompleteWrite(n int) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.writeErr(); err != nil {
+		return err
+	}
+	if n < 0 || n > r.free() {
+		return ErrInvalidLength
+	}
+	r.advanceWrite(n)
+	if r.block && n > 0 {
+		r.writeCond.Broadcast()
+	}
+	return nil
+}
+
+func descriptors(a, b []byte) (d [2]Descriptor) {
+	for i, p := range [2][]byte{a, b} {
+		if len(p) > 0 {
+			d[i] = Descriptor{Ptr: unsafe.Pointer(&p[0]), Len: len(p)}
+		}
+	}
+	if d[0].Len == 0 {
+		d[0], d[1] = d[1], Descriptor{}
+	}
+	return d
+}
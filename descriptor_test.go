@@ -0,0 +1,57 @@
+package ringbuffer
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestRingBuffer_Descriptors(t *testing.T) {
+	rb := New(10)
+	rb.Write([]byte("0123456"))
+	rb.Read(make([]byte, 5))
+
+	d := rb.WriteDescriptors()
+	if d[0].Len != 3 || d[1].Len != 5 {
+		t.Fatalf("expected write descriptors of 3 and 5 bytes, got %d and %d", d[0].Len, d[1].Len)
+	}
+	copy(unsafe.Slice((*byte)(d[0].Ptr), d[0].Len), "abc")
+	copy(unsafe.Slice((*byte)(d[1].Ptr), d[1].Len), "de")
+	if err := rb.CompleteWrite(9); err != ErrInvalidLength {
+		t.Fatalf("expected ErrInvalidLength, got %v", err)
+	}
+	if err := rb.CompleteWrite(5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := string(rb.Bytes(nil)); got != "56abcde" {
+		t.Fatalf("expected 56abcde, got %q", got)
+	}
+
+	d = rb.ReadDescriptors()
+	got := string(unsafe.Slice((*byte)(d[0].Ptr), d[0].Len)) + string(unsafe.Slice((*byte)(d[1].Ptr), d[1].Len))
+	if got != "56abcde" {
+		t.Fatalf("expected 56abcde, got %q", got)
+	}
+	if err := rb.CompleteRead(8); err != ErrInvalidLength {
+		t.Fatalf("expected ErrInvalidLength, got %v", err)
+	}
+	if err := rb.CompleteRead(4); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := string(rb.Bytes(nil)); got != "cde" {
+		t.Fatalf("expected cde, got %q", got)
+	}
+
+	d = rb.ReadDescriptors()
+	if d[0].Len != 1 || d[1].Len != 2 {
+		t.Fatalf("expected read descriptors of 1 and 2 bytes, got %+v", d)
+	}
+	rb.CompleteRead(1)
+	d = rb.ReadDescriptors()
+	if d[0].Len != 2 || d[1].Len != 0 || d[1].Ptr != nil {
+		t.Fatalf("expected a single read descriptor of 2 bytes, got %+v", d)
+	}
+	rb.CompleteRead(2)
+	if d = rb.ReadDescriptors(); d[0].Len != 0 || d[0].Ptr != nil {
+		t.Fatalf("expected no read descriptors, got %+v", d)
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// DrainOptions are the thresholds at which StartDrain writes buffered data.
+// With a zero DrainOptions, data is written as soon as it is buffered.
+type DrainOptions struct {
+	MinBytes  int           // Drain when at least MinBytes are buffered, capped at the buffer size.
+	MaxDelay  time.Duration // Drain when data has been buffered for MaxDelay, if positive.
+	ChunkSize int           // Size of the writes to w, 32 KiB if 0 or less.
+}
+
+// StartDrain starts a goroutine that writes the data of the ring buffer to w,
+// batching it until MinBytes are buffered or the oldest data has waited for MaxDelay,
+// whichever comes first.
+// If MinBytes is set and MaxDelay is not, data may stay buffered indefinitely.
+//
+// The returned stop function stops the goroutine after writing all buffered data,
+// and returns the first error returned by w, if any.
+// The goroutine also stops, writing all buffered data first,
+// when the writer of the ring buffer is closed,
+// and it stops immediately when the ring buffer is closed with an error or w fails.
+// Data read from the ring buffer when w fails is lost.
+//
+// The goroutine must be the only reader of the ring buffer.
+// It reads without waiting, so it works in blocking and non-blocking mode,
+// and it keeps running across Reset.
+func (r *RingBuffer) StartDrain(w io.Writer, opts DrainOptions) (stop func() error) {
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = 32 * 1024
+	}
+	stopC := make(chan struct{})
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		err = r.drain(w, opts, stopC)
+	}()
+	var once sync.Once
+	return func() error {
+		once.Do(func() { close(stopC) })
+		<-done
+		return err
+	}
+}
+
+// drain writes the data of the ring buffer to w according to opts until stop is closed.
+func (r *RingBuffer) drain(w io.Writer, opts DrainOptions, stop <-chan struct{}) error {
+	buf := make([]byte, opts.ChunkSize)
+	readable := r.ReadableC()
+	timer := time.NewTimer(time.Hour)
+	timer.Stop()
+	defer timer.Stop()
+	stopped := false
+	for {
+		due, wait, err := r.drainDue(opts)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil && err != ErrReset {
+			return err
+		}
+		if due || stopped {
+			err := r.drainTo(w, buf)
+			if err == io.EOF || (err == nil && stopped) {
+				return nil
+			}
+			if err != nil && err != ErrReset {
+				return err
+			}
+			continue
+		}
+		var timeout <-chan time.Time
+		if wait > 0 {
+			timer.Reset(wait)
+			timeout = timer.C
+		}
+		select {
+		case <-readable:
+		case <-timeout:
+		case <-stop:
+			stopped = true
+		}
+		if timeout != nil && !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+	}
+}
+
+// drainDue reports whether the buffered data should be drained according to opts,
+// and otherwise how long until its age reaches MaxDelay, or 0 if there is no data.
+// It returns the error a read would fail with.
+func (r *RingBuffer) drainDue(opts DrainOptions) (due bool, wait time.Duration, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.readErr(true); err != nil {
+		return false, 0, err
+	}
+	n := r.length()
+	if n == 0 {
+		return false, 0, nil
+	}
+	if n >= opts.MinBytes || n == r.size || r.err == io.EOF {
+		return true, 0, nil
+	}
+	if opts.MaxDelay <= 0 {
+		return false, 0, nil
+	}
+	r.keepTimes()
+	wait = opts.MaxDelay - time.Since(r.waitingSince())
+	return wait <= 0, wait, nil
+}
+
+// drainTo writes all buffered data to w, using buf to copy it.
+// It returns nil once the buffer is empty, io.EOF if the writer is closed as well,
+// and otherwise the error of the ring buffer or of w.
+func (r *RingBuffer) drainTo(w io.Writer, buf []byte) error {
+	for {
+		n, err := r.readAvailable(buf)
+		if n > 0 {
+			nw, werr := w.Write(buf[:n])
+			if werr == nil && nw != n {
+				werr = io.ErrShortWrite
+			}
+			if werr != nil {
+				return werr
+			}
+			continue
+		}
+		if err == ErrIsEmpty {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,84 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// syncBuffer is a bytes.Buffer that is safe for concurrent use.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestRingBuffer_StartDrain(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(64)
+	var out syncBuffer
+	stop := rb.StartDrain(&out, DrainOptions{MinBytes: 8, MaxDelay: 50 * time.Millisecond})
+
+	rb.WriteString("abc")
+	time.Sleep(20 * time.Millisecond)
+	if s := out.String(); s != "" {
+		t.Fatalf("expected nothing below the thresholds, got %q", s)
+	}
+	time.Sleep(80 * time.Millisecond)
+	if s := out.String(); s != "abc" {
+		t.Fatalf("expected abc after MaxDelay, got %q", s)
+	}
+
+	rb.WriteString("defghijk")
+	for out.String() != "abcdefghijk" {
+		time.Sleep(time.Millisecond)
+	}
+
+	rb.WriteString("lm")
+	if err := stop(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if s := out.String(); s != "abcdefghijklm" {
+		t.Fatalf("expected the rest to be drained on stop, got %q", s)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+type failWriter struct{ err error }
+
+func (w failWriter) Write(p []byte) (int, error) { return 0, w.err }
+
+func TestRingBuffer_StartDrainErrors(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(64)
+	var out syncBuffer
+	stop := rb.StartDrain(&out, DrainOptions{MinBytes: 64})
+	rb.WriteString("abc")
+	rb.CloseWriter()
+	if err := stop(); err != nil || out.String() != "abc" {
+		t.Fatalf("expected abc, got %q, %v", out.String(), err)
+	}
+
+	errTest := errors.New("test")
+	rb = New(64)
+	stop = rb.StartDrain(failWriter{errTest}, DrainOptions{})
+	rb.WriteString("abc")
+	if err := stop(); err != errTest {
+		t.Fatalf("expected %v, got %v", errTest, err)
+	}
+}
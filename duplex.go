@@ -0,0 +1,35 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "net"
+
+// An Endpoint is one side of a duplex link created by NewDuplex.
+// It is a Conn, so it is an io.ReadWriteCloser and a net.Conn.
+type Endpoint = Conn
+
+// NewDuplex returns the two endpoints of a bidirectional link,
+// built on two ring buffers of the given size, one for each direction.
+// Data written to one endpoint is read from the other.
+// Closing an endpoint is propagated to the other one, as described by Close,
+// and CloseWrite closes a single direction.
+func NewDuplex(size int) (a, b *Endpoint) {
+	ab, ba := New(size).SetBlocking(true), New(size).SetBlocking(true)
+	addrA, addrB := PacketAddr("duplex-a"), PacketAddr("duplex-b")
+	return newConn(ba, ab, addrA, addrB), newConn(ab, ba, addrB, addrA)
+}
+
+// CloseWrite closes the sending direction of the connection,
+// like the method of net.TCPConn.
+// The peer reads the remaining data and then io.EOF,
+// while it can still send data to be read from c.
+// Writes on c return ErrWriteOnClosed afterwards.
+func (c *Conn) CloseWrite() error {
+	if c.closed.Load() {
+		return net.ErrClosed
+	}
+	c.tx.CloseWriter()
+	return nil
+}
@@ -0,0 +1,50 @@
+package ringbuffer
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNewDuplex(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, b := NewDuplex(8)
+	var _ io.ReadWriteCloser = a
+
+	go func() {
+		a.Write([]byte("ping"))
+		a.CloseWrite()
+	}()
+	buf := make([]byte, 8)
+	n, err := io.ReadFull(b, buf[:4])
+	if err != nil || string(buf[:n]) != "ping" {
+		t.Fatalf("expected ping, got %q, %v", buf[:n], err)
+	}
+	if _, err := b.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF after CloseWrite, got %v", err)
+	}
+	if _, err := a.Write([]byte("x")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+
+	// The other direction is still open.
+	if _, err := b.Write([]byte("pong")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	n, err = a.Read(buf)
+	if err != nil || string(buf[:n]) != "pong" {
+		t.Fatalf("expected pong, got %q, %v", buf[:n], err)
+	}
+
+	b.Close()
+	if _, err := a.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF after Close, got %v", err)
+	}
+	if err := b.CloseWrite(); err != net.ErrClosed {
+		t.Fatalf("expected net.ErrClosed, got %v", err)
+	}
+	if s := a.RemoteAddr().String(); s != "duplex-b" {
+		t.Fatalf("expected duplex-b, got %s", s)
+	}
+}
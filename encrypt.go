@@ -0,0 +1,169 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrEncrypted is returned by MarshalBinary, FS, ServeHTTP and Replicate
+// when the ring buffer is encrypted, since they would hand out the data in cleartext.
+var ErrEncrypted = errors.New("ringbuffer is encrypted")
+
+// encryption encrypts the data of a ring buffer with AES-CTR.
+// The key stream is indexed by the absolute offset of the data in the stream,
+// so data can be decrypted wherever it is read from and however it is moved in the buffer.
+type encryption struct {
+	block   cipher.Block
+	iv      [aes.BlockSize]byte
+	base    int64 // Key stream offset of absolute offset 0, advanced when offsets restart.
+	ctr, ks [aes.BlockSize]byte
+}
+
+// newEncryption returns an encryption with block and a random IV.
+func newEncryption(block cipher.Block) (*encryption, error) {
+	e := &encryption{block: block}
+	if _, err := rand.Read(e.iv[:]); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// SetEncryptionKey encrypts the data in the buffer with AES-CTR and key,
+// so it is not kept in cleartext in memory.
+// Data is encrypted as it is written and decrypted as it is read or peeked.
+// key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256;
+// a random IV is generated for each ring buffer.
+// A nil key disables encryption.
+// The buffer must be empty, ErrIsNotEmpty is returned otherwise,
+// and memory-mapped or sealed ring buffers cannot be encrypted.
+//
+// The zero-copy APIs ReadableSlices, ReadDescriptors and Linearize give access
+// to the ciphertext, while Chunks, Records and PeekTo decrypt into a copy.
+// Data written directly into the buffer by ReadFrom, Reserve and WriteDescriptors,
+// or out of order by WriteAtOffset, is in cleartext until it is published.
+//
+// Encrypted data is never persisted or sent out in cleartext:
+// MarshalBinary, the files of FS, ServeHTTP and Replicate fail with ErrEncrypted,
+// and DebugHandler does not dump the data.
+// Snapshot and SnapshotFile still decrypt into a copy in memory,
+// like PeekTo, so their result must not be written out either.
+func (r *RingBuffer) SetEncryptionKey(key []byte) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.mapped != nil {
+		return ErrMapped
+	}
+	if r.sealed {
+		return ErrSealed
+	}
+	if r.length() > 0 || len(r.pending) > 0 {
+		return ErrIsNotEmpty
+	}
+	if key == nil {
+		r.enc = nil
+		return nil
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	enc, err := newEncryption(block)
+	if err != nil {
+		return err
+	}
+	r.enc = enc
+	return nil
+}
+
+// encrypted reports whether the data in the buffer is encrypted.
+func (r *RingBuffer) encrypted() bool {
+	defer r.runlock(r.rlock())
+	return r.enc != nil
+}
+
+// xorAt XORs p with the key stream at the absolute offset off.
+// The key stream is that of cipher.NewCTR with the IV, started at off.
+// It uses the scratch blocks of e instead of a cipher.Stream,
+// so it does not allocate and p does not escape.
+// Must be called when the ring buffer is locked.
+func (e *encryption) xorAt(p []byte, off int64) {
+	pos := uint64(off + e.base)
+	hi := binary.BigEndian.Uint64(e.iv[:8])
+	lo := binary.BigEndian.Uint64(e.iv[8:])
+	n := pos / aes.BlockSize
+	skip := int(pos % aes.BlockSize)
+	for len(p) > 0 {
+		// The counter block is the IV plus the block number, as a 128-bit big-endian integer.
+		h, l := hi, lo+n
+		if l < lo {
+			h++
+		}
+		binary.BigEndian.PutUint64(e.ctr[:8], h)
+		binary.BigEndian.PutUint64(e.ctr[8:], l)
+		e.block.Encrypt(e.ks[:], e.ctr[:])
+		k := aes.BlockSize - skip
+		if k > len(p) {
+			k = len(p)
+		}
+		for i := 0; i < k; i++ {
+			p[i] ^= e.ks[skip+i]
+		}
+		p = p[k:]
+		skip = 0
+		n++
+	}
+}
+
+// cryptBuf encrypts or decrypts in place the n bytes at index i of the backing array,
+// which hold the data at absolute offset off, if encryption is enabled.
+// Must be called when locked.
+func (r *RingBuffer) cryptBuf(i, n int, off int64) {
+	if r.enc == nil || n <= 0 {
+		return
+	}
+	if i+n <= r.size {
+		r.enc.xorAt(r.buf[i:i+n], off)
+		return
+	}
+	c := r.size - i
+	r.enc.xorAt(r.buf[i:], off)
+	r.enc.xorAt(r.buf[:n-c], off+int64(c))
+}
+
+// rebase moves the key stream past the data written so far,
+// before the absolute offsets restart, so the key stream is never reused.
+// Must be called when locked.
+func (r *RingBuffer) rebase() {
+	if r.enc != nil {
+		r.enc.base += r.written
+	}
+}
+
+// decrypt decrypts p, a copy of the data at absolute offset off,
+// if encryption is enabled.
+// Must be called when locked.
+func (r *RingBuffer) decrypt(p []byte, off int64) {
+	if r.enc != nil {
+		r.enc.xorAt(p, off)
+	}
+}
+
+// plain returns a decrypted copy of the n bytes at index i of the backing array,
+// which hold the data at absolute offset off.
+// Encryption must be enabled.
+// Must be called when locked.
+func (r *RingBuffer) plain(i, n int, off int64) []byte {
+	p := make([]byte, n)
+	if c := copy(p, r.buf[i:]); c < n {
+		copy(p[c:], r.buf)
+	}
+	r.enc.xorAt(p, off)
+	return p
+}
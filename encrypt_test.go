@@ -0,0 +1,135 @@
+package ringbuffer
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testKey = []byte("0123456789abcdef")
+
+func TestRingBuffer_Encryption(t *testing.T) {
+	rb := New(16)
+	if err := rb.SetEncryptionKey(testKey); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		// Wraps around the end of the buffer.
+		if _, err := rb.Write([]byte("secret!")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if bytes.Contains(rb.buf, []byte("sec")) {
+			t.Fatalf("expected ciphertext in buffer, got %q", rb.buf)
+		}
+		if s := string(rb.Bytes(nil)); s != "secret!" {
+			t.Fatalf("expected secret!, got %q", s)
+		}
+		p := make([]byte, 3)
+		rb.Peek(p)
+		if string(p) != "sec" {
+			t.Fatalf("expected sec, got %q", p)
+		}
+		rb.Read(p)
+		if b, _ := rb.ReadByte(); b != 'r' {
+			t.Fatalf("expected r, got %q", b)
+		}
+		rb.UnreadByte()
+		buf := make([]byte, 8)
+		n, _ := rb.Read(buf)
+		if string(buf[:n]) != "ret!" {
+			t.Fatalf("expected ret!, got %q", buf[:n])
+		}
+	}
+
+	rb.WriteString("line one\nline")
+	line, err := rb.ReadSlice('\n')
+	if err != nil || string(line) != "line one\n" {
+		t.Fatalf("expected line one, got %q, %v", line, err)
+	}
+	var out bytes.Buffer
+	if _, err := rb.PeekTo(&out, 8); out.String() != "line" {
+		t.Fatalf("expected line, got %q, %v", out.String(), err)
+	}
+
+	rb.Read(make([]byte, 4))
+	rb.WriteString("abc")
+	c := rb.Clone()
+	rb.Reset()
+	rb.WriteString("xyz")
+	if s := string(rb.Bytes(nil)); s != "xyz" {
+		t.Fatalf("expected xyz after Reset, got %q", s)
+	}
+	if s := string(c.Bytes(nil)); s != "abc" {
+		t.Fatalf("expected abc in clone, got %q", s)
+	}
+	if bytes.Contains(c.buf, []byte("abc")) {
+		t.Fatalf("expected ciphertext in clone, got %q", c.buf)
+	}
+}
+
+func TestRingBuffer_EncryptionBufio(t *testing.T) {
+	rb := New(64)
+	rb.SetEncryptionKey(testKey)
+	rb.WriteString("hello\nworld\n")
+	sc := bufio.NewScanner(rb)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	if len(lines) != 2 || lines[0] != "hello" || lines[1] != "world" {
+		t.Fatalf("expected hello and world, got %q", lines)
+	}
+}
+
+func TestRingBuffer_SetEncryptionKeyErrors(t *testing.T) {
+	rb := New(16)
+	if err := rb.SetEncryptionKey([]byte("short")); err == nil {
+		t.Fatalf("expected an error for an invalid key size")
+	}
+	rb.WriteString("a")
+	if err := rb.SetEncryptionKey(testKey); err != ErrIsNotEmpty {
+		t.Fatalf("expected ErrIsNotEmpty, got %v", err)
+	}
+	rb.ReadByte()
+	rb.SetEncryptionKey(testKey)
+	if err := rb.SetEncryptionKey(nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rb.WriteString("plain")
+	if !bytes.Contains(rb.buf, []byte("plain")) {
+		t.Fatalf("expected cleartext after disabling encryption, got %q", rb.buf)
+	}
+}
+
+func TestRingBuffer_EncryptedNotPersisted(t *testing.T) {
+	rb := New(16)
+	if err := rb.SetEncryptionKey(testKey); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rb.WriteString("secret")
+	if b, err := rb.MarshalBinary(); err != ErrEncrypted || b != nil {
+		t.Fatalf("expected ErrEncrypted, got %q, %v", b, err)
+	}
+	if _, err := rb.FS("ring.log").Open("ring.log"); !errors.Is(err, ErrEncrypted) {
+		t.Fatalf("expected ErrEncrypted, got %v", err)
+	}
+	rec := httptest.NewRecorder()
+	rb.ServeHTTP(rec, httptest.NewRequest("GET", "/?follow=0", nil))
+	if rec.Code != http.StatusForbidden || strings.Contains(rec.Body.String(), "secret") {
+		t.Fatalf("expected 403 without the data, got %d, %q", rec.Code, rec.Body.String())
+	}
+	var reg Registry
+	reg.Register("rb", rb)
+	rec = httptest.NewRecorder()
+	DebugHandler(&reg).ServeHTTP(rec, httptest.NewRequest("GET", "/?dump=16", nil))
+	if body := rec.Body.String(); strings.Contains(body, "secret") || !strings.Contains(body, "\ttail: "+ErrEncrypted.Error()) {
+		t.Fatalf("expected no dump of the data, got %q", body)
+	}
+	if _, err := rb.Replicate("127.0.0.1:0", 64, 0); err != ErrEncrypted {
+		t.Fatalf("expected ErrEncrypted, got %v", err)
+	}
+}
@@ -0,0 +1,23 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// ringError is an error of the ring buffer that can be classified
+// like a net.Error, without comparing error strings.
+// Timeouts return context.DeadlineExceeded, which is already a net.Error.
+type ringError struct {
+	msg       string
+	temporary bool
+}
+
+// Error returns the error message.
+func (e *ringError) Error() string { return e.msg }
+
+// Timeout reports whether the error is a timeout, which it never is.
+func (e *ringError) Timeout() bool { return false }
+
+// Temporary reports whether the operation may succeed when retried,
+// as for ErrIsFull, ErrIsEmpty and ErrAcquireLock.
+func (e *ringError) Temporary() bool { return e.temporary }
@@ -0,0 +1,41 @@
+package ringbuffer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_NetError(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true).WithReadTimeout(10 * time.Millisecond)
+	_, err := rb.Read(make([]byte, 1))
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("expected a timeout net.Error, got %v", err)
+	}
+	if !os.IsTimeout(err) {
+		t.Fatalf("expected os.IsTimeout to report %v", err)
+	}
+
+	for _, err := range []error{ErrIsFull, ErrIsEmpty, ErrAcquireLock} {
+		if !errors.As(err, &ne) || ne.Timeout() {
+			t.Fatalf("expected a net.Error that is not a timeout, got %v", err)
+		}
+		if tmp, ok := err.(interface{ Temporary() bool }); !ok || !tmp.Temporary() {
+			t.Fatalf("expected %v to be temporary", err)
+		}
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("expected %v not to match the deadline errors", err)
+		}
+	}
+	if errors.Is(ErrIsFull, ErrIsEmpty) {
+		t.Fatalf("expected ErrIsFull not to match ErrIsEmpty")
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "time"
+
+// Faults are the faults injected into Read and Write by SetFaults,
+// so tests can exercise the retry logic of the code using a ring buffer
+// deterministically. A zero field injects nothing.
+type Faults struct {
+	// FullEvery makes every FullEvery-th Write fail with ErrIsFull without writing,
+	// even in blocking mode.
+	FullEvery int
+	// EmptyEvery makes every EmptyEvery-th Read fail with ErrIsEmpty without reading,
+	// even in blocking mode.
+	EmptyEvery int
+	// MaxWrite limits a Write to MaxWrite bytes; the rest is not written
+	// and ErrTooMuchDataToWrite is returned, like a short write in non-blocking mode.
+	MaxWrite int
+	// MaxRead limits a Read to MaxRead bytes.
+	MaxRead int
+	// Delay is slept by every Read and Write before it starts, with the ring buffer unlocked.
+	Delay time.Duration
+	// Wakeups makes every Read and Write wake up all blocked readers and writers
+	// and notify the channels of ReadableC and WritableC, whether or not there is
+	// data or space for them.
+	Wakeups bool
+}
+
+// faultState holds the faults of a ring buffer and counts the calls they apply to.
+type faultState struct {
+	Faults
+	writes, reads int
+}
+
+// SetFaults sets the faults injected into Read and Write, for testing.
+// A zero Faults removes them.
+func (r *RingBuffer) SetFaults(f Faults) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	if f == (Faults{}) {
+		r.faults = nil
+		return r
+	}
+	r.faults = &faultState{Faults: f}
+	return r
+}
+
+// faultWrite injects the faults of a Write of p.
+// It returns the part of p to write, and an error to return instead of writing.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) faultWrite(p []byte) ([]byte, error) {
+	f := r.faults
+	if f == nil {
+		return p, nil
+	}
+	f.writes++
+	r.injectCommon(f)
+	if f.FullEvery > 0 && f.writes%f.FullEvery == 0 {
+		return p, ErrIsFull
+	}
+	if f.MaxWrite > 0 && len(p) > f.MaxWrite {
+		p = p[:f.MaxWrite]
+	}
+	return p, nil
+}
+
+// faultRead injects the faults of a Read into p.
+// It returns the part of p to read into, and an error to return instead of reading.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) faultRead(p []byte) ([]byte, error) {
+	f := r.faults
+	if f == nil {
+		return p, nil
+	}
+	f.reads++
+	r.injectCommon(f)
+	if f.EmptyEvery > 0 && f.reads%f.EmptyEvery == 0 {
+		return p, ErrIsEmpty
+	}
+	if f.MaxRead > 0 && len(p) > f.MaxRead {
+		p = p[:f.MaxRead]
+	}
+	return p, nil
+}
+
+// injectCommon injects the delay and the wakeups of f.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) injectCommon(f *faultState) {
+	if f.Delay > 0 {
+		r.unlock()
+		time.Sleep(f.Delay)
+		r.mu.Lock()
+	}
+	if f.Wakeups {
+		if r.block {
+			r.readCond.Broadcast()
+			r.writeCond.Broadcast()
+		}
+		notify(r.readableC)
+		notify(r.writableC)
+	}
+}
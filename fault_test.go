@@ -0,0 +1,62 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Faults(t *testing.T) {
+	rb := New(16).SetFaults(Faults{FullEvery: 2, EmptyEvery: 3, MaxWrite: 3, MaxRead: 2})
+	if n, err := rb.Write([]byte("ab")); err != nil || n != 2 {
+		t.Fatalf("expected 2 bytes, got %d, %v", n, err)
+	}
+	if n, err := rb.Write([]byte("cd")); err != ErrIsFull || n != 0 {
+		t.Fatalf("expected ErrIsFull, got %d, %v", n, err)
+	}
+	if n, err := rb.Write([]byte("cdef")); err != ErrTooMuchDataToWrite || n != 3 {
+		t.Fatalf("expected a short write of 3 bytes, got %d, %v", n, err)
+	}
+
+	buf := make([]byte, 8)
+	if n, err := rb.Read(buf); err != nil || string(buf[:n]) != "ab" {
+		t.Fatalf("expected ab, got %q, %v", buf[:n], err)
+	}
+	if n, err := rb.Read(buf); err != nil || string(buf[:n]) != "cd" {
+		t.Fatalf("expected cd, got %q, %v", buf[:n], err)
+	}
+	if _, err := rb.Read(buf); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	if rb.Length() != 1 {
+		t.Fatalf("expected length 1, got %d", rb.Length())
+	}
+
+	rb.SetFaults(Faults{})
+	if n, err := rb.Read(buf); err != nil || string(buf[:n]) != "e" {
+		t.Fatalf("expected e, got %q, %v", buf[:n], err)
+	}
+}
+
+func TestRingBuffer_FaultsBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true).SetFaults(Faults{EmptyEvery: 1})
+	// Injected errors are returned even in blocking mode, instead of waiting.
+	if _, err := rb.Read(make([]byte, 4)); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+
+	rb.SetFaults(Faults{Delay: 20 * time.Millisecond, Wakeups: true})
+	c := rb.ReadableC()
+	start := time.Now()
+	rb.Write([]byte("a"))
+	if d := time.Since(start); d < 20*time.Millisecond {
+		t.Fatalf("expected a delay of 20ms, got %v", d)
+	}
+	rb.Read(make([]byte, 1))
+	// The read notifies readers even though the buffer is empty now.
+	select {
+	case <-c:
+	default:
+		t.Fatalf("expected a spurious notification")
+	}
+}
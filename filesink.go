@@ -0,0 +1,236 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// FileSink drains a ring buffer to a file in the background,
+// rotating the file when it reaches a maximum size.
+// It is created by AttachFileSink.
+type FileSink struct {
+	rb         *RingBuffer
+	path       string
+	maxSize    int64
+	maxFiles   int
+	flushEvery time.Duration
+
+	f    *os.File
+	w    *bufio.Writer
+	size int64 // Bytes in the current file.
+	buf  []byte
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+	err  error // First error of the drainer, set before done is closed.
+}
+
+// AttachFileSink starts a goroutine that reads everything written to the ring buffer
+// and appends it to the file at path, so the ring buffer acts as an
+// asynchronous log writer.
+// When the file would grow beyond maxSize bytes it is rotated:
+// path.1 is renamed to path.2 and so on, path is renamed to path.1,
+// at most maxFiles rotated files are kept, and writing continues in a new file at path.
+// A maxFiles of 0 or less keeps no rotated files: the file at path is truncated in place
+// and the data written to it so far is discarded, so it never grows beyond maxSize.
+// A maxSize of 0 or less disables rotation.
+// Data is flushed to the file every flushEvery, or after every read if flushEvery is 0 or less.
+//
+// The sink must be the only reader of the ring buffer.
+// It reads without waiting, so it works in blocking and non-blocking mode,
+// and it keeps running across Reset.
+// It stops when the writer of the ring buffer is closed and all data is written,
+// when the ring buffer is closed with an error, or when Close is called.
+func (r *RingBuffer) AttachFileSink(path string, maxSize int64, maxFiles int, flushEvery time.Duration) (*FileSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	s := &FileSink{
+		rb:         r,
+		path:       path,
+		maxSize:    maxSize,
+		maxFiles:   maxFiles,
+		flushEvery: flushEvery,
+		f:          f,
+		w:          bufio.NewWriter(f),
+		size:       fi.Size(),
+		buf:        make([]byte, 32*1024),
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Close stops the sink after writing the data buffered in the ring buffer,
+// and closes the file.
+// It returns the first error encountered by the sink, if any.
+func (s *FileSink) Close() error {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+	return s.err
+}
+
+// Done returns a channel that is closed when the sink has stopped.
+func (s *FileSink) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err returns the error that stopped the sink, or nil if it is running or stopped cleanly.
+func (s *FileSink) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// run drains the ring buffer until it is closed or the sink is stopped.
+func (s *FileSink) run() {
+	defer close(s.done)
+	err := s.drain()
+	if ferr := s.w.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := s.f.Close(); err == nil {
+		err = cerr
+	}
+	s.err = err
+}
+
+// drain copies data from the ring buffer to the file until it is closed or the sink is stopped.
+func (s *FileSink) drain() error {
+	var tick <-chan time.Time
+	if s.flushEvery > 0 {
+		t := time.NewTicker(s.flushEvery)
+		defer t.Stop()
+		tick = t.C
+	}
+	readable := s.rb.ReadableC()
+	stopped := false
+	for {
+		n, err := s.rb.readAvailable(s.buf)
+		if n > 0 {
+			if err := s.write(s.buf[:n]); err != nil {
+				return err
+			}
+			if tick == nil {
+				if err := s.w.Flush(); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		switch err {
+		case ErrIsEmpty, ErrReset:
+			// A concurrent Reset is not an error for the sink, it keeps draining.
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+		if stopped {
+			return nil
+		}
+		select {
+		case <-readable:
+		case <-tick:
+			if err := s.w.Flush(); err != nil {
+				return err
+			}
+		case <-s.stop:
+			// Write what is buffered before stopping.
+			stopped = true
+		}
+	}
+}
+
+// write appends p to the file, rotating it when it reaches the maximum size.
+func (s *FileSink) write(p []byte) error {
+	for len(p) > 0 {
+		if s.maxSize > 0 && s.size >= s.maxSize {
+			if err := s.rotate(); err != nil {
+				return err
+			}
+		}
+		chunk := p
+		if s.maxSize > 0 && int64(len(chunk)) > s.maxSize-s.size {
+			chunk = chunk[:s.maxSize-s.size]
+		}
+		n, err := s.w.Write(chunk)
+		s.size += int64(n)
+		if err != nil {
+			return err
+		}
+		p = p[n:]
+	}
+	return nil
+}
+
+// rotate closes the current file, shifts the rotated files
+// and opens a new file at path.
+// If no rotated files are kept, the current file is truncated instead.
+func (s *FileSink) rotate() error {
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	if s.maxFiles > 0 {
+		os.Remove(s.rotated(s.maxFiles))
+		for i := s.maxFiles - 1; i >= 1; i-- {
+			if err := os.Rename(s.rotated(i), s.rotated(i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(s.path, s.rotated(1)); err != nil {
+			return err
+		}
+	}
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	s.f = f
+	s.w.Reset(f)
+	s.size = 0
+	return nil
+}
+
+// rotated returns the path of the i-th rotated file.
+func (s *FileSink) rotated(i int) string {
+	return s.path + "." + strconv.Itoa(i)
+}
+
+// readAvailable reads buffered data into p without waiting,
+// whether or not the ring buffer is blocking.
+// It returns ErrIsEmpty if there is no data.
+func (r *RingBuffer) readAvailable(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.readErr(true); err != nil {
+		return 0, err
+	}
+	n, err = r.read(p)
+	if r.block && n > 0 {
+		r.readCond.Broadcast()
+	}
+	return n, err
+}
@@ -0,0 +1,106 @@
+package ringbuffer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_AttachFileSink(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	path := filepath.Join(t.TempDir(), "log")
+	rb := New(64)
+	s, err := rb.AttachFileSink(path, 10, 2, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n", "dddd\n", "eeee\n"} {
+		for {
+			if _, err := rb.WriteString(line); err != ErrIsFull {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	rb.CloseWriter()
+	<-s.Done()
+	if err := s.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for name, want := range map[string]string{
+		path:        "eeee\n",
+		path + ".1": "cccc\ndddd\n",
+		path + ".2": "aaaa\nbbbb\n",
+	} {
+		b, err := os.ReadFile(name)
+		if err != nil || string(b) != want {
+			t.Fatalf("expected %q in %s, got %q, %v", want, name, b, err)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected at most 2 rotated files, got %v", err)
+	}
+}
+
+func TestRingBuffer_FileSinkTruncate(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	path := filepath.Join(t.TempDir(), "log")
+	rb := New(64)
+	s, err := rb.AttachFileSink(path, 10, 0, 0)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, line := range []string{"aaaa\n", "bbbb\n", "cccc\n"} {
+		for {
+			if _, err := rb.WriteString(line); err != ErrIsFull {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	rb.CloseWriter()
+	<-s.Done()
+	if err := s.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if b, err := os.ReadFile(path); err != nil || string(b) != "cccc\n" {
+		t.Fatalf("expected the file to be truncated in place, got %q, %v", b, err)
+	}
+	if _, err := os.Stat(path + ".1"); !os.IsNotExist(err) {
+		t.Fatalf("expected no rotated files, got %v", err)
+	}
+}
+
+func TestRingBuffer_FileSinkClose(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	path := filepath.Join(t.TempDir(), "log")
+	rb := New(1024).SetBlocking(true)
+	s, err := rb.AttachFileSink(path, 0, 0, time.Hour)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	var want strings.Builder
+	for i := 0; i < 100; i++ {
+		rb.WriteString("line\n")
+		want.WriteString("line\n")
+		if i == 50 {
+			// The sink keeps draining across a concurrent Reset.
+			rb.ResetWith(ResetWait)
+			want.Reset()
+		}
+	}
+	rb.Flush()
+	if err := s.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	b, _ := os.ReadFile(path)
+	if !strings.HasSuffix(string(b), want.String()) {
+		t.Fatalf("expected the data written after Reset, got %d bytes", len(b))
+	}
+	if s.Err() != nil {
+		t.Fatalf("expected no error, got %v", s.Err())
+	}
+}
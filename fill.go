@@ -0,0 +1,122 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"io"
+	"sync"
+)
+
+// StartFill starts a goroutine that keeps the ring buffer topped up with data read from src,
+// like a ReadFrom that runs in the background and shares the buffer with other writers.
+// It reads from src only as much as fits in the free space,
+// and writes without waiting, so it works in blocking and non-blocking mode.
+//
+// The goroutine stops when src returns io.EOF, leaving the writer of the ring buffer open,
+// or when the ring buffer is closed.
+// If src fails with another error, the ring buffer is closed with it,
+// so readers and writers fail with the error.
+// The returned stop function stops the goroutine and returns the error
+// that stopped it, if any. It waits for a pending Read of src to return,
+// and data read from src that does not fit at that point is discarded.
+// After stop returns, StartFill can be called again.
+func (r *RingBuffer) StartFill(src io.Reader) (stop func() error) {
+	stopC := make(chan struct{})
+	done := make(chan struct{})
+	var err error
+	go func() {
+		defer close(done)
+		err = r.topUp(src, stopC)
+	}()
+	var once sync.Once
+	return func() error {
+		once.Do(func() { close(stopC) })
+		<-done
+		return err
+	}
+}
+
+// topUp copies data from src to the ring buffer until src is drained or stop is closed.
+func (r *RingBuffer) topUp(src io.Reader, stop <-chan struct{}) error {
+	buf := make([]byte, 32*1024)
+	writable := r.WritableC()
+	for {
+		free, err := r.fillSpace()
+		if err != nil {
+			return err
+		}
+		if free == 0 {
+			select {
+			case <-writable:
+				continue
+			case <-stop:
+				return nil
+			}
+		}
+		if free > len(buf) {
+			free = len(buf)
+		}
+		nr, rerr := src.Read(buf[:free])
+		for p := buf[:nr]; len(p) > 0; {
+			n, err := r.writeAvailable(p)
+			p = p[n:]
+			switch err {
+			case nil, ErrIsFull, ErrTooMuchDataToWrite, ErrReset:
+			default:
+				return err
+			}
+			if len(p) == 0 {
+				break
+			}
+			// Other writers took the space.
+			select {
+			case <-writable:
+			case <-stop:
+				return nil
+			}
+		}
+		if rerr == io.EOF {
+			return nil
+		}
+		if rerr != nil {
+			r.CloseWithError(rerr)
+			return rerr
+		}
+		select {
+		case <-stop:
+			return nil
+		default:
+		}
+	}
+}
+
+// fillSpace returns the free space of the ring buffer,
+// or the error a write would fail with.
+func (r *RingBuffer) fillSpace() (int, error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+	if r.overwrite || r.maxSize > r.size {
+		return r.size, nil
+	}
+	return r.free(), nil
+}
+
+// writeAvailable writes as much of p as fits without waiting,
+// whether or not the ring buffer is blocking.
+func (r *RingBuffer) writeAvailable(p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+	n, err = r.write(p)
+	if r.block && n > 0 {
+		r.writeCond.Broadcast()
+	}
+	return n, r.setErr(err, true)
+}
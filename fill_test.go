@@ -0,0 +1,59 @@
+package ringbuffer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_StartFill(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8)
+	src := strings.NewReader("abcdefghijklmnop")
+	stop := rb.StartFill(src)
+
+	var got []byte
+	buf := make([]byte, 4)
+	for len(got) < 16 {
+		n, err := rb.Read(buf)
+		if err != nil && err != ErrIsEmpty {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		got = append(got, buf[:n]...)
+		time.Sleep(time.Millisecond)
+	}
+	if string(got) != "abcdefghijklmnop" {
+		t.Fatalf("expected abcdefghijklmnop, got %q", got)
+	}
+	if err := stop(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	// Other writers share the buffer, and the fill can be restarted.
+	rb.WriteString("x")
+	stop = rb.StartFill(strings.NewReader("yz"))
+	if err := stop(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := rb.Write(nil); err != nil {
+		t.Fatalf("expected the writer to stay open, got %v", err)
+	}
+}
+
+func TestRingBuffer_StartFillError(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	errTest := errors.New("test")
+	rb := New(8).SetBlocking(true)
+	stop := rb.StartFill(iotestErrReader{errTest})
+	if _, err := rb.Read(make([]byte, 4)); err != errTest {
+		t.Fatalf("expected %v, got %v", errTest, err)
+	}
+	if err := stop(); err != errTest {
+		t.Fatalf("expected %v, got %v", errTest, err)
+	}
+}
+
+type iotestErrReader struct{ err error }
+
+func (r iotestErrReader) Read(p []byte) (int, error) { return 0, r.err }
@@ -0,0 +1,124 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"time"
+)
+
+// SnapshotFile returns an fs.File with a copy of the unread data, taken now,
+// so the buffered data can be passed to APIs that consume files,
+// for example to add it to a zip archive of diagnostics.
+// The file is named name and also implements io.ReaderAt and io.Seeker.
+// Its modification time is the time of the last write,
+// or the zero time if write times are not kept, see LastRead.
+// It does not move the read pointer.
+// The data of an encrypted ring buffer is decrypted, see SetEncryptionKey.
+func (r *RingBuffer) SnapshotFile(name string) fs.File {
+	defer r.runlock(r.rlock())
+	data := make([]byte, r.length())
+	r.peekAt(data, 0)
+	return &snapshotFile{Reader: bytes.NewReader(data), info: snapshotInfo{name: name, size: int64(len(data)), modTime: r.lastWrite}}
+}
+
+// FS returns a file system holding a single file named name,
+// which must be a single path element like "ring.log".
+// Each time the file is opened it holds a new snapshot of the unread data,
+// as returned by SnapshotFile, so the live contents of the ring buffer
+// can for example be served with http.FileServer(http.FS(rb.FS("ring.log"))).
+// Opening the file fails with ErrEncrypted if the ring buffer is encrypted.
+func (r *RingBuffer) FS(name string) fs.FS {
+	return snapshotFS{rb: r, name: name}
+}
+
+// snapshotFS is the file system returned by FS.
+type snapshotFS struct {
+	rb   *RingBuffer
+	name string
+}
+
+// Open opens the snapshot file or the root directory.
+func (s snapshotFS) Open(name string) (fs.File, error) {
+	switch {
+	case !fs.ValidPath(name):
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	case name == s.name:
+		if s.rb.encrypted() {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: ErrEncrypted}
+		}
+		return s.rb.SnapshotFile(name), nil
+	case name == ".":
+		f := s.rb.SnapshotFile(s.name).(*snapshotFile)
+		return &snapshotDir{file: f.info}, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// snapshotFile is a file returned by SnapshotFile.
+type snapshotFile struct {
+	*bytes.Reader
+	info snapshotInfo
+}
+
+// Stat returns the name, size and modification time of the snapshot.
+func (f *snapshotFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+// Close does nothing.
+func (f *snapshotFile) Close() error { return nil }
+
+// snapshotDir is the root directory of a snapshotFS.
+type snapshotDir struct {
+	file snapshotInfo
+	read bool // Whether ReadDir returned the file.
+}
+
+// Stat returns the information of the directory.
+func (d *snapshotDir) Stat() (fs.FileInfo, error) {
+	return snapshotInfo{name: ".", modTime: d.file.modTime, dir: true}, nil
+}
+
+// Read fails, since d is a directory.
+func (d *snapshotDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+
+// Close does nothing.
+func (d *snapshotDir) Close() error { return nil }
+
+// ReadDir returns the entry of the snapshot file.
+func (d *snapshotDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if d.read {
+		if n > 0 {
+			return nil, io.EOF
+		}
+		return nil, nil
+	}
+	d.read = true
+	return []fs.DirEntry{fs.FileInfoToDirEntry(d.file)}, nil
+}
+
+// snapshotInfo is the fs.FileInfo of a snapshot file or its directory.
+type snapshotInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i snapshotInfo) Name() string       { return i.name }
+func (i snapshotInfo) Size() int64        { return i.size }
+func (i snapshotInfo) ModTime() time.Time { return i.modTime }
+func (i snapshotInfo) IsDir() bool        { return i.dir }
+func (i snapshotInfo) Sys() interface{}   { return nil }
+
+func (i snapshotInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+	return 0o444
+}
@@ -0,0 +1,62 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRingBuffer_SnapshotFile(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 2))
+	rb.Write([]byte("ghij"))
+
+	f := rb.SnapshotFile("ring.log")
+	rb.Write([]byte("x"))
+	info, err := f.Stat()
+	if err != nil || info.Name() != "ring.log" || info.Size() != 8 {
+		t.Fatalf("expected ring.log of 8 bytes, got %v, %v", info, err)
+	}
+	p := make([]byte, 3)
+	if n, err := f.(io.ReaderAt).ReadAt(p, 4); err != nil || string(p[:n]) != "ghi" {
+		t.Fatalf("expected ghi, got %q, %v", p[:n], err)
+	}
+	data, err := io.ReadAll(f)
+	if err != nil || string(data) != "cdefghij" {
+		t.Fatalf("expected cdefghij, got %q, %v", data, err)
+	}
+	if rb.Length() != 8 {
+		t.Fatalf("expected the data not to be consumed, got length %d", rb.Length())
+	}
+}
+
+func TestRingBuffer_FS(t *testing.T) {
+	rb := New(16)
+	rb.Write([]byte("hello"))
+	fsys := rb.FS("ring.log")
+	if err := fstest.TestFS(fsys, "ring.log"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fsys.Open("other"); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("expected fs.ErrNotExist, got %v", err)
+	}
+
+	// Each request serves the current contents.
+	srv := httptest.NewServer(http.FileServer(http.FS(fsys)))
+	defer srv.Close()
+	rb.Write([]byte(" world"))
+	resp, err := http.Get(srv.URL + "/ring.log")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "hello world" {
+		t.Fatalf("expected hello world, got %q", body)
+	}
+}
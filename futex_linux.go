@@ -0,0 +1,38 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"math"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+// Futex operations, without FUTEX_PRIVATE_FLAG so they work across processes.
+const (
+	futexWaitOp = 0
+	futexWakeOp = 1
+)
+
+// futexWait sleeps until addr is woken up by futexWake, if it still holds val.
+// It returns nil when woken up, interrupted or if addr no longer holds val.
+func futexWait(addr *atomic.Uint32, val uint32) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_FUTEX, uintptr(unsafe.Pointer(addr)), futexWaitOp, uintptr(val), 0, 0, 0)
+	if errno != 0 && errno != syscall.EAGAIN && errno != syscall.EINTR {
+		return os.NewSyscallError("futex", errno)
+	}
+	return nil
+}
+
+// futexWake wakes up all processes sleeping in futexWait on addr.
+func futexWake(addr *atomic.Uint32) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_FUTEX, uintptr(unsafe.Pointer(addr)), futexWakeOp, math.MaxInt32, 0, 0, 0)
+	if errno != 0 {
+		return os.NewSyscallError("futex", errno)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !linux
+
+package ringbuffer
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// futexWait polls until addr no longer holds val, for platforms without futexes.
+func futexWait(addr *atomic.Uint32, val uint32) error {
+	for i := 0; i < 100 && addr.Load() == val; i++ {
+		time.Sleep(100 * time.Microsecond)
+	}
+	return nil
+}
+
+// futexWake does nothing, since futexWait polls.
+func futexWake(addr *atomic.Uint32) error { return nil }
@@ -0,0 +1,84 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// SetAutoGrow lets writes grow the buffer up to max bytes
+// instead of blocking or returning ErrIsFull or ErrTooMuchDataToWrite.
+// When data does not fit, the buffer is reallocated to at least double its size,
+// and buffered data is kept.
+// Once the buffer has reached max bytes, writes behave as usual.
+// A max of 0 or less than the current capacity disables growing (default).
+//
+// The buffer is not grown while ReadFrom or WriteTo are copying directly
+// to or from it, and descriptors returned by ReadDescriptors and WriteDescriptors
+// must not be used after a write that may have grown it.
+func (r *RingBuffer) SetAutoGrow(max int) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.maxSize = max
+	return r
+}
+
+// Resize changes the capacity of the ring buffer to newSize bytes,
+// reallocating the buffer and keeping the buffered data.
+// Blocked writers are woken up, so they can use the new space.
+// ErrBufferTooSmall is returned and nothing is changed if the buffered data
+// does not fit in newSize bytes.
+// Like SwapBuffer, Resize waits for ReadFrom and WriteTo calls that are copying
+// directly to or from the buffer.
+func (r *RingBuffer) Resize(newSize int) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.buf == nil {
+		return ErrReleased
+	}
+	if newSize == r.size {
+		return nil
+	}
+	r.waitUnlent()
+	_, err := r.replaceBuffer(make([]byte, newSize))
+	return err
+}
+
+// Compact shrinks the buffer to release memory of a ring buffer that is mostly empty.
+// The new capacity is the size of the buffered data plus minFree bytes, but at least 1,
+// and the buffer is only reallocated if that is smaller than the current capacity.
+// Compact can be combined with SetAutoGrow, so the buffer grows again when needed.
+// Like SwapBuffer, Compact waits for ReadFrom and WriteTo calls that are copying
+// directly to or from the buffer.
+func (r *RingBuffer) Compact(minFree int) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.buf == nil {
+		return ErrReleased
+	}
+	r.waitUnlent()
+	_, _, total := r.retained()
+	size := total + minFree
+	if size < 1 {
+		size = 1
+	}
+	if size >= r.size {
+		return nil
+	}
+	_, err := r.replaceBuffer(make([]byte, size))
+	return err
+}
+
+// grow grows the buffer so n more bytes fit, as far as auto grow allows.
+// Must be called when locked.
+func (r *RingBuffer) grow(n int) {
+	if r.maxSize <= r.size || r.lent > 0 || r.buf == nil || r.mapped != nil || r.free() >= n {
+		return
+	}
+	size := r.size * 2
+	if need := r.size - r.free() + n; size < need {
+		size = need
+	}
+	if size > r.maxSize {
+		size = r.maxSize
+	}
+	r.replaceBuffer(make([]byte, size))
+}
@@ -0,0 +1,156 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_AutoGrow(t *testing.T) {
+	rb := New(4).SetAutoGrow(20)
+	rb.Write([]byte("ab"))
+	rb.Read(make([]byte, 1))
+	if n, err := rb.Write([]byte("cdefg")); err != nil || n != 5 {
+		t.Fatalf("expected 5 bytes written, got %d, %v", n, err)
+	}
+	if rb.Capacity() != 8 {
+		t.Fatalf("expected capacity 8, got %d", rb.Capacity())
+	}
+	if n, err := rb.Write([]byte("hijklmnopq")); err != nil || n != 10 {
+		t.Fatalf("expected 10 bytes written, got %d, %v", n, err)
+	}
+	if rb.Capacity() != 16 {
+		t.Fatalf("expected capacity 16, got %d", rb.Capacity())
+	}
+	for _, c := range []byte("rstu") {
+		if err := rb.WriteByte(c); err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	}
+	if rb.Capacity() != 20 {
+		t.Fatalf("expected capacity 20, got %d", rb.Capacity())
+	}
+	if err := rb.WriteByte('v'); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull at the maximum, got %v", err)
+	}
+	if got := string(rb.Bytes(nil)); got != "bcdefghijklmnopqrstu" {
+		t.Fatalf("expected bcdefghijklmnopqrstu, got %q", got)
+	}
+}
+
+func TestRingBuffer_AutoGrowReadFrom(t *testing.T) {
+	rb := New(4).SetAutoGrow(1024)
+	data := strings.Repeat("0123456789", 50)
+	n, err := rb.ReadFrom(strings.NewReader(data))
+	if err != nil || n != int64(len(data)) {
+		t.Fatalf("expected %d bytes, got %d, %v", len(data), n, err)
+	}
+	if got := rb.Bytes(nil); !bytes.Equal(got, []byte(data)) {
+		t.Fatalf("data mismatch")
+	}
+	if rb.Capacity() != 512 {
+		t.Fatalf("expected capacity 512, got %d", rb.Capacity())
+	}
+}
+
+func TestRingBuffer_AutoGrowAccounting(t *testing.T) {
+	acct := &testAccountant{}
+	rb := New(8).WithAccountant(acct).SetAutoGrow(64)
+	rb.Write(make([]byte, 40))
+	if got := acct.used.Load(); got != int64(rb.Capacity()) {
+		t.Fatalf("expected %d bytes used, got %d", rb.Capacity(), got)
+	}
+}
+
+func TestRingBuffer_Resize(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true)
+	rb.Write([]byte("abcd"))
+	done := make(chan error)
+	go func() {
+		_, err := rb.Write([]byte("efgh"))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := rb.Resize(8); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("expected the blocked write to complete, got %v", err)
+	}
+	if got := string(rb.Bytes(nil)); got != "abcdefgh" {
+		t.Fatalf("expected abcdefgh, got %q", got)
+	}
+
+	if err := rb.Resize(6); err != ErrBufferTooSmall {
+		t.Fatalf("expected ErrBufferTooSmall, got %v", err)
+	}
+	rb.Read(make([]byte, 5))
+	if err := rb.Resize(3); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if rb.Capacity() != 3 || !rb.IsFull() {
+		t.Fatalf("expected a full buffer of 3 bytes, got capacity %d and length %d", rb.Capacity(), rb.Length())
+	}
+	if got := string(rb.Bytes(nil)); got != "fgh" {
+		t.Fatalf("expected fgh, got %q", got)
+	}
+}
+
+func TestRingBuffer_CapacityDuringResize(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			rb.Resize(4 + i%8)
+		}
+	}()
+	for {
+		select {
+		case <-done:
+			if rb.Capacity() != 11 {
+				t.Fatalf("expected capacity 11, got %d", rb.Capacity())
+			}
+			return
+		default:
+			if c := rb.Capacity(); c < 4 || c > 11 {
+				t.Fatalf("expected capacity between 4 and 11, got %d", c)
+			}
+		}
+	}
+}
+
+func TestRingBuffer_Compact(t *testing.T) {
+	acct := &testAccountant{}
+	rb := New(1024).WithAccountant(acct).SetAutoGrow(1024)
+	rb.Write([]byte("hello"))
+	if err := rb.Compact(11); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if rb.Capacity() != 16 {
+		t.Fatalf("expected capacity 16, got %d", rb.Capacity())
+	}
+	if got := acct.used.Load(); got != 16 {
+		t.Fatalf("expected 16 bytes used, got %d", got)
+	}
+	if got := string(rb.Bytes(nil)); got != "hello" {
+		t.Fatalf("expected hello, got %q", got)
+	}
+
+	// Compact never grows the buffer.
+	if err := rb.Compact(100); err != nil || rb.Capacity() != 16 {
+		t.Fatalf("expected capacity 16, got %d, %v", rb.Capacity(), err)
+	}
+
+	rb.Read(make([]byte, 5))
+	if err := rb.Compact(0); err != nil || rb.Capacity() != 1 {
+		t.Fatalf("expected capacity 1, got %d, %v", rb.Capacity(), err)
+	}
+	rb.Write([]byte("grows again"))
+	if got := string(rb.Bytes(nil)); got != "grows again" {
+		t.Fatalf("expected grows again, got %q", got)
+	}
+}
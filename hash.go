@@ -0,0 +1,75 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "hash"
+
+// WithHash sets a hash that is updated with all bytes written to the ring buffer.
+// The hash is updated while the data is copied into the buffer,
+// so it works with all write methods including ReadFrom.
+// The hash is reset when the ring buffer is Reset.
+// A nil hash disables hashing of written data.
+func (r *RingBuffer) WithHash(h hash.Hash) *RingBuffer {
+	r.mu.Lock()
+	r.wHash = h
+	r.unlock()
+	return r
+}
+
+// WithReadHash sets a hash that is updated with all bytes read from the ring buffer.
+// The hash is updated while the data is consumed,
+// so it works with all read methods including WriteTo.
+// The hash is reset when the ring buffer is Reset.
+// A nil hash disables hashing of read data.
+func (r *RingBuffer) WithReadHash(h hash.Hash) *RingBuffer {
+	r.mu.Lock()
+	r.rHash = h
+	r.unlock()
+	return r
+}
+
+// Sum appends the current hash of all written bytes to b and returns the resulting slice.
+// If no hash has been set with WithHash, b is returned unchanged.
+func (r *RingBuffer) Sum(b []byte) []byte {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.wHash == nil {
+		return b
+	}
+	return r.wHash.Sum(b)
+}
+
+// ReadSum appends the current hash of all read bytes to b and returns the resulting slice.
+// If no hash has been set with WithReadHash, b is returned unchanged.
+func (r *RingBuffer) ReadSum(b []byte) []byte {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.rHash == nil {
+		return b
+	}
+	return r.rHash.Sum(b)
+}
+
+// hashWrite adds p to the write hash and passes it to the write tee.
+// Must be called when locked.
+func (r *RingBuffer) hashWrite(p []byte) {
+	if r.wHash != nil {
+		r.wHash.Write(p)
+	}
+	if r.wTee != nil {
+		r.wTee.Write(p)
+	}
+}
+
+// hashRead adds p to the read hash and passes it to the read tee.
+// Must be called when locked.
+func (r *RingBuffer) hashRead(p []byte) {
+	if r.rHash != nil {
+		r.rHash.Write(p)
+	}
+	if r.rTee != nil {
+		r.rTee.Write(p)
+	}
+}
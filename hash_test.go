@@ -0,0 +1,73 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer_Hash(t *testing.T) {
+	rb := New(16).WithHash(crc32.NewIEEE()).WithReadHash(crc32.NewIEEE())
+
+	data := []byte(strings.Repeat("0123456789", 10))
+	var out bytes.Buffer
+	for i := 0; i < len(data); {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		n, _ := rb.Write(data[i:end])
+		i += n
+		if i%3 == 0 {
+			b, _ := rb.ReadByte()
+			out.WriteByte(b)
+		}
+		buf := make([]byte, 5)
+		n, _ = rb.Read(buf)
+		out.Write(buf[:n])
+	}
+	rb.WriteToAvailable(&out)
+	if !bytes.Equal(out.Bytes(), data) {
+		t.Fatalf("expected %q, got %q", data, out.Bytes())
+	}
+
+	want := crc32.NewIEEE()
+	want.Write(data)
+	if got := rb.Sum(nil); !bytes.Equal(got, want.Sum(nil)) {
+		t.Fatalf("write sum: expected %x, got %x", want.Sum(nil), got)
+	}
+	if got := rb.ReadSum(nil); !bytes.Equal(got, want.Sum(nil)) {
+		t.Fatalf("read sum: expected %x, got %x", want.Sum(nil), got)
+	}
+
+	rb.Reset()
+	want.Reset()
+	if got := rb.Sum(nil); !bytes.Equal(got, want.Sum(nil)) {
+		t.Fatalf("expected reset sum %x, got %x", want.Sum(nil), got)
+	}
+}
+
+func TestRingBuffer_HashCopy(t *testing.T) {
+	data := bytes.Repeat([]byte("hello world"), 1000)
+	rb := New(1024).WithHash(sha256.New()).WithReadHash(sha256.New())
+	n, err := rb.Copy(io.Discard, bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != int64(len(data)) {
+		t.Fatalf("expected %d bytes, got %d", len(data), n)
+	}
+	want := sha256.Sum256(data)
+	if got := rb.Sum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("write sum: expected %x, got %x", want, got)
+	}
+	if got := rb.ReadSum(nil); !bytes.Equal(got, want[:]) {
+		t.Fatalf("read sum: expected %x, got %x", want, got)
+	}
+	if got := New(1).Sum([]byte("x")); string(got) != "x" {
+		t.Fatalf("expected unchanged slice, got %q", got)
+	}
+}
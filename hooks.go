@@ -0,0 +1,150 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// BlockKind tells whether a reader or a writer is blocked.
+type BlockKind int
+
+const (
+	// BlockRead means a reader is waiting for data to be written.
+	BlockRead BlockKind = iota
+	// BlockWrite means a writer is waiting for free space.
+	BlockWrite
+)
+
+// String returns "read" or "write".
+func (k BlockKind) String() string {
+	if k == BlockRead {
+		return "read"
+	}
+	return "write"
+}
+
+// Hooks are callbacks observing the events of a ring buffer,
+// for example to record tracing spans or to drive flow control.
+// Any of them can be nil.
+type Hooks struct {
+	OnWrite func(n int)     // n bytes have been written.
+	OnRead  func(n int)     // n bytes have been read.
+	OnBlock func(BlockKind) // A reader or writer started waiting.
+	OnClose func(err error) // The ring buffer was closed with err, io.EOF by CloseWriter.
+}
+
+// hookEvent is an event waiting to be passed to the hooks.
+type hookEvent struct {
+	kind int // One of the ev* constants.
+	n    int
+	err  error
+}
+
+const (
+	evWrite = iota
+	evRead
+	evBlock
+	evClose
+)
+
+// hookState holds the hooks of a ring buffer and the events not yet passed to them.
+type hookState struct {
+	Hooks
+	events  []hookEvent
+	blocked [2]bool // Whether OnBlock was called for a wait that is not over yet.
+}
+
+// SetHooks sets the hooks called on the events of the ring buffer.
+// Unlike the callbacks of SetWatermarks and SetOverwrite, hooks are called
+// outside the lock, once the operation that caused the event releases it,
+// so they can call back into the ring buffer.
+// Hooks may be called concurrently by different goroutines.
+//
+// OnBlock is called when a reader or writer starts waiting,
+// and not again for the same kind until data has been written or read.
+// A zero Hooks removes the hooks.
+func (r *RingBuffer) SetHooks(h Hooks) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	if h.OnWrite == nil && h.OnRead == nil && h.OnBlock == nil && h.OnClose == nil {
+		r.hooks = nil
+		return r
+	}
+	r.hooks = &hookState{Hooks: h}
+	return r
+}
+
+// addHookEvent records an event for the hooks, if they are set.
+// Must be called when locked.
+func (r *RingBuffer) addHookEvent(kind, n int, err error) {
+	h := r.hooks
+	if h == nil {
+		return
+	}
+	switch kind {
+	case evWrite:
+		h.blocked[BlockRead] = false
+		if h.OnWrite == nil || n == 0 {
+			return
+		}
+	case evRead:
+		h.blocked[BlockWrite] = false
+		if h.OnRead == nil || n == 0 {
+			return
+		}
+	case evClose:
+		if h.OnClose == nil {
+			return
+		}
+	}
+	h.events = append(h.events, hookEvent{kind: kind, n: n, err: err})
+}
+
+// hookBlock calls the OnBlock hook before a wait of the given kind,
+// unless it was called for the current wait already.
+// Since the hook is called unlocked, it returns true if it was called,
+// in which case the caller must check its condition again instead of waiting.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) hookBlock(kind BlockKind) (unlocked bool) {
+	h := r.hooks
+	if h == nil || h.OnBlock == nil || h.blocked[kind] {
+		return false
+	}
+	h.blocked[kind] = true
+	h.events = append(h.events, hookEvent{kind: evBlock, n: int(kind)})
+	r.unlock()
+	r.mu.Lock()
+	return true
+}
+
+// unlock publishes the length, unlocks the ring buffer and then passes the recorded events to the hooks.
+// With the ringbuffer_debug build tag it first checks the invariants,
+// and panics after unlocking if one is violated.
+func (r *RingBuffer) unlock() {
+	if debugInvariants {
+		if err := r.checkInvariants(); err != nil {
+			r.mu.Unlock()
+			panic(err)
+		}
+	}
+	r.publish()
+	h := r.hooks
+	if h == nil || len(h.events) == 0 {
+		r.mu.Unlock()
+		return
+	}
+	events := h.events
+	h.events = nil
+	r.mu.Unlock()
+	for _, e := range events {
+		switch e.kind {
+		case evWrite:
+			h.OnWrite(e.n)
+		case evRead:
+			h.OnRead(e.n)
+		case evBlock:
+			h.OnBlock(BlockKind(e.n))
+		case evClose:
+			h.OnClose(e.err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Hooks(t *testing.T) {
+	rb := New(8)
+	var written, read, lengths []int
+	rb.SetHooks(Hooks{
+		OnWrite: func(n int) {
+			written = append(written, n)
+			// Hooks are called unlocked, so they can use the ring buffer.
+			lengths = append(lengths, rb.Length())
+		},
+		OnRead: func(n int) { read = append(read, n) },
+	})
+	rb.Write([]byte("abc"))
+	rb.WriteString("de")
+	rb.Read(make([]byte, 4))
+	rb.ReadByte()
+	rb.Read(make([]byte, 4))
+
+	if len(written) != 2 || written[0] != 3 || written[1] != 2 {
+		t.Fatalf("expected writes of 3 and 2 bytes, got %v", written)
+	}
+	if lengths[0] != 3 || lengths[1] != 5 {
+		t.Fatalf("expected lengths 3 and 5, got %v", lengths)
+	}
+	if len(read) != 2 || read[0] != 4 || read[1] != 1 {
+		t.Fatalf("expected reads of 4 and 1 bytes, got %v", read)
+	}
+
+	rb.SetHooks(Hooks{})
+	rb.Write([]byte("f"))
+	if len(written) != 2 {
+		t.Fatalf("expected hooks to be removed, got %v", written)
+	}
+}
+
+func TestRingBuffer_HooksOnBlock(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true)
+	var blocked []BlockKind
+	rb.SetHooks(Hooks{
+		OnBlock: func(kind BlockKind) {
+			blocked = append(blocked, kind)
+			if kind == BlockRead {
+				rb.Write([]byte("abcd"))
+			}
+		},
+	})
+
+	buf := make([]byte, 4)
+	n, err := rb.Read(buf)
+	if err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", buf[:n], err)
+	}
+	if len(blocked) != 1 || blocked[0] != BlockRead {
+		t.Fatalf("expected one blocked read, got %v", blocked)
+	}
+
+	rb.Write([]byte("efgh"))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.Read(buf)
+	}()
+	if _, err := rb.Write([]byte("i")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(blocked) != 2 || blocked[1] != BlockWrite {
+		t.Fatalf("expected a blocked write, got %v", blocked)
+	}
+	if s := BlockWrite.String(); s != "write" {
+		t.Fatalf("expected write, got %s", s)
+	}
+}
+
+func TestRingBuffer_HooksOnClose(t *testing.T) {
+	var closed []error
+	hooks := Hooks{OnClose: func(err error) { closed = append(closed, err) }}
+
+	rb := New(4).SetHooks(hooks)
+	rb.CloseWriter()
+	if len(closed) != 1 || closed[0] != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", closed)
+	}
+
+	errTest := errors.New("test")
+	rb = New(4).SetHooks(hooks)
+	rb.CloseWithError(errTest)
+	rb.Reset()
+	if len(closed) != 2 || closed[1] != errTest {
+		t.Fatalf("expected %v, got %v", errTest, closed)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "fmt"
+
+// checkInvariants returns an error describing the first violated invariant
+// of the internal state, with a dump of the state, or nil if there is none.
+// When built with the ringbuffer_debug tag, every operation checks the invariants
+// when it releases the lock and panics with this error on a violation,
+// so corrupted state is found where it is corrupted instead of much later.
+// Must be called when locked.
+func (r *RingBuffer) checkInvariants() error {
+	var msg string
+	switch {
+	case len(r.buf) != r.size:
+		msg = "size differs from the length of buf"
+	case r.size == 0 && (r.r != 0 || r.w != 0 || r.isFull):
+		msg = "empty buf with non-zero positions or full"
+	case r.size > 0 && (r.r < 0 || r.r >= r.size):
+		msg = "read position out of range"
+	case r.size > 0 && (r.w < 0 || r.w >= r.size):
+		msg = "write position out of range"
+	case r.isFull && r.r != r.w:
+		msg = "full with different read and write positions"
+	case r.length()+r.free() != r.size:
+		msg = "length and free space do not add up to size"
+	case r.length() < 0 || r.length() > r.size:
+		msg = "length out of range"
+	case len(r.reserved) > r.free():
+		msg = "reservation larger than the free space"
+	case r.lent < 0:
+		msg = "negative number of lent buffers"
+	case r.inFlight != r.inFlightTotal():
+		msg = "operations in flight do not add up"
+	case len(r.pending) > 0 && r.pending[0].Start < r.written:
+		msg = "out-of-order data before the write offset"
+	default:
+		return nil
+	}
+	return fmt.Errorf("ringbuffer: invariant violated: %s\n%s", msg, r.dumpState())
+}
+
+// inFlightTotal returns the number of operations in flight, counted by kind.
+// Must be called when locked.
+func (r *RingBuffer) inFlightTotal() int {
+	n := 0
+	for _, c := range r.ops {
+		n += c
+	}
+	return n
+}
+
+// dumpState returns the internal state for checkInvariants.
+// Must be called when locked.
+func (r *RingBuffer) dumpState() string {
+	return fmt.Sprintf("name=%q size=%d len(buf)=%d r=%d w=%d isFull=%t length=%d free=%d "+
+		"written=%d pending=%v reserved=%d lent=%d inFlight=%d ops=%v maxSize=%d err=%v",
+		r.name, r.size, len(r.buf), r.r, r.w, r.isFull, r.length(), r.free(),
+		r.written, r.pending, len(r.reserved), r.lent, r.inFlight, r.ops, r.maxSize, r.err)
+}
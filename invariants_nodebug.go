@@ -0,0 +1,11 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build !ringbuffer_debug
+
+package ringbuffer
+
+// debugInvariants makes every operation check the invariants of the ring buffer.
+// Build with the ringbuffer_debug tag to enable it.
+const debugInvariants = false
@@ -0,0 +1,57 @@
+package ringbuffer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer_CheckInvariants(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abc"))
+	rb.Read(make([]byte, 2))
+	if err := rb.checkInvariants(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := New(0).checkInvariants(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		corrupt func(rb *RingBuffer)
+		msg     string
+	}{
+		{func(rb *RingBuffer) { rb.w = 8 }, "write position out of range"},
+		{func(rb *RingBuffer) { rb.r = -1 }, "read position out of range"},
+		{func(rb *RingBuffer) { rb.isFull = true }, "full with different read and write positions"},
+		{func(rb *RingBuffer) { rb.size = 16 }, "size differs from the length of buf"},
+		{func(rb *RingBuffer) { rb.inFlight = 1 }, "operations in flight do not add up"},
+	} {
+		rb := New(8)
+		rb.Write([]byte("abc"))
+		tc.corrupt(rb)
+		err := rb.checkInvariants()
+		if err == nil || !strings.Contains(err.Error(), tc.msg) {
+			t.Fatalf("expected %q, got %v", tc.msg, err)
+		}
+		if !strings.Contains(err.Error(), "written=3") {
+			t.Fatalf("expected a state dump, got %v", err)
+		}
+	}
+}
+
+func TestRingBuffer_DebugInvariants(t *testing.T) {
+	if !debugInvariants {
+		t.Skip("requires the ringbuffer_debug build tag")
+	}
+	rb := New(8)
+	rb.w = 9
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic")
+		}
+		// The ring buffer is unlocked before panicking.
+		rb.mu.Lock()
+		rb.mu.Unlock()
+	}()
+	rb.Write([]byte("a"))
+}
@@ -0,0 +1,145 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+//go:build go1.23
+
+package ringbuffer
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"iter"
+	"time"
+)
+
+// Chunks returns an iterator over the buffered data.
+// Each chunk is a contiguous region of the buffer, so the data is not copied.
+// A chunk is consumed when the loop body for it returns,
+// and is only valid until then.
+// Iteration stops when the buffer is empty; it never waits for more data.
+//
+// The loop body must not read from the ring buffer,
+// and there must be no other readers while iterating.
+// Writers are not blocked while it runs.
+func (r *RingBuffer) Chunks() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		r.yieldChunks(nil, yield)
+	}
+}
+
+// Drained returns an iterator over all data written to the ring buffer,
+// like Chunks, but in blocking mode it waits for more data when the buffer is empty.
+// Iteration stops when the ring buffer is closed and all data has been read,
+// or when ctx is done.
+// If not blocking it stops when the buffer is empty, like Chunks.
+func (r *RingBuffer) Drained(ctx context.Context) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		r.yieldChunks(ctx, yield)
+	}
+}
+
+// yieldChunks yields and consumes contiguous chunks of buffered data.
+// If ctx is not nil and blocking it waits for data until ctx is done.
+func (r *RingBuffer) yieldChunks(ctx context.Context, yield func([]byte) bool) {
+	r.mu.Lock()
+	defer r.unlock()
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	wait := ctx != nil && r.block
+	if wait {
+		defer r.wakeOnDone(ctx, r.writeCond, r.wLabels)()
+	}
+	for {
+		if wait && ctx.Err() != nil {
+			return
+		}
+		a, _ := r.readable()
+		if len(a) == 0 {
+			if r.readErr(true) != nil || !wait {
+				return
+			}
+			r.waitUntil(r.writeCond, time.Time{})
+			continue
+		}
+		n := len(a)
+		if r.enc != nil {
+			a = r.plain(r.r, n, r.consumed())
+		}
+		if !r.yieldPeeked(a, n, yield) {
+			return
+		}
+	}
+}
+
+// Records returns an iterator over the records in the buffer,
+// as written by WriteMsg or a PacketConn.
+// A record is passed without copying when it doesn't wrap around the end of the buffer,
+// and is copied into a reused scratch buffer otherwise.
+// A record is consumed when the loop body for it returns,
+// and is only valid until then.
+// Iteration stops when no complete record is buffered,
+// or at a record that does not match its checksum; it never waits for more data.
+//
+// The loop body must not read from the ring buffer,
+// and there must be no other readers while iterating.
+func (r *RingBuffer) Records() iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		r.mu.Lock()
+		defer r.unlock()
+		r.begin(OpRead)
+		defer r.end(OpRead)
+		var scratch []byte
+		for r.length() >= msgHeader {
+			var hdr [msgHeader]byte
+			r.peekAt(hdr[:], 0)
+			size := int(binary.BigEndian.Uint32(hdr[:]))
+			trailer := r.msgTrailerSize()
+			if size > r.length()-msgHeader-trailer || !r.checkMsg(size) {
+				return
+			}
+			var rec []byte
+			if a, _ := r.readable(); msgHeader+size <= len(a) && r.enc == nil {
+				rec = a[msgHeader : msgHeader+size]
+			} else {
+				if cap(scratch) < size {
+					scratch = make([]byte, size)
+				}
+				rec = scratch[:size]
+				r.peekAt(rec, msgHeader)
+			}
+			if !r.yieldPeeked(rec, msgHeader+size+trailer, yield) {
+				return
+			}
+		}
+	}
+}
+
+// yieldPeeked passes buffered data b to the loop body with the ring buffer unlocked,
+// then consumes n bytes.
+// It returns false if iteration must stop, because the loop body returned false,
+// the ring buffer was closed with an error, or data was read concurrently.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) yieldPeeked(b []byte, n int, yield func([]byte) bool) bool {
+	off := r.consumed()
+	r.lend()
+	r.unlock()
+	relocked := false
+	defer func() {
+		// Relock if the loop body panics, for the deferred unlock of the caller.
+		if !relocked {
+			r.mu.Lock()
+			r.unlend()
+		}
+	}()
+	ok := yield(b)
+	r.mu.Lock()
+	r.unlend()
+	relocked = true
+	if err := r.readErr(true); (err != nil && err != io.EOF) || r.consumed() != off {
+		return false
+	}
+	r.discard(n)
+	return ok
+}
@@ -0,0 +1,97 @@
+//go:build go1.23
+
+package ringbuffer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Chunks(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghijk")) // wraps
+
+	var got []string
+	for chunk := range rb.Chunks() {
+		got = append(got, string(chunk))
+	}
+	if len(got) != 2 || got[0] != "efgh" || got[1] != "ijk" {
+		t.Fatalf("expected [efgh ijk], got %q", got)
+	}
+	if !rb.IsEmpty() {
+		t.Fatalf("expected buffer to be empty, got %d bytes", rb.Length())
+	}
+
+	rb.Write([]byte("abcdef"))
+	for chunk := range rb.Chunks() {
+		if string(chunk) != "abcde" {
+			t.Fatalf("expected abcde, got %q", chunk)
+		}
+		break
+	}
+	if rb.Length() != 1 {
+		t.Fatalf("expected break to leave 1 byte after the first chunk, got %d", rb.Length())
+	}
+}
+
+func TestRingBuffer_Drained(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8).SetBlocking(true)
+	go func() {
+		rb.Write([]byte("hello "))
+		rb.Write([]byte("world"))
+		rb.CloseWriter()
+	}()
+	var got []byte
+	for chunk := range rb.Drained(context.Background()) {
+		got = append(got, chunk...)
+	}
+	if string(got) != "hello world" {
+		t.Fatalf("expected hello world, got %q", got)
+	}
+
+	rb = New(8).SetBlocking(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	for range rb.Drained(ctx) {
+		t.Fatalf("expected no data")
+	}
+}
+
+func TestRingBuffer_Records(t *testing.T) {
+	rb := New(16)
+	c := rb.PacketConn()
+	c.Write([]byte("abcd"))
+	rb.Read(make([]byte, 8))
+	c.Write([]byte("hello")) // wraps
+	c.Write(nil)
+
+	var got []string
+	for rec := range rb.Records() {
+		got = append(got, string(rec))
+	}
+	if len(got) != 2 || got[0] != "hello" || got[1] != "" {
+		t.Fatalf("expected [hello \"\"], got %q", got)
+	}
+	if !rb.IsEmpty() {
+		t.Fatalf("expected buffer to be empty, got %d bytes", rb.Length())
+	}
+}
+
+func TestRingBuffer_RecordsChecksum(t *testing.T) {
+	rb := New(32)
+	rb.SetChecksum(true)
+	rb.WriteMsg([]byte("ghi"))
+	rb.WriteMsg([]byte("jkl"))
+	rb.buf[11+4] ^= 1
+	var recs []string
+	for rec := range rb.Records() {
+		recs = append(recs, string(rec))
+	}
+	if len(recs) != 1 || recs[0] != "ghi" {
+		t.Fatalf("expected Records to stop at the corrupted record, got %q", recs)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// WithName sets the name of the ring buffer.
+//
+// When a name is set, goroutines blocked in Read, Write and the other blocking methods
+// are labeled with the profiler labels "ringbuffer" (the name) and "ringbuffer.op"
+// ("write" while waiting for space, "read" while waiting for data).
+// This makes blocked time attributable in goroutine and block profiles.
+//
+// The methods taking a context, such as WaitForData, WaitForSpace, FlushContext and Drained,
+// add the labels to those of the context, like pprof.Do does,
+// and give the goroutine the labels of the context back when they return.
+// The methods without a context cannot know the previous labels of the goroutine,
+// so they clear its labels when they stop waiting.
+// A caller that labels its goroutines, for example with pprof.Do,
+// should use the methods taking a context or set its labels again.
+//
+// An empty name disables the labels.
+func (r *RingBuffer) WithName(name string) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.name = name
+	r.rLabels, r.wLabels = nil, nil
+	if name != "" {
+		rLabels := pprof.Labels("ringbuffer", name, "ringbuffer.op", "write")
+		wLabels := pprof.Labels("ringbuffer", name, "ringbuffer.op", "read")
+		r.rLabels, r.wLabels = &rLabels, &wLabels
+	}
+	return r
+}
+
+// label sets labels as the profiler labels of the goroutine
+// and returns a function that clears them.
+func label(labels *pprof.LabelSet) (unlabel func()) {
+	pprof.SetGoroutineLabels(pprof.WithLabels(context.Background(), *labels))
+	return func() { pprof.SetGoroutineLabels(context.Background()) }
+}
+
+// Name returns the name of the ring buffer set with WithName.
+func (r *RingBuffer) Name() string {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.name
+}
@@ -0,0 +1,89 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"context"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForProfile waits until the goroutine profile satisfies ok.
+func waitForProfile(ok func(profile string) bool) bool {
+	for i := 0; i < 100; i++ {
+		time.Sleep(time.Millisecond)
+		var buf bytes.Buffer
+		pprof.Lookup("goroutine").WriteTo(&buf, 1)
+		if ok(buf.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRingBuffer_WithName(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(10).SetBlocking(true).WithName("test-buffer")
+	if rb.Name() != "test-buffer" {
+		t.Fatalf("expected test-buffer, got %q", rb.Name())
+	}
+
+	waited := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		pprof.Do(context.Background(), pprof.Labels("caller", "test"), func(ctx context.Context) {
+			rb.WaitForData(ctx, 1)
+			close(waited)
+			// Keep the goroutine around to check its labels.
+			<-done
+		})
+	}()
+
+	if !waitForProfile(func(p string) bool {
+		return strings.Contains(p, `"ringbuffer":"test-buffer"`) &&
+			strings.Contains(p, `"ringbuffer.op":"read"`) && strings.Contains(p, `"caller":"test"`)
+	}) {
+		t.Fatalf("blocked goroutine is not labeled")
+	}
+	rb.Write([]byte("x"))
+	<-waited
+
+	// The labels of the caller are restored.
+	if !waitForProfile(func(p string) bool {
+		return strings.Contains(p, `"caller":"test"`) && !strings.Contains(p, `"ringbuffer":"test-buffer"`)
+	}) {
+		t.Fatalf("labels of the caller are not restored")
+	}
+	done <- struct{}{}
+}
+
+func TestRingBuffer_WithNameRead(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(10).SetBlocking(true).WithName("plain-buffer")
+
+	read := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rb.Read(make([]byte, 1))
+		close(read)
+		<-done
+	}()
+
+	if !waitForProfile(func(p string) bool {
+		return strings.Contains(p, `"ringbuffer":"plain-buffer"`) && strings.Contains(p, `"ringbuffer.op":"read"`)
+	}) {
+		t.Fatalf("goroutine blocked in Read is not labeled")
+	}
+	rb.Write([]byte("x"))
+	<-read
+
+	if !waitForProfile(func(p string) bool {
+		return !strings.Contains(p, `"ringbuffer":"plain-buffer"`)
+	}) {
+		t.Fatalf("labels are not cleared after Read")
+	}
+	done <- struct{}{}
+}
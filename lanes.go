@@ -0,0 +1,207 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+// ErrInvalidLane is returned by WriteLane when the lane does not exist.
+var ErrInvalidLane = errors.New("invalid lane")
+
+// LaneBuffer is a buffer of records with several priority lanes sharing one capacity,
+// so urgent messages written to a high priority lane overtake bulk data
+// queued in a lower priority lane instead of waiting behind it.
+// Lane 0 has the highest priority.
+// Records are read one at a time, from the highest priority lane holding one,
+// and in write order within a lane.
+//
+// Each lane is a ring buffer of length-prefixed records, as written by WriteMsg,
+// that grows as needed up to the shared capacity.
+// It is safe to concurrently read and write a LaneBuffer.
+type LaneBuffer struct {
+	mu        sync.Mutex
+	lanes     []*RingBuffer // Records of each lane, in priority order.
+	size      int           // Capacity shared by the lanes.
+	used      int           // Bytes buffered in all lanes, including the length prefixes.
+	err       error
+	block     bool
+	readCond  *sync.Cond // Signaled when records have been read.
+	writeCond *sync.Cond // Signaled when records have been written.
+}
+
+// NewLaneBuffer returns a new LaneBuffer with the given number of lanes
+// sharing a capacity of size bytes.
+// Each record takes 4 bytes of the capacity in addition to its payload.
+func NewLaneBuffer(size, lanes int) *LaneBuffer {
+	initial := 64
+	if initial > size {
+		initial = size
+	}
+	b := &LaneBuffer{lanes: make([]*RingBuffer, lanes), size: size}
+	for i := range b.lanes {
+		b.lanes[i] = New(initial).SetAutoGrow(size)
+	}
+	return b
+}
+
+// SetBlocking sets the blocking mode of the buffer.
+// If block is true, reads wait for a record and writes wait for free space.
+// If block is false, they return ErrIsEmpty or ErrIsFull immediately.
+// By default, the buffer is not blocking.
+// This setting should be called before any read or write.
+func (b *LaneBuffer) SetBlocking(block bool) *LaneBuffer {
+	b.block = block
+	if block {
+		b.readCond = sync.NewCond(&b.mu)
+		b.writeCond = sync.NewCond(&b.mu)
+	}
+	return b
+}
+
+// WriteLane writes p as a single record to the given lane.
+// The record is written completely or not at all:
+// in blocking mode WriteLane waits until there is enough free space,
+// and otherwise it returns ErrIsFull.
+// A record that can never fit is rejected with ErrTooMuchDataToWrite.
+func (b *LaneBuffer) WriteLane(lane int, p []byte) error {
+	if lane < 0 || lane >= len(b.lanes) {
+		return ErrInvalidLane
+	}
+	need := msgHeader + len(p)
+	if need > b.size {
+		return ErrTooMuchDataToWrite
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		if err := b.writeErr(); err != nil {
+			return err
+		}
+		if b.size-b.used >= need {
+			break
+		}
+		if !b.block {
+			return ErrIsFull
+		}
+		b.readCond.Wait()
+	}
+	if err := b.lanes[lane].WriteMsg(p); err != nil {
+		return err
+	}
+	b.used += need
+	if b.block {
+		b.writeCond.Broadcast()
+	}
+	return nil
+}
+
+// ReadLane reads the next record into p and returns its size and lane,
+// taking it from the highest priority lane that holds a record.
+// When the buffer is empty, it waits for a record in blocking mode,
+// and returns ErrIsEmpty otherwise.
+// If the record is larger than p, io.ErrShortBuffer and the size of the record are returned
+// and the record is left in the buffer, so it can be read with a larger p.
+func (b *LaneBuffer) ReadLane(p []byte) (n, lane int, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for {
+		if err := b.readErr(); err != nil {
+			return 0, 0, err
+		}
+		if b.used > 0 {
+			break
+		}
+		if !b.block {
+			return 0, 0, ErrIsEmpty
+		}
+		b.writeCond.Wait()
+	}
+	for lane = range b.lanes {
+		if b.lanes[lane].IsEmpty() {
+			continue
+		}
+		n, err = b.lanes[lane].ReadMsg(p)
+		if err == nil {
+			b.used -= msgHeader + n
+			if b.block {
+				b.readCond.Broadcast()
+			}
+		}
+		return n, lane, err
+	}
+	return 0, 0, ErrIsEmpty
+}
+
+// Read reads the next record into p like ReadLane, so a LaneBuffer can be used as an io.Reader
+// that returns one record per call.
+func (b *LaneBuffer) Read(p []byte) (n int, err error) {
+	n, _, err = b.ReadLane(p)
+	return n, err
+}
+
+// Length returns the number of bytes buffered in all lanes, including the length prefixes.
+func (b *LaneBuffer) Length() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.used
+}
+
+// Capacity returns the capacity shared by the lanes.
+func (b *LaneBuffer) Capacity() int {
+	return b.size
+}
+
+// Lanes returns the number of lanes.
+func (b *LaneBuffer) Lanes() int {
+	return len(b.lanes)
+}
+
+// readErr returns the error a read should fail with, if any.
+// Must be called when locked.
+func (b *LaneBuffer) readErr() error {
+	if b.err == io.EOF && b.used > 0 {
+		return nil
+	}
+	return b.err
+}
+
+// writeErr returns the error a write should fail with, if any.
+// Must be called when locked.
+func (b *LaneBuffer) writeErr() error {
+	if b.err == io.EOF {
+		return ErrWriteOnClosed
+	}
+	return b.err
+}
+
+// CloseWithError closes the buffer with err.
+// Reads and writes will return err, or, if err is nil,
+// reads will return the remaining records and io.EOF.
+//
+// CloseWithError never overwrites the previous error if it exists.
+func (b *LaneBuffer) CloseWithError(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil && b.err != io.EOF {
+		return
+	}
+	b.err = err
+	if b.block {
+		b.readCond.Broadcast()
+		b.writeCond.Broadcast()
+	}
+}
+
+// CloseWriter closes the writer.
+// Reads will return any remaining records and io.EOF.
+func (b *LaneBuffer) CloseWriter() {
+	b.CloseWithError(nil)
+}
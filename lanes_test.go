@@ -0,0 +1,88 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLaneBuffer(t *testing.T) {
+	b := NewLaneBuffer(64, 2)
+	b.WriteLane(1, []byte("bulk1"))
+	b.WriteLane(1, []byte("bulk2"))
+	b.WriteLane(0, []byte("ctrl"))
+	if b.Length() != 26 {
+		t.Fatalf("expected length 26, got %d", b.Length())
+	}
+	if err := b.WriteLane(2, nil); err != ErrInvalidLane {
+		t.Fatalf("expected ErrInvalidLane, got %v", err)
+	}
+	if err := b.WriteLane(1, make([]byte, 64)); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+	if err := b.WriteLane(1, make([]byte, 40)); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+
+	p := make([]byte, 8)
+	for _, want := range []struct {
+		s    string
+		lane int
+	}{{"ctrl", 0}, {"bulk1", 1}, {"bulk2", 1}} {
+		n, lane, err := b.ReadLane(p)
+		if err != nil || string(p[:n]) != want.s || lane != want.lane {
+			t.Fatalf("expected %s from lane %d, got %q from lane %d, %v", want.s, want.lane, p[:n], lane, err)
+		}
+	}
+	if _, err := b.Read(p); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+
+	b.WriteLane(0, []byte("too long"))
+	if n, err := b.Read(p[:2]); err != io.ErrShortBuffer || n != 8 {
+		t.Fatalf("expected io.ErrShortBuffer and 8, got %d, %v", n, err)
+	}
+	b.CloseWriter()
+	if n, err := b.Read(p); err != nil || string(p[:n]) != "too long" {
+		t.Fatalf("expected too long, got %q, %v", p[:n], err)
+	}
+	if _, err := b.Read(p); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+	if err := b.WriteLane(0, nil); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+}
+
+func TestLaneBufferBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	b := NewLaneBuffer(16, 2).SetBlocking(true)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.WriteLane(1, make([]byte, 12))
+	}()
+	p := make([]byte, 16)
+	if n, lane, err := b.ReadLane(p); err != nil || n != 12 || lane != 1 {
+		t.Fatalf("expected 12 bytes from lane 1, got %d from lane %d, %v", n, lane, err)
+	}
+
+	b.WriteLane(1, make([]byte, 12))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.Read(p)
+	}()
+	if err := b.WriteLane(0, []byte("ctrl")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n, lane, _ := b.ReadLane(p); string(p[:n]) != "ctrl" || lane != 0 {
+		t.Fatalf("expected ctrl from lane 0, got %q from lane %d", p[:n], lane)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.CloseWithError(io.ErrClosedPipe)
+	}()
+	if _, err := b.Read(p); err != io.ErrClosedPipe {
+		t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"io"
+	"math"
+)
+
+// SetLimit sets the total number of bytes that can be written to the ring buffer.
+// Once n bytes have been written since the ring buffer was created or Reset,
+// the writer is closed as if CloseWriter was called:
+// readers get the remaining data followed by io.EOF,
+// and writes beyond the limit fail with ErrWriteOnClosed.
+// ReadFrom stops reading from its source when the limit is reached
+// and returns no error.
+// A limit of 0 or less disables the limit (default).
+func (r *RingBuffer) SetLimit(n int64) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.limit = n
+	r.checkLimit()
+	return r
+}
+
+// remaining returns the number of bytes that can be written before the limit is reached.
+// Must be called when locked.
+func (r *RingBuffer) remaining() int64 {
+	if r.limit <= 0 {
+		return math.MaxInt64
+	}
+	if r.written >= r.limit {
+		return 0
+	}
+	return r.limit - r.written
+}
+
+// checkLimit closes the writer if the limit has been reached.
+// Must be called when locked.
+func (r *RingBuffer) checkLimit() {
+	if r.limit > 0 && r.written >= r.limit {
+		r.setErr(io.EOF, true)
+	}
+}
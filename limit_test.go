@@ -0,0 +1,53 @@
+package ringbuffer
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_SetLimit(t *testing.T) {
+	rb := New(100).SetLimit(8)
+	if n, err := rb.Write([]byte("hello")); err != nil || n != 5 {
+		t.Fatalf("expected 5, nil; got %d, %v", n, err)
+	}
+	if n, err := rb.Write([]byte("world")); err != ErrWriteOnClosed || n != 3 {
+		t.Fatalf("expected 3, ErrWriteOnClosed; got %d, %v", n, err)
+	}
+	if err := rb.WriteByte('x'); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+	got, err := io.ReadAll(rb)
+	if err != nil || string(got) != "hellowor" {
+		t.Fatalf("expected hellowor, nil; got %q, %v", got, err)
+	}
+	if reason, _ := rb.CloseState(); reason != ClosedByWriter {
+		t.Fatalf("expected ClosedByWriter, got %v", reason)
+	}
+
+	// The limit is kept after Reset.
+	rb.Reset()
+	if n, err := rb.Fill('a', 10); err != ErrWriteOnClosed || n != 8 {
+		t.Fatalf("expected 8, ErrWriteOnClosed; got %d, %v", n, err)
+	}
+}
+
+func TestRingBuffer_SetLimitReadFrom(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true).SetLimit(10)
+	src := strings.NewReader("0123456789abcdef")
+	go func() {
+		n, err := rb.ReadFrom(src)
+		if err != nil || n != 10 {
+			t.Errorf("expected 10, nil; got %d, %v", n, err)
+		}
+	}()
+	got, err := io.ReadAll(rb)
+	if err != nil || string(got) != "0123456789" {
+		t.Fatalf("expected 0123456789, nil; got %q, %v", got, err)
+	}
+	if src.Len() != 6 {
+		t.Fatalf("expected 6 bytes left in the source, got %d", src.Len())
+	}
+}
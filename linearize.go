@@ -0,0 +1,56 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// Linearize moves the buffered data in place so it no longer wraps around
+// the end of the backing array, if it does.
+// Afterwards the readable data is a single contiguous region,
+// so the second of the ReadDescriptors is empty until the data wraps again.
+// This lets parsers that need contiguous frames avoid copying them out.
+//
+// Linearize does not allocate. Like SwapBuffer it waits for ReadFrom and WriteTo calls
+// that are copying directly to or from the backing array,
+// and descriptors returned before must not be used afterwards.
+func (r *RingBuffer) Linearize() {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.buf == nil {
+		return
+	}
+	r.waitUnlent()
+
+	start, _, total := r.retained()
+	if start+total <= r.size {
+		return
+	}
+
+	r.moveToStart(start)
+}
+
+// moveToStart rotates the buffer in place so the byte at start
+// moves to the beginning of the backing array.
+// Must be called when locked, with no unlocked reads or writes using the buffer.
+func (r *RingBuffer) moveToStart(start int) {
+	rotate(r.buf, start)
+	r.r = (r.r - start + r.size) % r.size
+	r.w = (r.w - start + r.size) % r.size
+	r.sealR = 0
+	r.unreadable = 0
+	r.storeHeader()
+}
+
+// rotate rotates b left by k bytes in place.
+func rotate(b []byte, k int) {
+	reverse(b[:k])
+	reverse(b[k:])
+	reverse(b)
+}
+
+// reverse reverses b in place.
+func reverse(b []byte) {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+}
@@ -0,0 +1,51 @@
+package ringbuffer
+
+import "testing"
+
+func TestRingBuffer_Linearize(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 5))
+	rb.Write([]byte("ghijk"))
+	if d := rb.ReadDescriptors(); d[1].Len == 0 {
+		t.Fatalf("expected data to wrap, got %+v", d)
+	}
+
+	rb.Linearize()
+	d := rb.ReadDescriptors()
+	if d[0].Len != 6 || d[1].Len != 0 {
+		t.Fatalf("expected a single region of 6 bytes, got %+v", d)
+	}
+	if got := string(rb.Bytes(nil)); got != "fghijk" {
+		t.Fatalf("expected fghijk, got %q", got)
+	}
+	rb.Write([]byte("lm"))
+	if !rb.IsFull() {
+		t.Fatalf("expected buffer to be full")
+	}
+	if got := string(rb.Bytes(nil)); got != "fghijklm" {
+		t.Fatalf("expected fghijklm, got %q", got)
+	}
+
+	// Full and wrapped.
+	rb.Read(make([]byte, 3))
+	rb.Write([]byte("nop"))
+	rb.Linearize()
+	if d := rb.ReadDescriptors(); d[0].Len != 8 {
+		t.Fatalf("expected a single region of 8 bytes, got %+v", d)
+	}
+	if got := string(rb.Bytes(nil)); got != "ijklmnop" {
+		t.Fatalf("expected ijklmnop, got %q", got)
+	}
+}
+
+func TestRingBuffer_LinearizeNotWrapped(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 2))
+	before := rb.ReadDescriptors()
+	rb.Linearize()
+	if after := rb.ReadDescriptors(); after != before {
+		t.Fatalf("expected data not to move, got %+v, want %+v", after, before)
+	}
+}
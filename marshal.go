@@ -0,0 +1,227 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrInvalidEncoding is returned by UnmarshalBinary when the data
+// was not produced by MarshalBinary.
+var ErrInvalidEncoding = errors.New("invalid ring buffer encoding")
+
+// encodingVersion is the version of the format written by MarshalBinary.
+const encodingVersion = 1
+
+// maxDecodedSize is the largest buffer UnmarshalBinary allocates by default,
+// so a small corrupted or malicious encoding cannot exhaust memory.
+const maxDecodedSize = 1 << 30
+
+// Flags of the modes encoded by MarshalBinary.
+const (
+	encBlock = 1 << iota
+	encWipe
+	encOverwrite
+	encAtomicWrites
+	encClosed
+	encChecksum
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// It encodes the size, modes, timeouts, offsets and unread data of the ring buffer,
+// and whether its writer is closed, so it can be restored with UnmarshalBinary.
+// As with Clone, hashes, the accountant, callbacks, statistics
+// and data written out of order are not encoded.
+// ErrEncrypted is returned if the ring buffer is encrypted, see SetEncryptionKey,
+// so the data is not persisted in cleartext.
+func (r *RingBuffer) MarshalBinary() ([]byte, error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.enc != nil {
+		return nil, ErrEncrypted
+	}
+	var flags byte
+	if r.block {
+		flags |= encBlock
+	}
+	if r.wipe {
+		flags |= encWipe
+	}
+	if r.overwrite {
+		flags |= encOverwrite
+	}
+	if r.atomicWrites {
+		flags |= encAtomicWrites
+	}
+	if r.err == io.EOF || r.sealed {
+		flags |= encClosed
+	}
+	if r.checksum {
+		flags |= encChecksum
+	}
+
+	length := r.length()
+	b := make([]byte, 0, 2+8*binary.MaxVarintLen64+len(r.name)+length)
+	b = append(b, encodingVersion, flags)
+	b = binary.AppendUvarint(b, uint64(r.size))
+	b = binary.AppendUvarint(b, uint64(r.maxSize))
+	b = binary.AppendVarint(b, int64(r.rTimeout))
+	b = binary.AppendVarint(b, int64(r.wTimeout))
+	b = binary.AppendVarint(b, r.limit)
+	b = binary.AppendVarint(b, r.written)
+	b = binary.AppendUvarint(b, uint64(len(r.name)))
+	b = append(b, r.name...)
+	b = binary.AppendUvarint(b, uint64(length))
+	b = b[:len(b)+length]
+	r.peekAt(b[len(b)-length:], 0)
+	return b, nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// It restores the state encoded by MarshalBinary, replacing the buffer and any data in it.
+// A zero RingBuffer can be used to restore into.
+// ErrInFlight is returned if operations are in flight,
+// ErrMapped if the ring buffer was created by NewMmap,
+// and ErrInvalidEncoding if data is not a valid encoding;
+// the ring buffer is left untouched in all cases.
+//
+// Since the size of the buffer comes from data, sizes above 1GB are rejected
+// with ErrTooMuchDataToWrite, unless the ring buffer already has that capacity
+// or may grow to it, see SetAutoGrow.
+func (r *RingBuffer) UnmarshalBinary(data []byte) error {
+	d := decoder{b: data}
+	if d.byte() != encodingVersion {
+		return ErrInvalidEncoding
+	}
+	flags := d.byte()
+	size := d.uvarint()
+	maxSize := d.uvarint()
+	rTimeout := d.varint()
+	wTimeout := d.varint()
+	limit := d.varint()
+	written := d.varint()
+	name := d.bytes()
+	unread := d.bytes()
+	if d.err != nil || len(d.b) != 0 || size == 0 || size > math.MaxInt || maxSize > math.MaxInt ||
+		uint64(len(unread)) > size || written < int64(len(unread)) {
+		return ErrInvalidEncoding
+	}
+
+	r.mu.Lock()
+	defer r.unlock()
+	if r.mapped != nil {
+		return ErrMapped
+	}
+	if r.inFlight > 0 || r.lent > 0 {
+		return ErrInFlight
+	}
+	if size > maxDecodedSize && size > uint64(r.size) && size > uint64(r.maxSize) {
+		return ErrTooMuchDataToWrite
+	}
+	if r.wipe {
+		zero(r.buf)
+	}
+	r.freed(len(r.buf))
+	r.buf = make([]byte, size)
+	r.allocated(len(r.buf))
+	r.size = int(size)
+	r.resized()
+	n := copy(r.buf, unread)
+	r.r = 0
+	r.w = n % r.size
+	r.isFull = n == r.size
+	r.unreadable = 0
+	r.rebase()
+	r.written = written
+	if r.ages != nil {
+		r.ages.stamps = r.ages.stamps[:0]
+		r.ages.stamp(written, time.Now())
+	}
+	r.cryptBuf(0, n, written-int64(n))
+	r.pending = nil
+	r.sealed = false
+	r.err = nil
+	if flags&encClosed != 0 {
+		r.err = io.EOF
+	}
+	r.block = flags&encBlock != 0
+	if r.block && r.readCond == nil {
+		r.readCond = sync.NewCond(&r.mu)
+		r.writeCond = sync.NewCond(&r.mu)
+	}
+	r.wipe = flags&encWipe != 0
+	r.overwrite = flags&encOverwrite != 0
+	r.atomicWrites = flags&encAtomicWrites != 0
+	r.checksum = flags&encChecksum != 0
+	r.maxSize = int(maxSize)
+	r.rTimeout = time.Duration(rTimeout)
+	r.wTimeout = time.Duration(wTimeout)
+	r.limit = limit
+	r.name = string(name)
+	r.unread = time.Time{}
+	if n > 0 {
+		r.unread = time.Now()
+	}
+	return nil
+}
+
+// decoder reads the fields encoded by MarshalBinary.
+// After the first error, all reads return zero values.
+type decoder struct {
+	b   []byte
+	err error
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil || len(d.b) == 0 {
+		d.err = ErrInvalidEncoding
+		return 0
+	}
+	c := d.b[0]
+	d.b = d.b[1:]
+	return c
+}
+
+func (d *decoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = ErrInvalidEncoding
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.b)
+	if n <= 0 {
+		d.err = ErrInvalidEncoding
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.uvarint()
+	if d.err != nil || n > uint64(len(d.b)) {
+		d.err = ErrInvalidEncoding
+		return nil
+	}
+	b := d.b[:n]
+	d.b = d.b[n:]
+	return b
+}
@@ -0,0 +1,78 @@
+package ringbuffer
+
+import (
+	"encoding"
+	"encoding/binary"
+	"io"
+	"testing"
+	"time"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*RingBuffer)(nil)
+	_ encoding.BinaryUnmarshaler = (*RingBuffer)(nil)
+)
+
+func TestRingBuffer_MarshalBinary(t *testing.T) {
+	rb := New(8).SetBlocking(true).WithTimeout(time.Second).SetOverwrite(true).WithName("log")
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghij"))
+	rb.CloseWriter()
+
+	data, err := rb.MarshalBinary()
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	var restored RingBuffer
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if restored.Capacity() != 8 || !restored.block || restored.wTimeout != time.Second ||
+		!restored.overwrite || restored.Name() != "log" {
+		t.Fatalf("expected the size and modes to be restored, got %+v", &restored)
+	}
+	if restored.ReadOffset() != 4 || restored.WriteOffset() != 10 {
+		t.Fatalf("expected offsets 4 and 10, got %d and %d", restored.ReadOffset(), restored.WriteOffset())
+	}
+	if b, err := io.ReadAll(&restored); err != nil || string(b) != "efghij" {
+		t.Fatalf("expected efghij, got %q, %v", b, err)
+	}
+}
+
+func TestRingBuffer_UnmarshalBinaryInvalid(t *testing.T) {
+	rb := New(4)
+	rb.Write([]byte("ab"))
+	data, _ := rb.MarshalBinary()
+	for i := 0; i < len(data); i++ {
+		if err := New(1).UnmarshalBinary(data[:i]); err != ErrInvalidEncoding {
+			t.Fatalf("expected ErrInvalidEncoding for %d bytes, got %v", i, err)
+		}
+	}
+	if err := New(1).UnmarshalBinary(append(data, 0)); err != ErrInvalidEncoding {
+		t.Fatalf("expected ErrInvalidEncoding, got %v", err)
+	}
+
+	dst := New(4)
+	dst.Write([]byte("xyz"))
+	if err := dst.UnmarshalBinary(data); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	buf := make([]byte, 4)
+	if n, err := dst.Read(buf); err != nil || string(buf[:n]) != "ab" {
+		t.Fatalf("expected ab, got %q, %v", buf[:n], err)
+	}
+}
+
+func TestRingBuffer_UnmarshalBinaryHuge(t *testing.T) {
+	data := []byte{encodingVersion, 0}
+	data = binary.AppendUvarint(data, 1<<62)
+	data = append(data, 0, 0, 0, 0, 0, 0, 0)
+	var rb RingBuffer
+	if err := rb.UnmarshalBinary(data); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+	if rb.Capacity() != 0 {
+		t.Fatalf("expected the ring buffer to be left untouched, got capacity %d", rb.Capacity())
+	}
+}
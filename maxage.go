@@ -0,0 +1,103 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "time"
+
+// ageState holds the maximum age set with SetMaxAge and the write times of buffered data.
+type ageState struct {
+	maxAge time.Duration
+	stamps []ageStamp // Write times of the buffered data, oldest first.
+}
+
+// ageStamp records that the data up to the absolute offset end,
+// after that of the previous stamp, was written at t or later.
+type ageStamp struct {
+	end int64
+	t   time.Time
+}
+
+// SetMaxAge sets the maximum age of buffered data.
+// Data that has been buffered for longer than d is dropped instead of read,
+// and passed to the OnExpire callback, if set.
+// Expired data is dropped when data is about to be read or peeked,
+// so it still takes space in the buffer until then.
+// Write times are tracked with a granularity of d/16, so data may expire up to d/16 early.
+// Data that is already buffered counts as written now.
+// A d of 0 or less disables expiry.
+func (r *RingBuffer) SetMaxAge(d time.Duration) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	if d <= 0 {
+		r.ages = nil
+		return r
+	}
+	if r.ages == nil {
+		r.ages = &ageState{}
+		if r.length() > 0 {
+			r.ages.stamps = append(r.ages.stamps, ageStamp{end: r.written, t: time.Now()})
+		}
+	}
+	r.ages.maxAge = d
+	return r
+}
+
+// OnExpire sets a callback that is called with data that is dropped
+// because it is older than the maximum age of SetMaxAge, for example to count or log it.
+// The callback may be called twice at once, when the data wraps around
+// the end of the buffer, and expired is only valid during the call.
+// It is called with the ring buffer locked, so it must not call back into the ring buffer.
+// A nil callback removes it.
+func (r *RingBuffer) OnExpire(fn func(expired []byte)) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.onExpire = fn
+	return r
+}
+
+// stamp records that the data up to the absolute offset end was written at t.
+func (a *ageState) stamp(end int64, t time.Time) {
+	if n := len(a.stamps); n > 0 && t.Sub(a.stamps[n-1].t) < a.maxAge/16 {
+		a.stamps[n-1].end = end
+		return
+	}
+	a.stamps = append(a.stamps, ageStamp{end: end, t: t})
+}
+
+// expire drops the buffered data that is older than the maximum age.
+// Must be called when locked.
+func (r *RingBuffer) expire() {
+	a := r.ages
+	if a == nil || len(a.stamps) == 0 {
+		return
+	}
+	cutoff := time.Now().Add(-a.maxAge)
+	consumed := r.consumed()
+	end := consumed
+	i := 0
+	for ; i < len(a.stamps); i++ {
+		s := a.stamps[i]
+		if s.end > consumed && !s.t.Before(cutoff) {
+			break
+		}
+		if s.end > end {
+			end = s.end
+		}
+	}
+	a.stamps = append(a.stamps[:0], a.stamps[i:]...)
+	n := int(end - consumed)
+	if n <= 0 {
+		return
+	}
+	r.drop(n, r.onExpire)
+	r.ctr.expired += int64(n)
+	r.storeHeader()
+	r.checkWatermarks()
+	notify(r.writableC)
+	r.publish()
+	if r.block {
+		r.readCond.Broadcast()
+	}
+}
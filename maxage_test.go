@@ -0,0 +1,59 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_SetMaxAge(t *testing.T) {
+	rb := New(16).SetMaxAge(50 * time.Millisecond)
+	var expired []byte
+	rb.OnExpire(func(p []byte) { expired = append(expired, p...) })
+
+	rb.Write([]byte("old"))
+	time.Sleep(100 * time.Millisecond)
+	rb.Write([]byte("new"))
+	buf := make([]byte, 8)
+	n, err := rb.Read(buf)
+	if err != nil || string(buf[:n]) != "new" {
+		t.Fatalf("expected new, got %q, %v", buf[:n], err)
+	}
+	if string(expired) != "old" {
+		t.Fatalf("expected old to expire, got %q", expired)
+	}
+	if s := rb.Stats(); s.Expired != 3 || s.BytesRead != 3 {
+		t.Fatalf("expected 3 expired and 3 read bytes, got %d and %d", s.Expired, s.BytesRead)
+	}
+
+	rb.Write([]byte("stale"))
+	time.Sleep(100 * time.Millisecond)
+	if _, err := rb.Read(buf); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	if rb.ReadOffset() != rb.WriteOffset() {
+		t.Fatalf("expected expired data to be consumed, got offsets %d and %d", rb.ReadOffset(), rb.WriteOffset())
+	}
+
+	rb.SetMaxAge(0)
+	rb.Write([]byte("kept"))
+	time.Sleep(100 * time.Millisecond)
+	if n, _ := rb.Read(buf); string(buf[:n]) != "kept" {
+		t.Fatalf("expected kept, got %q", buf[:n])
+	}
+}
+
+func TestRingBuffer_SetMaxAgeBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true).SetMaxAge(20 * time.Millisecond)
+	rb.Write([]byte("abcd"))
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.Write([]byte("ef"))
+	}()
+	buf := make([]byte, 4)
+	n, err := rb.Read(buf)
+	if err != nil || string(buf[:n]) != "ef" {
+		t.Fatalf("expected ef, got %q, %v", buf[:n], err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+)
+
+var (
+	// ErrMapped is returned when trying to replace the memory-mapped buffer
+	// of a ring buffer created by NewMmap.
+	ErrMapped = errors.New("ringbuffer is memory-mapped")
+	// ErrMmapSize is returned by NewMmap when the file holds a ring buffer of another size.
+	ErrMmapSize = errors.New("mapped file has a different size")
+)
+
+// mmapMagic identifies a file created by NewMmap.
+const mmapMagic = "RBMM"
+
+// mmapHeader is the size of the header stored before the data in a mapped file.
+// It holds the magic, the data size, the read and write positions,
+// whether the buffer is full, whether records are checksummed
+// and the total bytes written, as little-endian integers.
+const mmapHeader = 64
+
+// NewMmap returns a new RingBuffer whose buffer is a memory-mapped file at path,
+// so buffered data survives a crash of the process and does not live on the heap.
+// The file is created if it doesn't exist, and is size bytes plus a small header.
+// If it was created by NewMmap before, the buffered data and offsets are recovered
+// from the header, and ErrMmapSize is returned if its size is not size.
+//
+// The positions in the header are updated after every read and write,
+// so a write is recovered once it has returned.
+// The buffer cannot be replaced, so SwapBuffer, Resize and Compact return ErrMapped
+// and auto grow has no effect.
+// Release unmaps the file.
+func NewMmap(path string, size int) (*RingBuffer, error) {
+	if size <= 0 {
+		return nil, ErrBufferTooSmall
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	exists := fi.Size() > 0
+	if exists && fi.Size() != int64(mmapHeader+size) {
+		return nil, ErrMmapSize
+	}
+	if !exists {
+		if err := f.Truncate(int64(mmapHeader + size)); err != nil {
+			return nil, err
+		}
+	}
+	m, err := mmapFile(f, mmapHeader+size)
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewBuffer(m[mmapHeader:])
+	r.mapped = m
+	if exists {
+		if err := r.loadHeader(); err != nil {
+			munmapFile(m)
+			return nil, err
+		}
+	} else {
+		r.storeHeader()
+	}
+	return r, nil
+}
+
+// storeHeader stores the positions of a mapped ring buffer in its header.
+// Must be called when locked.
+func (r *RingBuffer) storeHeader() {
+	if r.mapped == nil {
+		return
+	}
+	var h [mmapHeader]byte
+	copy(h[:], mmapMagic)
+	binary.LittleEndian.PutUint64(h[8:], uint64(r.size))
+	binary.LittleEndian.PutUint64(h[16:], uint64(r.r))
+	binary.LittleEndian.PutUint64(h[24:], uint64(r.w))
+	if r.isFull {
+		h[32] = 1
+	}
+	if r.checksum {
+		h[33] = 1
+	}
+	binary.LittleEndian.PutUint64(h[40:], uint64(r.written))
+	copy(r.mapped, h[:])
+}
+
+// loadHeader restores the positions of a mapped ring buffer from its header.
+// Must be called when locked or before the ring buffer is shared.
+func (r *RingBuffer) loadHeader() error {
+	h := r.mapped[:mmapHeader]
+	if string(h[:4]) != mmapMagic {
+		return ErrInvalidEncoding
+	}
+	if binary.LittleEndian.Uint64(h[8:]) != uint64(r.size) {
+		return ErrMmapSize
+	}
+	rp := binary.LittleEndian.Uint64(h[16:])
+	wp := binary.LittleEndian.Uint64(h[24:])
+	written := int64(binary.LittleEndian.Uint64(h[40:]))
+	if rp >= uint64(r.size) || wp >= uint64(r.size) || h[32] > 1 || h[33] > 1 || written < 0 {
+		return ErrInvalidEncoding
+	}
+	r.r = int(rp)
+	r.w = int(wp)
+	r.isFull = h[32] == 1 && r.r == r.w
+	r.checksum = h[33] == 1
+	r.written = written
+	if r.written < int64(r.length()) {
+		return ErrInvalidEncoding
+	}
+	if r.length() > 0 {
+		r.markWrite(0)
+	}
+	return nil
+}
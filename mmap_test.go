@@ -0,0 +1,58 @@
+//go:build unix
+
+package ringbuffer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewMmap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	rb, err := NewMmap(path, 8)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	rb.Write([]byte("abcdef"))
+	buf := make([]byte, 4)
+	rb.Read(buf)
+	rb.Write([]byte("ghij"))
+	if err := rb.Resize(16); err != ErrMapped {
+		t.Fatalf("expected ErrMapped, got %v", err)
+	}
+	rb.Release()
+
+	fi, err := os.Stat(path)
+	if err != nil || fi.Size() != mmapHeader+8 {
+		t.Fatalf("expected a file of %d bytes, got %v, %v", mmapHeader+8, fi, err)
+	}
+
+	// The data and offsets are recovered.
+	rb, err = NewMmap(path, 8)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer rb.Release()
+	if rb.ReadOffset() != 4 || rb.WriteOffset() != 10 {
+		t.Fatalf("expected offsets 4 and 10, got %d and %d", rb.ReadOffset(), rb.WriteOffset())
+	}
+	buf = make([]byte, 8)
+	if n, err := rb.Read(buf); err != nil || string(buf[:n]) != "efghij" {
+		t.Fatalf("expected efghij, got %q, %v", buf[:n], err)
+	}
+
+	if _, err := NewMmap(path, 16); err != ErrMmapSize {
+		t.Fatalf("expected ErrMmapSize, got %v", err)
+	}
+}
+
+func TestNewMmapInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ring")
+	if err := os.WriteFile(path, make([]byte, mmapHeader+8), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewMmap(path, 8); err != ErrInvalidEncoding {
+		t.Fatalf("expected ErrInvalidEncoding, got %v", err)
+	}
+}
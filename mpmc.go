@@ -0,0 +1,119 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "sync/atomic"
+
+// MPMC is a lock-free bounded queue of elements of type T
+// for any number of writer and reader goroutines.
+// Each slot carries a sequence number that tells writers and readers
+// whether it is free or filled for their lap around the buffer,
+// so they claim slots with a single compare-and-swap
+// instead of serializing on a lock, as described by Dmitry Vyukov.
+//
+// MPMC is never blocking: WriteOne returns ErrIsFull when there is no free slot
+// and ReadOne returns ErrIsEmpty when there is no element.
+type MPMC[T any] struct {
+	slots []mpmcSlot[T]
+	mask  uint64
+
+	_   [cacheLine]byte
+	enq atomic.Uint64 // Next position to write.
+	_   [cacheLine - 8]byte
+	deq atomic.Uint64 // Next position to read.
+	_   [cacheLine - 8]byte
+}
+
+type mpmcSlot[T any] struct {
+	seq atomic.Uint64
+	val T
+}
+
+// NewMPMC returns a new multi-producer multi-consumer queue
+// with room for at least size elements.
+// The capacity is rounded up to a power of two.
+func NewMPMC[T any](size int) *MPMC[T] {
+	n := 1
+	for n < size {
+		n <<= 1
+	}
+	q := &MPMC[T]{
+		slots: make([]mpmcSlot[T], n),
+		mask:  uint64(n - 1),
+	}
+	for i := range q.slots {
+		q.slots[i].seq.Store(uint64(i))
+	}
+	return q
+}
+
+// WriteOne adds v to the queue, or returns ErrIsFull if the queue is full.
+// It is safe to call from multiple goroutines.
+func (q *MPMC[T]) WriteOne(v T) error {
+	pos := q.enq.Load()
+	for {
+		slot := &q.slots[pos&q.mask]
+		switch dif := int64(slot.seq.Load() - pos); {
+		case dif == 0:
+			if q.enq.CompareAndSwap(pos, pos+1) {
+				slot.val = v
+				// Publish the element to readers.
+				slot.seq.Store(pos + 1)
+				return nil
+			}
+			pos = q.enq.Load()
+		case dif < 0:
+			// The slot still holds the element of the previous lap.
+			return ErrIsFull
+		default:
+			// Another writer claimed the slot.
+			pos = q.enq.Load()
+		}
+	}
+}
+
+// ReadOne removes and returns the oldest element of the queue,
+// or returns ErrIsEmpty if the queue is empty.
+// It is safe to call from multiple goroutines.
+func (q *MPMC[T]) ReadOne() (v T, err error) {
+	pos := q.deq.Load()
+	for {
+		slot := &q.slots[pos&q.mask]
+		switch dif := int64(slot.seq.Load() - (pos + 1)); {
+		case dif == 0:
+			if q.deq.CompareAndSwap(pos, pos+1) {
+				v = slot.val
+				var zero T
+				slot.val = zero
+				// Release the slot to writers of the next lap.
+				slot.seq.Store(pos + q.mask + 1)
+				return v, nil
+			}
+			pos = q.deq.Load()
+		case dif < 0:
+			// The slot has not been written in this lap.
+			return v, ErrIsEmpty
+		default:
+			// Another reader claimed the slot.
+			pos = q.deq.Load()
+		}
+	}
+}
+
+// Length returns the number of elements in the queue.
+// With concurrent writers and readers it is only an estimate.
+func (q *MPMC[T]) Length() int {
+	deq := q.deq.Load()
+	enq := q.enq.Load()
+	if enq < deq {
+		return 0
+	}
+	return int(enq - deq)
+}
+
+// Capacity returns the number of elements the queue can hold.
+func (q *MPMC[T]) Capacity() int {
+	return len(q.slots)
+}
@@ -0,0 +1,98 @@
+package ringbuffer
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMPMC(t *testing.T) {
+	q := NewMPMC[int](3)
+	if q.Capacity() != 4 {
+		t.Fatalf("expected capacity 4, got %d", q.Capacity())
+	}
+	if _, err := q.ReadOne(); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := q.WriteOne(i); err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	}
+	if err := q.WriteOne(4); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	if q.Length() != 4 {
+		t.Fatalf("expected length 4, got %d", q.Length())
+	}
+	for lap := 0; lap < 3; lap++ {
+		for i := 0; i < 4; i++ {
+			v, err := q.ReadOne()
+			if err != nil || v != lap*4+i {
+				t.Fatalf("expected %d, got %d, %v", lap*4+i, v, err)
+			}
+			q.WriteOne(lap*4 + i + 4)
+		}
+	}
+}
+
+func TestMPMCConcurrent(t *testing.T) {
+	defer timeout(10 * time.Second)()
+	const writers, readers, perWriter = 4, 4, 10000
+	q := NewMPMC[int](64)
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 1; i <= perWriter; i++ {
+				for q.WriteOne(i) != nil {
+					runtime.Gosched()
+				}
+			}
+		}(w)
+	}
+
+	sums := make(chan int, readers)
+	var read sync.WaitGroup
+	var count sync.Mutex
+	remaining := writers * perWriter
+	for r := 0; r < readers; r++ {
+		read.Add(1)
+		go func() {
+			defer read.Done()
+			sum := 0
+			for {
+				count.Lock()
+				if remaining == 0 {
+					count.Unlock()
+					break
+				}
+				count.Unlock()
+				v, err := q.ReadOne()
+				if err != nil {
+					runtime.Gosched()
+					continue
+				}
+				sum += v
+				count.Lock()
+				remaining--
+				count.Unlock()
+			}
+			sums <- sum
+		}()
+	}
+	wg.Wait()
+	read.Wait()
+	close(sums)
+
+	total := 0
+	for s := range sums {
+		total += s
+	}
+	if want := writers * perWriter * (perWriter + 1) / 2; total != want {
+		t.Fatalf("expected sum %d, got %d", want, total)
+	}
+}
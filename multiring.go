@@ -0,0 +1,198 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"sort"
+	"sync/atomic"
+	"unsafe"
+)
+
+// FullPolicy tells a MultiRing what to do when a ring buffer has no room for a write.
+type FullPolicy int
+
+const (
+	// FullBlock waits until all ring buffers have room, if they are blocking,
+	// and returns ErrIsFull otherwise.
+	FullBlock FullPolicy = iota
+	// FullDrop skips the ring buffers without room, or whose writer is closed,
+	// so a slow reader loses data instead of holding back the others.
+	FullDrop
+	// FullError returns ErrIsFull without writing anything.
+	FullError
+)
+
+// MultiRing duplicates each write into several ring buffers, like io.MultiWriter,
+// for example to fan the same stream out to several consumers.
+// Each write is atomic across the ring buffers:
+// p is written completely to all of them, or, with FullDrop,
+// completely to those that have room, and readers never see a write
+// in one ring buffer and not yet in the others.
+type MultiRing struct {
+	rings   []*RingBuffer // Sorted by address, the order in which they are locked.
+	pos     []int         // Index in rings of each ring buffer passed to NewMultiRing.
+	policy  FullPolicy
+	dropped []atomic.Int64 // Indexed like rings.
+}
+
+// NewMultiRing returns a MultiRing writing to the given ring buffers,
+// handling full ring buffers according to policy.
+// The ring buffers can be shared with other MultiRings, in any order,
+// but must be distinct: NewMultiRing panics if a ring buffer is passed twice.
+func NewMultiRing(policy FullPolicy, rings ...*RingBuffer) *MultiRing {
+	// Lock the ring buffers in the same order in all MultiRings,
+	// so MultiRings sharing them do not deadlock.
+	order := make([]int, len(rings))
+	for i := range order {
+		order[i] = i
+	}
+	addr := func(i int) uintptr { return uintptr(unsafe.Pointer(rings[order[i]])) }
+	sort.Slice(order, func(i, j int) bool { return addr(i) < addr(j) })
+	m := &MultiRing{
+		rings:   make([]*RingBuffer, len(rings)),
+		pos:     make([]int, len(rings)),
+		policy:  policy,
+		dropped: make([]atomic.Int64, len(rings)),
+	}
+	for i, j := range order {
+		if i > 0 && rings[j] == m.rings[i-1] {
+			panic("ringbuffer: ring buffer passed twice to NewMultiRing")
+		}
+		m.rings[i] = rings[j]
+		m.pos[j] = i
+	}
+	return m
+}
+
+// Write writes p to all ring buffers, according to the policy for full ring buffers.
+// ErrTooMuchDataToWrite is returned if p does not fit in one of the ring buffers
+// even when it is empty, unless it is in overwrite mode or the policy is FullDrop.
+// In overwrite mode a ring buffer always has room.
+// It returns len(p) if p was written, even if it was dropped for some ring buffers.
+func (m *MultiRing) Write(p []byte) (n int, err error) {
+	for {
+		for _, r := range m.rings {
+			r.mu.Lock()
+		}
+		full, err := m.check(len(p))
+		if err == nil && full != nil && m.policy == FullBlock {
+			// Wait for the first full ring buffer, then check them all again.
+			for _, r := range m.rings {
+				if r != full {
+					r.unlock()
+				}
+			}
+			err = full.waitRoom(len(p))
+			full.unlock()
+			if err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if err == nil && full != nil && m.policy == FullError {
+			err = ErrIsFull
+		}
+		if err == nil {
+			for i, r := range m.rings {
+				if m.policy == FullDrop && !r.hasRoom(len(p)) {
+					m.dropped[i].Add(1)
+					continue
+				}
+				r.write(p)
+				if r.block {
+					r.writeCond.Broadcast()
+				}
+			}
+			n = len(p)
+		}
+		for _, r := range m.rings {
+			r.unlock()
+		}
+		return n, err
+	}
+}
+
+// WriteString writes s to all ring buffers like Write.
+func (m *MultiRing) WriteString(s string) (n int, err error) {
+	return m.Write([]byte(s))
+}
+
+// check returns the first ring buffer without room for n bytes, if any,
+// or the error the write should fail with.
+// Must be called with all ring buffers locked.
+func (m *MultiRing) check(n int) (full *RingBuffer, err error) {
+	for _, r := range m.rings {
+		err := r.writeErr()
+		if err == nil && r.sealed {
+			err = ErrSealed
+		}
+		if err == nil && r.remaining() < int64(n) {
+			err = ErrWriteOnClosed
+		}
+		if err == nil && n > r.size && n > r.maxSize && !r.overwrite {
+			err = ErrTooMuchDataToWrite
+		}
+		if err != nil {
+			if m.policy == FullDrop {
+				continue
+			}
+			return nil, err
+		}
+		r.grow(n)
+		if !r.hasRoom(n) {
+			r.stalls++
+			if full == nil {
+				full = r
+			}
+		}
+	}
+	return full, nil
+}
+
+// Dropped returns the number of writes skipped for the i-th ring buffer
+// passed to NewMultiRing with FullDrop.
+func (m *MultiRing) Dropped(i int) int64 {
+	return m.dropped[m.pos[i]].Load()
+}
+
+// CloseWriter closes the writers of all ring buffers.
+func (m *MultiRing) CloseWriter() {
+	for _, r := range m.rings {
+		r.CloseWriter()
+	}
+}
+
+// hasRoom reports whether n bytes can be written without blocking.
+// Must be called when locked.
+func (r *RingBuffer) hasRoom(n int) bool {
+	return r.writeErr() == nil && !r.sealed && r.remaining() >= int64(n) &&
+		(r.overwrite || r.free() >= n)
+}
+
+// waitRoom waits until n bytes can be written, returning ErrIsFull if not blocking.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) waitRoom(n int) error {
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	for !r.hasRoom(n) {
+		if err := r.writeErr(); err != nil {
+			return err
+		}
+		if r.sealed {
+			return ErrSealed
+		}
+		if r.remaining() < int64(n) {
+			return ErrWriteOnClosed
+		}
+		if !r.block {
+			return ErrIsFull
+		}
+		if err := r.waitReadDeadline(nil); err != nil {
+			return err
+		}
+		r.grow(n)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestMultiRing(t *testing.T) {
+	a, b := New(8), New(4)
+	m := NewMultiRing(FullError, a, b)
+	if _, err := m.WriteString("abc"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := m.WriteString("de"); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	if a.Length() != 3 || b.Length() != 3 {
+		t.Fatalf("expected nothing written on error, got lengths %d and %d", a.Length(), b.Length())
+	}
+	if _, err := m.Write(make([]byte, 5)); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+
+	m = NewMultiRing(FullDrop, a, b)
+	if n, err := m.WriteString("de"); err != nil || n != 2 {
+		t.Fatalf("expected 2 bytes written, got %d, %v", n, err)
+	}
+	if s := string(a.Bytes(nil)); s != "abcde" {
+		t.Fatalf("expected abcde, got %q", s)
+	}
+	if s := string(b.Bytes(nil)); s != "abc" {
+		t.Fatalf("expected abc, got %q", s)
+	}
+	if m.Dropped(0) != 0 || m.Dropped(1) != 1 {
+		t.Fatalf("expected one drop for the second ring, got %d and %d", m.Dropped(0), m.Dropped(1))
+	}
+
+	m.CloseWriter()
+	if _, err := a.Write([]byte("x")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+}
+
+func TestMultiRingBlock(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, b := New(8).SetBlocking(true), New(4).SetBlocking(true)
+	m := NewMultiRing(FullBlock, a, b)
+	m.WriteString("abc")
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.Read(make([]byte, 2))
+	}()
+	if _, err := m.WriteString("de"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if a.Length() != 5 {
+		t.Fatalf("expected the write to wait for space in all rings, got length %d", a.Length())
+	}
+	if s := string(b.Bytes(nil)); s != "cde" {
+		t.Fatalf("expected cde, got %q", s)
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.CloseWithError(io.ErrClosedPipe)
+	}()
+	if _, err := m.WriteString("fg"); err != io.ErrClosedPipe {
+		t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+	}
+}
+
+func TestMultiRingLockOrder(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	a, b := New(1024).SetOverwrite(true), New(1024).SetOverwrite(true)
+	m1, m2 := NewMultiRing(FullDrop, a, b), NewMultiRing(FullDrop, b, a)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 1000; i++ {
+			m1.WriteString("x")
+		}
+		close(done)
+	}()
+	for i := 0; i < 1000; i++ {
+		m2.WriteString("y")
+	}
+	<-done
+
+	// Dropped is indexed in the order the ring buffers were passed.
+	c := New(1)
+	m := NewMultiRing(FullDrop, a, c)
+	m.WriteString("ab")
+	if m.Dropped(0) != 0 || m.Dropped(1) != 1 {
+		t.Fatalf("expected one drop for the second ring, got %d and %d", m.Dropped(0), m.Dropped(1))
+	}
+	m = NewMultiRing(FullDrop, c, a)
+	m.WriteString("ab")
+	if m.Dropped(0) != 1 || m.Dropped(1) != 0 {
+		t.Fatalf("expected one drop for the first ring, got %d and %d", m.Dropped(0), m.Dropped(1))
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic for a duplicate ring buffer")
+		}
+	}()
+	NewMultiRing(FullBlock, a, b, a)
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// ReadableC returns a channel that receives a value when data is written to the ring buffer
+// or it is closed, so readers can select on it together with other channels.
+// Notifications are coalesced: after receiving, read until ErrIsEmpty
+// before waiting on the channel again.
+// If data is buffered when ReadableC is first called, a value is ready immediately.
+// The same channel is returned by every call.
+func (r *RingBuffer) ReadableC() <-chan struct{} {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.readableC == nil {
+		r.readableC = make(chan struct{}, 1)
+		if r.length() > 0 || r.err != nil {
+			notify(r.readableC)
+		}
+	}
+	return r.readableC
+}
+
+// WritableC returns a channel that receives a value when data is read from the ring buffer,
+// freeing space, or when it is reset or closed, so writers can select on it
+// together with other channels.
+// Notifications are coalesced: after receiving, write until ErrIsFull
+// before waiting on the channel again.
+// If there is free space when WritableC is first called, a value is ready immediately.
+// The same channel is returned by every call.
+func (r *RingBuffer) WritableC() <-chan struct{} {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.writableC == nil {
+		r.writableC = make(chan struct{}, 1)
+		if r.free() > 0 || r.err != nil {
+			notify(r.writableC)
+		}
+	}
+	return r.writableC
+}
+
+// notify sends a value on c without blocking, if c is not nil.
+func notify(c chan struct{}) {
+	if c == nil {
+		return
+	}
+	select {
+	case c <- struct{}{}:
+	default:
+	}
+}
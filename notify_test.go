@@ -0,0 +1,67 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_ReadableC(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4)
+	readable := rb.ReadableC()
+	select {
+	case <-readable:
+		t.Fatalf("expected no notification for an empty buffer")
+	default:
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		rb.Write([]byte("ab"))
+		rb.Write([]byte("cd"))
+	}()
+	select {
+	case <-readable:
+	case <-time.After(time.Second):
+		t.Fatalf("expected a notification after a write")
+	}
+	buf := make([]byte, 4)
+	got := 0
+	for got < 4 {
+		n, err := rb.Read(buf)
+		got += n
+		if err == ErrIsEmpty {
+			<-readable
+		}
+	}
+
+	rb.CloseWriter()
+	<-readable
+	if _, err := rb.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestRingBuffer_WritableC(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(2)
+	writable := rb.WritableC()
+	// A value is ready since there is free space.
+	<-writable
+	rb.Write([]byte("ab"))
+	select {
+	case <-writable:
+		t.Fatalf("expected no notification after a write")
+	default:
+	}
+	rb.Read(make([]byte, 1))
+	select {
+	case <-writable:
+	default:
+		t.Fatalf("expected a notification after a read")
+	}
+	if rb.WritableC() != writable {
+		t.Fatalf("expected the same channel")
+	}
+}
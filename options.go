@@ -0,0 +1,76 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"time"
+)
+
+// An Option configures a ring buffer created by NewWithOptions.
+// Options are named with the prefix Opt, so they are not confused
+// with the methods of RingBuffer that configure the same settings.
+type Option func(*options)
+
+// options is the configuration collected from the options of NewWithOptions.
+type options struct {
+	buf       []byte
+	block     bool
+	overwrite bool
+	timeout   time.Duration
+	ctx       context.Context
+}
+
+// OptBlocking sets blocking mode, like SetBlocking.
+func OptBlocking(block bool) Option {
+	return func(o *options) { o.block = block }
+}
+
+// OptOverwrite sets overwrite mode, like SetOverwrite.
+func OptOverwrite(overwrite bool) Option {
+	return func(o *options) { o.overwrite = overwrite }
+}
+
+// OptTimeout sets the read and write timeouts, like the WithTimeout method.
+func OptTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// OptBuffer uses b as the backing array, like NewBuffer.
+// The size passed to NewWithOptions is ignored.
+func OptBuffer(b []byte) Option {
+	return func(o *options) { o.buf = b }
+}
+
+// OptContext closes the ring buffer with the error of ctx when ctx is done,
+// like WithCancel.
+func OptContext(ctx context.Context) Option {
+	return func(o *options) { o.ctx = ctx }
+}
+
+// NewWithOptions returns a new RingBuffer of the given size, configured by opts.
+// Unlike calling the setters after New, the ring buffer is completely configured
+// before it is returned, so it cannot be used before SetBlocking has been called.
+// Options are applied in order; a later option overrides an earlier one.
+func NewWithOptions(size int, opts ...Option) *RingBuffer {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	var r *RingBuffer
+	if o.buf != nil {
+		r = NewBuffer(o.buf)
+	} else {
+		r = New(size)
+	}
+	r.SetBlocking(o.block)
+	r.overwrite = o.overwrite
+	r.rTimeout = o.timeout
+	r.wTimeout = o.timeout
+	if o.ctx != nil {
+		r.WithCancel(o.ctx)
+	}
+	return r
+}
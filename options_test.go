@@ -0,0 +1,34 @@
+package ringbuffer
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewWithOptions(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := NewWithOptions(8)
+	if rb.Capacity() != 8 || rb.block || rb.overwrite {
+		t.Fatalf("expected a non-blocking buffer of 8 bytes, got %d, blocking %v", rb.Capacity(), rb.block)
+	}
+
+	buf := make([]byte, 4)
+	rb = NewWithOptions(8, OptBuffer(buf), OptOverwrite(true))
+	rb.Write([]byte("abcdef"))
+	if rb.Capacity() != 4 || string(buf) != "cdef" {
+		t.Fatalf("expected buf to be used, got capacity %d and %q", rb.Capacity(), buf)
+	}
+
+	rb = NewWithOptions(4, OptBlocking(true), OptTimeout(20*time.Millisecond))
+	if _, err := rb.Read(buf); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rb = NewWithOptions(4, OptBlocking(true), OptContext(ctx))
+	cancel()
+	if _, err := rb.Read(buf); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// SetOverwrite sets whether writes overwrite the oldest unread data when the buffer is full,
+// instead of blocking or returning ErrIsFull or ErrTooMuchDataToWrite.
+// If a single write is larger than the buffer, only its last bytes are kept.
+// Overwritten data is passed to the OnOverwrite callback, if set,
+// and counts as consumed for ReadOffset, but not for the read hash.
+//
+// Overwriting applies to Write, WriteString, WriteByte and their Try variants.
+func (r *RingBuffer) SetOverwrite(overwrite bool) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.overwrite = overwrite
+	return r
+}
+
+// OnOverwrite sets a callback that is called with data that is about to be
+// overwritten in overwrite mode, for example to count or spill it.
+// The callback may be called twice for one write, when the data wraps around
+// the end of the buffer, and evicted is only valid during the call.
+// It is called with the ring buffer locked, so it must not call back into the ring buffer.
+// A nil callback removes it.
+func (r *RingBuffer) OnOverwrite(fn func(evicted []byte)) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.onOverwrite = fn
+	return r
+}
+
+// makeRoom evicts the oldest buffered data so p fits in the free space.
+// If p is larger than the buffer, its beginning is evicted as well and
+// the remainder that fits is returned with the number of bytes dropped from p.
+// Must be called when locked.
+func (r *RingBuffer) makeRoom(p []byte) ([]byte, int) {
+	dropped := 0
+	if len(p) > r.size {
+		r.evict(r.length())
+		dropped = len(p) - r.size
+		if r.onOverwrite != nil {
+			r.onOverwrite(p[:dropped])
+		}
+		r.hashWrite(p[:dropped])
+		r.written += int64(dropped)
+		r.ctr.bytesWritten += int64(dropped)
+		r.ctr.overwritten += int64(dropped)
+		p = p[dropped:]
+	}
+	if need := len(p) - r.free(); need > 0 {
+		r.evict(need)
+	}
+	return p, dropped
+}
+
+// evict discards the next n buffered bytes, passing them to the OnOverwrite callback.
+// Must be called when locked.
+func (r *RingBuffer) evict(n int) {
+	if n <= 0 {
+		return
+	}
+	r.drop(n, r.onOverwrite)
+	r.ctr.overwritten += int64(n)
+}
+
+// drop discards the next n buffered bytes, passing them to fn if it is not nil.
+// Must be called when locked.
+func (r *RingBuffer) drop(n int, fn func([]byte)) {
+	if fn != nil {
+		a, b := r.readable()
+		if r.enc != nil {
+			fn(r.plain(r.r, n, r.consumed()))
+		} else if n <= len(a) {
+			fn(a[:n])
+		} else {
+			fn(a)
+			fn(b[:n-len(a)])
+		}
+	}
+	r.wipeRead(n)
+	r.r = (r.r + n) % r.size
+	r.isFull = false
+	r.unreadable = 0
+}
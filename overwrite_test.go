@@ -0,0 +1,59 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Overwrite(t *testing.T) {
+	var evicted []byte
+	rb := New(8).SetOverwrite(true).OnOverwrite(func(b []byte) {
+		evicted = append(evicted, b...)
+	})
+	rb.Write([]byte("abcdef"))
+	if n, err := rb.Write([]byte("ghijk")); err != nil || n != 5 {
+		t.Fatalf("expected 5 bytes written, got %d, %v", n, err)
+	}
+	if got := string(rb.Bytes(nil)); got != "defghijk" {
+		t.Fatalf("expected defghijk, got %q", got)
+	}
+	if string(evicted) != "abc" {
+		t.Fatalf("expected abc to be evicted, got %q", evicted)
+	}
+
+	evicted = nil
+	if err := rb.WriteByte('l'); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if string(evicted) != "d" {
+		t.Fatalf("expected d to be evicted, got %q", evicted)
+	}
+
+	// A write larger than the buffer keeps its last bytes.
+	evicted = nil
+	if n, err := rb.Write([]byte("0123456789")); err != nil || n != 10 {
+		t.Fatalf("expected 10 bytes written, got %d, %v", n, err)
+	}
+	if got := string(rb.Bytes(nil)); got != "23456789" {
+		t.Fatalf("expected 23456789, got %q", got)
+	}
+	if string(evicted) != "efghijkl01" {
+		t.Fatalf("expected efghijkl01 to be evicted, got %q", evicted)
+	}
+	if rb.WriteOffset() != 22 || rb.ReadOffset() != 14 {
+		t.Fatalf("expected offsets 22 and 14, got %d and %d", rb.WriteOffset(), rb.ReadOffset())
+	}
+}
+
+func TestRingBuffer_OverwriteBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true).SetOverwrite(true)
+	for i := 0; i < 10; i++ {
+		if _, err := rb.Write([]byte("abc")); err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	}
+	if got := string(rb.Bytes(nil)); got != "cabc" {
+		t.Fatalf("expected cabc, got %q", got)
+	}
+}
@@ -4,7 +4,11 @@
 
 package ringbuffer
 
-import "io"
+import (
+	"io"
+	"sync"
+	"time"
+)
 
 // Pipe creates an asynchronous in-memory pipe compatible with io.Pipe
 // It can be used to connect code expecting an [io.Reader]
@@ -18,20 +22,60 @@ import "io"
 // It is safe (and intended) to call Read and Write in parallel with each other or with Close.
 func (r *RingBuffer) Pipe() (*PipeReader, *PipeWriter) {
 	r.SetBlocking(true)
-	pr := PipeReader{pipe: r}
-	return &pr, &PipeWriter{pipe: r}
+	pr := &PipeReader{pipe: r}
+	pr.dl = pr.deadline.get
+	pw := &PipeWriter{pipe: r}
+	pw.dl = pw.deadline.get
+	return pr, pw
+}
+
+// pipeDeadline is the deadline of one half of a pipe.
+type pipeDeadline struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (d *pipeDeadline) get() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.t
+}
+
+func (d *pipeDeadline) set(t time.Time) {
+	d.mu.Lock()
+	d.t = t
+	d.mu.Unlock()
 }
 
 // A PipeReader is the read half of a pipe.
-type PipeReader struct{ pipe *RingBuffer }
+type PipeReader struct {
+	pipe     *RingBuffer
+	deadline pipeDeadline
+	dl       func() time.Time
+}
 
 // Read implements the standard Read interface:
 // it reads data from the pipe, blocking until a writer
 // arrives or the write end is closed.
 // If the write end is closed with an error, that error is
 // returned as err; otherwise err is io.EOF.
+// If the read deadline passes, Read returns os.ErrDeadlineExceeded.
 func (r *PipeReader) Read(data []byte) (n int, err error) {
-	return r.pipe.Read(data)
+	r.pipe.mu.Lock()
+	defer r.pipe.unlock()
+	return r.pipe.readUntil(data, r.dl)
+}
+
+// SetReadDeadline sets the deadline for reads, including blocked reads.
+// When it passes reads return os.ErrDeadlineExceeded,
+// without closing the pipe, unlike the timeouts of the ring buffer.
+// A zero t disables the deadline.
+func (r *PipeReader) SetReadDeadline(t time.Time) error {
+	r.deadline.set(t)
+	r.pipe.mu.Lock()
+	r.pipe.writeCond.Broadcast()
+	r.pipe.unlock()
+	return nil
 }
 
 // Close closes the reader; subsequent writes to the
@@ -55,21 +99,42 @@ func (r *PipeReader) CloseWithError(err error) error {
 }
 
 // A PipeWriter is the write half of a pipe.
-type PipeWriter struct{ pipe *RingBuffer }
+type PipeWriter struct {
+	pipe     *RingBuffer
+	deadline pipeDeadline
+	dl       func() time.Time
+}
 
 // Write implements the standard Write interface:
 // it writes data to the pipe.
 // The Write will block until all data has been written to the ring buffer.
 // If the read end is closed with an error, that err is
 // returned as err; otherwise err is [io.ErrClosedPipe].
+// If the write deadline passes, Write returns the number of bytes written
+// and os.ErrDeadlineExceeded.
 func (w *PipeWriter) Write(data []byte) (n int, err error) {
-	if n, err = w.pipe.Write(data); err == ErrWriteOnClosed {
+	w.pipe.mu.Lock()
+	n, err = w.pipe.writeUntil(data, w.dl)
+	w.pipe.unlock()
+	if err == ErrWriteOnClosed {
 		// Replace error.
 		err = io.ErrClosedPipe
 	}
 	return n, err
 }
 
+// SetWriteDeadline sets the deadline for writes, including blocked writes.
+// When it passes writes return os.ErrDeadlineExceeded,
+// without closing the pipe, unlike the timeouts of the ring buffer.
+// A zero t disables the deadline.
+func (w *PipeWriter) SetWriteDeadline(t time.Time) error {
+	w.deadline.set(t)
+	w.pipe.mu.Lock()
+	w.pipe.readCond.Broadcast()
+	w.pipe.unlock()
+	return nil
+}
+
 // Close closes the writer; subsequent reads from the
 // read half of the pipe will return no bytes and EOF.
 func (w *PipeWriter) Close() error {
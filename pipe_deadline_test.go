@@ -0,0 +1,45 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPipeDeadlines(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	pr, pw := New(4).Pipe()
+
+	pr.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	if _, err := pr.Read(make([]byte, 1)); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	pw.SetWriteDeadline(time.Now().Add(20 * time.Millisecond))
+	if n, err := pw.Write([]byte("abcdef")); !errors.Is(err, os.ErrDeadlineExceeded) || n != 4 {
+		t.Fatalf("expected 4 bytes and os.ErrDeadlineExceeded, got %d, %v", n, err)
+	}
+
+	// The pipe is still usable.
+	pr.SetReadDeadline(time.Time{})
+	pw.SetWriteDeadline(time.Time{})
+	buf := make([]byte, 4)
+	if n, err := pr.Read(buf); err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", buf[:n], err)
+	}
+
+	// A deadline set while a read is blocked applies to it.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		pr.SetReadDeadline(time.Now())
+	}()
+	if _, err := pr.Read(buf); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("expected os.ErrDeadlineExceeded, got %v", err)
+	}
+	pw.Close()
+	pr.SetReadDeadline(time.Time{})
+	if _, err := pr.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
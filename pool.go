@@ -0,0 +1,69 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "sync"
+
+// Pool is a set of ring buffers that can be reused,
+// to avoid allocating a new buffer for each short-lived ring buffer,
+// for example one per connection.
+// Ring buffers are pooled by size, each size in its own sync.Pool,
+// so pooled buffers are released when they are not used for a while.
+// The zero value is an empty pool ready to use.
+// It is safe for concurrent use.
+type Pool struct {
+	mu    sync.Mutex
+	pools map[int]*sync.Pool
+}
+
+// Get returns a ring buffer of the given size from the pool,
+// or a new one if the pool has none.
+// The ring buffer is in the state New returns it in:
+// empty, open, non-blocking and without any option set.
+func (p *Pool) Get(size int) *RingBuffer {
+	if r, _ := p.pool(size).Get().(*RingBuffer); r != nil {
+		return r
+	}
+	return New(size)
+}
+
+// Put resets r to the state of a new ring buffer and returns it to the pool.
+// All data and options are discarded, including blocking mode, timeouts,
+// callbacks and hooks; if secure wipe was enabled the buffer is zeroed first,
+// and the accountant is notified that the buffer is freed.
+// r must not be used after Put, and must not have been configured with WithCancel,
+// whose goroutine would later close it.
+// Released, memory-mapped and in-use ring buffers are not pooled.
+func (p *Pool) Put(r *RingBuffer) {
+	r.mu.Lock()
+	buf := r.buf
+	if buf == nil || r.mapped != nil || r.inFlight > 0 || r.lent > 0 {
+		r.mu.Unlock()
+		return
+	}
+	if r.wipe {
+		zero(buf)
+	}
+	r.freed(len(buf))
+	r.mu.Unlock()
+
+	*r = RingBuffer{buf: buf, size: len(buf)}
+	p.pool(len(buf)).Put(r)
+}
+
+// pool returns the pool of ring buffers of the given size.
+func (p *Pool) pool(size int) *sync.Pool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sp := p.pools[size]
+	if sp == nil {
+		if p.pools == nil {
+			p.pools = make(map[int]*sync.Pool)
+		}
+		sp = &sync.Pool{}
+		p.pools[size] = sp
+	}
+	return sp
+}
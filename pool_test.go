@@ -0,0 +1,47 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	var p Pool
+	rb := p.Get(8)
+	if rb.Capacity() != 8 {
+		t.Fatalf("expected capacity 8, got %d", rb.Capacity())
+	}
+	rb.SetBlocking(true).SetOverwrite(true).WithTimeout(time.Second).WithName("conn")
+	rb.Write([]byte("abc"))
+	rb.CloseWriter()
+	p.Put(rb)
+
+	// sync.Pool may drop the buffer, so only check a reused one is reset.
+	rb = p.Get(8)
+	if rb.Capacity() != 8 || rb.Length() != 0 || rb.block || rb.overwrite || rb.rTimeout != 0 || rb.Name() != "" {
+		t.Fatalf("expected a reset ring buffer, got %+v", rb.Stats())
+	}
+	if _, err := rb.Write([]byte("abcd")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if rb := p.Get(4); rb.Capacity() != 4 {
+		t.Fatalf("expected capacity 4, got %d", rb.Capacity())
+	}
+}
+
+func TestPool_PutWipe(t *testing.T) {
+	var p Pool
+	buf := []byte("secret")
+	rb := NewBuffer(buf).SetSecureWipe(true)
+	p.Put(rb)
+	if string(buf) != "\x00\x00\x00\x00\x00\x00" {
+		t.Fatalf("expected the buffer to be wiped, got %q", buf)
+	}
+
+	rb = New(4)
+	rb.Release()
+	p.Put(rb)
+	if rb := p.Get(4); rb.buf == nil {
+		t.Fatalf("expected released ring buffers not to be pooled")
+	}
+}
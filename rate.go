@@ -0,0 +1,163 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRateLimited is returned in non-blocking mode by a RateLimitedReader or RateLimitedWriter
+// when the rate limit does not allow more data for now.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// Bandwidth is a rate in bytes per second.
+type Bandwidth int64
+
+// tokenBucket is a token bucket allowing rate bytes per second,
+// in bursts of up to burst bytes.
+// It is protected by the lock of the ring buffer.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newTokenBucket returns a full token bucket for limit,
+// allowing bursts of one second of data or size bytes, whichever is smaller.
+// It panics if limit is not positive, since take would never wait.
+func newTokenBucket(limit Bandwidth, size int) tokenBucket {
+	if limit <= 0 {
+		panic("ringbuffer: non-positive bandwidth")
+	}
+	burst := float64(limit)
+	if burst > float64(size) {
+		burst = float64(size)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return tokenBucket{rate: float64(limit), burst: burst, tokens: burst, last: time.Now()}
+}
+
+// take takes up to n tokens and returns how many were taken.
+// If there are none, it returns how long it takes for one to be available.
+func (b *tokenBucket) take(n int, now time.Time) (int, time.Duration) {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return 0, time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	}
+	k := int(b.tokens)
+	if k > n {
+		k = n
+	}
+	b.tokens -= float64(k)
+	return k, 0
+}
+
+// refund returns n unused tokens.
+func (b *tokenBucket) refund(n int) {
+	b.tokens += float64(n)
+}
+
+// A RateLimitedWriter writes to a ring buffer at no more than a given bandwidth.
+type RateLimitedWriter struct {
+	rb *RingBuffer
+	tb tokenBucket
+}
+
+// RateLimitedWriter returns a writer that writes to the ring buffer at up to limit bytes per second,
+// allowing bursts of one second of data or the size of the buffer, whichever is smaller.
+// It panics if limit is not positive.
+// Only the data written through the returned writer is limited.
+func (r *RingBuffer) RateLimitedWriter(limit Bandwidth) *RateLimitedWriter {
+	r.mu.Lock()
+	defer r.unlock()
+	return &RateLimitedWriter{rb: r, tb: newTokenBucket(limit, r.size)}
+}
+
+// Write writes p like the Write method of the ring buffer,
+// waiting as needed to stay within the bandwidth.
+// In non-blocking mode it returns the number of bytes written and ErrRateLimited
+// when the bandwidth is used up.
+// Waits are interrupted when the ring buffer is closed or reset.
+func (w *RateLimitedWriter) Write(p []byte) (n int, err error) {
+	r := w.rb
+	r.mu.Lock()
+	defer r.unlock()
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	for n < len(p) {
+		if err := r.writeErr(); err != nil {
+			return n, err
+		}
+		k, wait := w.tb.take(len(p)-n, time.Now())
+		if k == 0 {
+			if !r.block {
+				return n, ErrRateLimited
+			}
+			r.waitReadUntil(time.Now().Add(wait))
+			continue
+		}
+		m, err := r.writeUntil(p[n:n+k], nil)
+		n += m
+		w.tb.refund(k - m)
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// A RateLimitedReader reads from a ring buffer at no more than a given bandwidth.
+type RateLimitedReader struct {
+	rb *RingBuffer
+	tb tokenBucket
+}
+
+// RateLimitedReader returns a reader that reads from the ring buffer at up to limit bytes per second,
+// allowing bursts like RateLimitedWriter.
+// It panics if limit is not positive.
+// Only the data read through the returned reader is limited.
+func (r *RingBuffer) RateLimitedReader(limit Bandwidth) *RateLimitedReader {
+	r.mu.Lock()
+	defer r.unlock()
+	return &RateLimitedReader{rb: r, tb: newTokenBucket(limit, r.size)}
+}
+
+// Read reads up to len(p) bytes like the Read method of the ring buffer,
+// first waiting as needed to stay within the bandwidth.
+// In non-blocking mode it returns ErrRateLimited when the bandwidth is used up.
+// Waits are interrupted when the ring buffer is closed or reset.
+func (rr *RateLimitedReader) Read(p []byte) (n int, err error) {
+	r := rr.rb
+	r.mu.Lock()
+	defer r.unlock()
+	if len(p) == 0 {
+		return 0, r.readErr(true)
+	}
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	for {
+		if err := r.readErr(true); err != nil {
+			return 0, err
+		}
+		k, wait := rr.tb.take(len(p), time.Now())
+		if k > 0 {
+			n, err = r.readUntil(p[:k], nil)
+			rr.tb.refund(k - n)
+			return n, err
+		}
+		if !r.block {
+			return 0, ErrRateLimited
+		}
+		r.waitWriteUntil(time.Now().Add(wait))
+	}
+}
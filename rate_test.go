@@ -0,0 +1,104 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRateLimitedWriter(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(64).SetBlocking(true)
+	w := rb.RateLimitedWriter(640)
+	go io.Copy(io.Discard, rb)
+
+	start := time.Now()
+	n, err := w.Write(make([]byte, 192))
+	if err != nil || n != 192 {
+		t.Fatalf("expected 192 bytes, got %d, %v", n, err)
+	}
+	// The first 64 bytes are a burst, the rest takes 200ms.
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Fatalf("expected the write to take 200ms, took %v", d)
+	}
+	rb.CloseWriter()
+	if _, err := w.Write([]byte("a")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+}
+
+func TestRateLimitedWriter_NonBlocking(t *testing.T) {
+	rb := New(64)
+	w := rb.RateLimitedWriter(32)
+	n, err := w.Write(make([]byte, 48))
+	if err != ErrRateLimited || n != 32 {
+		t.Fatalf("expected 32 bytes and ErrRateLimited, got %d, %v", n, err)
+	}
+}
+
+func TestRateLimitedReader(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(64).SetBlocking(true)
+	r := rb.RateLimitedReader(320)
+	rb.Write(make([]byte, 64))
+
+	start := time.Now()
+	buf := make([]byte, 64)
+	total := 0
+	for total < 64 {
+		n, err := r.Read(buf)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		total += n
+	}
+	// The first 64 bytes are a burst.
+	if d := time.Since(start); d > 50*time.Millisecond {
+		t.Fatalf("expected the burst to be read at once, took %v", d)
+	}
+	rb.Write(make([]byte, 32))
+	start = time.Now()
+	for total < 96 {
+		n, _ := r.Read(buf)
+		total += n
+	}
+	if d := time.Since(start); d < 50*time.Millisecond {
+		t.Fatalf("expected the read to take 100ms, took %v", d)
+	}
+
+	// Closing interrupts a reader waiting for the bandwidth.
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.CloseWithError(io.ErrClosedPipe)
+	}()
+	rb.Write(make([]byte, 64))
+	start = time.Now()
+	var err error
+	for err == nil {
+		_, err = r.Read(buf)
+	}
+	if err != io.ErrClosedPipe {
+		t.Fatalf("expected io.ErrClosedPipe, got %v", err)
+	}
+	if d := time.Since(start); d > 150*time.Millisecond {
+		t.Fatalf("expected the reader to stop when closed, took %v", d)
+	}
+}
+
+func TestRateLimitedNonPositive(t *testing.T) {
+	for _, limit := range []Bandwidth{0, -1} {
+		for name, f := range map[string]func(){
+			"writer": func() { New(8).RateLimitedWriter(limit) },
+			"reader": func() { New(8).RateLimitedReader(limit) },
+		} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Fatalf("expected a panic for a %s limited to %d", name, limit)
+					}
+				}()
+				f()
+			}()
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// ReadableSlices returns the buffered data as up to two slices of the backing array,
+// in the order the data should be consumed, so it can be parsed in place.
+// The second slice is only used when the data wraps around the end of the backing array.
+// Call Advance to consume the data once it is no longer needed.
+//
+// Like ReadDescriptors, the slices are only valid until the next read from the ring buffer,
+// so there should be a single reader while they are in use.
+func (r *RingBuffer) ReadableSlices() (s [2][]byte) {
+	r.mu.Lock()
+	defer r.unlock()
+	s[0], s[1] = r.readable()
+	return s
+}
+
+// Advance consumes n bytes of the data returned by ReadableSlices without copying them.
+// ErrInvalidLength is returned if n is more than the buffered data.
+func (r *RingBuffer) Advance(n int) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if n < 0 || n > r.length() {
+		return ErrInvalidLength
+	}
+	r.discard(n)
+	return nil
+}
@@ -0,0 +1,31 @@
+package ringbuffer
+
+import "testing"
+
+func TestRingBuffer_ReadableSlices(t *testing.T) {
+	rb := New(8)
+	if s := rb.ReadableSlices(); s[0] != nil || s[1] != nil {
+		t.Fatalf("expected no slices, got %q", s)
+	}
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghij"))
+
+	s := rb.ReadableSlices()
+	if string(s[0]) != "efgh" || string(s[1]) != "ij" {
+		t.Fatalf("expected efgh and ij, got %q", s)
+	}
+	if err := rb.Advance(7); err != ErrInvalidLength {
+		t.Fatalf("expected ErrInvalidLength, got %v", err)
+	}
+	if err := rb.Advance(5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	s = rb.ReadableSlices()
+	if string(s[0]) != "j" || s[1] != nil {
+		t.Fatalf("expected j, got %q", s)
+	}
+	if rb.ReadOffset() != 9 {
+		t.Fatalf("expected read offset 9, got %d", rb.ReadOffset())
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "io"
+
+// Peeker is the interface that wraps the basic Peek method.
+//
+// Peek reads up to len(p) bytes into p without consuming them.
+type Peeker interface {
+	Peek(p []byte) (n int, err error)
+}
+
+// ReadOnlyBuffer is a read-only view of a ring buffer.
+type ReadOnlyBuffer interface {
+	io.Reader
+	Peeker
+	Length() int
+}
+
+// ReadOnly returns a read-only view of the ring buffer.
+// The view only allows reading, peeking and checking the length,
+// so consumers cannot write to, reset or close the ring buffer.
+// The underlying ring buffer cannot be recovered from the view.
+func (r *RingBuffer) ReadOnly() ReadOnlyBuffer {
+	return readOnly{rb: r}
+}
+
+type readOnly struct {
+	rb *RingBuffer
+}
+
+func (r readOnly) Read(p []byte) (n int, err error) {
+	return r.rb.Read(p)
+}
+
+func (r readOnly) Peek(p []byte) (n int, err error) {
+	return r.rb.Peek(p)
+}
+
+func (r readOnly) Length() int {
+	return r.rb.Length()
+}
@@ -0,0 +1,32 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+)
+
+func TestRingBuffer_ReadOnly(t *testing.T) {
+	rb := New(10)
+	ro := rb.ReadOnly()
+	if _, ok := ro.(io.Writer); ok {
+		t.Fatalf("read-only view implements io.Writer")
+	}
+	if _, ok := ro.(interface{ Reset() }); ok {
+		t.Fatalf("read-only view implements Reset")
+	}
+
+	rb.Write([]byte("hello"))
+	if ro.Length() != 5 {
+		t.Fatalf("expected length 5, got %d", ro.Length())
+	}
+	buf := make([]byte, 2)
+	if n, err := ro.Peek(buf); err != nil || string(buf[:n]) != "he" {
+		t.Fatalf("expected he, nil; got %q, %v", buf[:n], err)
+	}
+	if n, err := ro.Read(buf); err != nil || string(buf[:n]) != "he" {
+		t.Fatalf("expected he, nil; got %q, %v", buf[:n], err)
+	}
+	if ro.Length() != 3 {
+		t.Fatalf("expected length 3, got %d", ro.Length())
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "errors"
+
+// ErrOutOfOrderPending is returned by sequential writes while
+// out-of-order data written with WriteAtOffset is waiting for the gap before it to be filled.
+var ErrOutOfOrderPending = errors.New("out-of-order data pending")
+
+// Range is a range of absolute stream offsets, from Start (inclusive) to End (exclusive).
+type Range struct {
+	Start, End int64
+}
+
+// WriteAtOffset writes p at the absolute stream offset off,
+// which may be ahead of the contiguous data written so far.
+// The absolute offset of a byte is the number of bytes written before it
+// since the ring buffer was created or Reset.
+//
+// Data ahead of the contiguous frontier is kept in the free space of the buffer,
+// but is not readable until the gap before it has been filled.
+// Reads only ever return contiguous data.
+// Data before the frontier has already been received and is ignored.
+// This allows the ring buffer to be used as a receive buffer for
+// protocols that deliver data out of order.
+//
+// Data that does not fit in the free space of the buffer is dropped
+// and ErrTooMuchDataToWrite is returned.
+// The returned n is the number of bytes of p that were accepted,
+// including bytes that were already received.
+//
+// While out-of-order data is pending, sequential writes fail with ErrOutOfOrderPending.
+func (r *RingBuffer) WriteAtOffset(off int64, p []byte) (n int, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.writeErr(); err != nil && err != ErrOutOfOrderPending {
+		return 0, err
+	}
+
+	// Skip data that has already been received.
+	if skip := r.written - off; skip > 0 {
+		if skip >= int64(len(p)) {
+			return len(p), nil
+		}
+		n = int(skip)
+		p = p[skip:]
+		off = r.written
+	}
+	if len(p) == 0 {
+		return n, nil
+	}
+
+	if rem := r.remaining(); off-r.written+int64(len(p)) > rem {
+		// Drop data beyond the limit.
+		if off-r.written >= rem {
+			return n, ErrWriteOnClosed
+		}
+		err = ErrWriteOnClosed
+		p = p[:rem-(off-r.written)]
+	}
+
+	rel := int(off - r.written)
+	r.grow(rel + len(p))
+	free := r.free()
+	if rel >= free {
+		r.stalls++
+		return n, ErrTooMuchDataToWrite
+	}
+	if rel+len(p) > free {
+		r.stalls++
+		err = ErrTooMuchDataToWrite
+		p = p[:free-rel]
+	}
+
+	a, b := r.writable()
+	if rel < len(a) {
+		c := copy(a[rel:], p)
+		copy(b, p[c:])
+	} else {
+		copy(b[rel-len(a):], p)
+	}
+	n += len(p)
+	r.addPending(Range{Start: off, End: off + int64(len(p))})
+
+	// Publish the data that has become contiguous.
+	if first := r.pending[0]; first.Start == r.written {
+		r.pending = r.pending[1:]
+		if len(r.pending) == 0 {
+			r.pending = nil
+		}
+		r.advanceWrite(int(first.End - first.Start))
+		if r.block {
+			r.writeCond.Broadcast()
+		}
+	}
+	return n, err
+}
+
+// Ranges returns the ranges of out-of-order data received with WriteAtOffset
+// beyond the contiguous frontier, sorted by offset.
+// Adjacent and overlapping ranges are merged.
+// It can be used to generate selective acknowledgements.
+// The returned slice is a copy and may be modified.
+func (r *RingBuffer) Ranges() []Range {
+	r.mu.Lock()
+	defer r.unlock()
+	if len(r.pending) == 0 {
+		return nil
+	}
+	return append([]Range(nil), r.pending...)
+}
+
+// addPending adds rng to the sorted pending ranges, merging overlapping
+// and adjacent ranges.
+// Must be called when locked.
+func (r *RingBuffer) addPending(rng Range) {
+	merged := r.pending[:0:0]
+	i := 0
+	for ; i < len(r.pending) && r.pending[i].End < rng.Start; i++ {
+		merged = append(merged, r.pending[i])
+	}
+	for ; i < len(r.pending) && r.pending[i].Start <= rng.End; i++ {
+		if r.pending[i].Start < rng.Start {
+			rng.Start = r.pending[i].Start
+		}
+		if r.pending[i].End > rng.End {
+			rng.End = r.pending[i].End
+		}
+	}
+	merged = append(merged, rng)
+	r.pending = append(merged, r.pending[i:]...)
+}
@@ -0,0 +1,130 @@
+package ringbuffer
+
+import (
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_WriteAtOffset(t *testing.T) {
+	rb := New(16)
+	if n, err := rb.WriteAtOffset(6, []byte("ghi")); err != nil || n != 3 {
+		t.Fatalf("expected 3, nil; got %d, %v", n, err)
+	}
+	if !rb.IsEmpty() {
+		t.Fatalf("out-of-order data is readable")
+	}
+	if _, err := rb.Write([]byte("abc")); err != ErrOutOfOrderPending {
+		t.Fatalf("expected ErrOutOfOrderPending, got %v", err)
+	}
+	rb.WriteAtOffset(0, []byte("abc"))
+	if got := string(rb.Bytes(nil)); got != "abc" {
+		t.Fatalf("expected abc, got %q", got)
+	}
+	// Overlapping the frontier and the pending range.
+	rb.WriteAtOffset(2, []byte("cdefg"))
+	if got := string(rb.Bytes(nil)); got != "abcdefghi" {
+		t.Fatalf("expected abcdefghi, got %q", got)
+	}
+	if n, err := rb.WriteAtOffset(1, []byte("bc")); err != nil || n != 2 {
+		t.Fatalf("expected 2, nil; got %d, %v", n, err)
+	}
+	if _, err := rb.Write([]byte("j")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Data beyond the free space is dropped.
+	rb.Read(make([]byte, 5))
+	n, err := rb.WriteAtOffset(15, []byte("0123456789"))
+	if err != ErrTooMuchDataToWrite || n != 6 {
+		t.Fatalf("expected 6, ErrTooMuchDataToWrite; got %d, %v", n, err)
+	}
+	if _, err := rb.WriteAtOffset(30, []byte("x")); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+	rb.WriteAtOffset(10, []byte("klmno"))
+	if got := string(rb.Bytes(nil)); got != "fghijklmno012345" {
+		t.Fatalf("expected fghijklmno012345, got %q", got)
+	}
+	if !rb.IsFull() {
+		t.Fatalf("expect IsFull is true but got false")
+	}
+}
+
+func TestRingBuffer_WriteAtOffsetRandom(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	data := make([]byte, 100000)
+	rand.Read(data)
+
+	rb := New(1024).SetBlocking(true)
+	go func() {
+		var off int64
+		for off < int64(len(data)) {
+			// Send a window of packets out of order.
+			var packets []Range
+			for start := off; start < off+512 && start < int64(len(data)); start += 64 {
+				end := start + 64
+				if end > int64(len(data)) {
+					end = int64(len(data))
+				}
+				packets = append(packets, Range{start, end})
+			}
+			rand.Shuffle(len(packets), func(i, j int) { packets[i], packets[j] = packets[j], packets[i] })
+			for _, p := range packets {
+				for {
+					_, err := rb.WriteAtOffset(p.Start, data[p.Start:p.End])
+					if err == nil {
+						break
+					}
+					time.Sleep(time.Millisecond)
+				}
+			}
+			off = packets[0].End
+			for _, p := range packets {
+				if p.End > off {
+					off = p.End
+				}
+			}
+		}
+		rb.CloseWriter()
+	}()
+	got, err := io.ReadAll(rb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Fatalf("reassembled data does not match")
+	}
+}
+
+func TestRingBuffer_Ranges(t *testing.T) {
+	rb := New(32)
+	if got := rb.Ranges(); got != nil {
+		t.Fatalf("expected no ranges, got %v", got)
+	}
+	rb.WriteAtOffset(10, []byte("kl"))
+	rb.WriteAtOffset(4, []byte("ef"))
+	rb.WriteAtOffset(12, []byte("mn"))
+	rb.WriteAtOffset(20, []byte("u"))
+	want := []Range{{4, 6}, {10, 14}, {20, 21}}
+	if got := rb.Ranges(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	rb.WriteAtOffset(5, []byte("fghij"))
+	want = []Range{{4, 14}, {20, 21}}
+	if got := rb.Ranges(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	rb.WriteAtOffset(0, []byte("abcd"))
+	want = []Range{{20, 21}}
+	if got := rb.Ranges(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := string(rb.Bytes(nil)); got != "abcdefghijklmn" {
+		t.Fatalf("expected abcdefghijklmn, got %q", got)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"sort"
+	"sync"
+)
+
+// Registry is a set of named ring buffers,
+// which can be enumerated for monitoring, debug dumps and admin tooling.
+// The zero value is an empty registry ready to use.
+// It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	buffers map[string]*RingBuffer
+}
+
+// DefaultRegistry is the registry used by the package level
+// Register, Unregister, Lookup and RangeRegistry functions.
+var DefaultRegistry = &Registry{}
+
+// Register adds rb to the registry under name,
+// replacing any ring buffer previously registered under that name.
+func (reg *Registry) Register(name string, rb *RingBuffer) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	if reg.buffers == nil {
+		reg.buffers = make(map[string]*RingBuffer)
+	}
+	reg.buffers[name] = rb
+}
+
+// Unregister removes the ring buffer registered under name.
+func (reg *Registry) Unregister(name string) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	delete(reg.buffers, name)
+}
+
+// Lookup returns the ring buffer registered under name.
+func (reg *Registry) Lookup(name string) (rb *RingBuffer, ok bool) {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	rb, ok = reg.buffers[name]
+	return rb, ok
+}
+
+// Range calls fn for each registered ring buffer, sorted by name.
+// If fn returns false, Range stops the iteration.
+// fn may modify the registry.
+func (reg *Registry) Range(fn func(name string, rb *RingBuffer) bool) {
+	reg.mu.RLock()
+	names := make([]string, 0, len(reg.buffers))
+	for name := range reg.buffers {
+		names = append(names, name)
+	}
+	reg.mu.RUnlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		rb, ok := reg.Lookup(name)
+		if !ok {
+			continue
+		}
+		if !fn(name, rb) {
+			return
+		}
+	}
+}
+
+// Register adds rb to the default registry under name.
+func Register(name string, rb *RingBuffer) {
+	DefaultRegistry.Register(name, rb)
+}
+
+// Unregister removes the ring buffer registered under name from the default registry.
+func Unregister(name string) {
+	DefaultRegistry.Unregister(name)
+}
+
+// Lookup returns the ring buffer registered under name in the default registry.
+func Lookup(name string) (rb *RingBuffer, ok bool) {
+	return DefaultRegistry.Lookup(name)
+}
+
+// RangeRegistry calls fn for each ring buffer in the default registry, sorted by name.
+// If fn returns false, RangeRegistry stops the iteration.
+func RangeRegistry(fn func(name string, rb *RingBuffer) bool) {
+	DefaultRegistry.Range(fn)
+}
@@ -0,0 +1,61 @@
+package ringbuffer
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	var reg Registry
+	if _, ok := reg.Lookup("a"); ok {
+		t.Fatalf("found buffer in empty registry")
+	}
+	a, b, c := New(1), New(2), New(3)
+	reg.Register("b", b)
+	reg.Register("a", a)
+	reg.Register("c", New(4))
+	reg.Register("c", c)
+
+	if rb, ok := reg.Lookup("a"); !ok || rb != a {
+		t.Fatalf("lookup returned %v, %v", rb, ok)
+	}
+
+	var names []string
+	reg.Range(func(name string, rb *RingBuffer) bool {
+		names = append(names, name)
+		if name == "c" && rb != c {
+			t.Errorf("registered buffer not replaced")
+		}
+		return true
+	})
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+
+	names = names[:0]
+	reg.Range(func(name string, rb *RingBuffer) bool {
+		names = append(names, name)
+		reg.Unregister("b")
+		return name != "c"
+	})
+	if want := []string{"a", "c"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("expected %v, got %v", want, names)
+	}
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	rb := New(1)
+	Register("test", rb)
+	defer Unregister("test")
+	if got, ok := Lookup("test"); !ok || got != rb {
+		t.Fatalf("lookup returned %v, %v", got, ok)
+	}
+	found := false
+	RangeRegistry(func(name string, got *RingBuffer) bool {
+		found = found || got == rb
+		return true
+	})
+	if !found {
+		t.Fatalf("registered buffer not found")
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrReplicaGap is returned when a replica resumes from an offset
+	// whose data is no longer in the backlog of the Replicator.
+	ErrReplicaGap = errors.New("replica offset not in backlog")
+
+	// ErrTeeInUse is returned by Replicate when a write tee is already set.
+	ErrTeeInUse = errors.New("write tee already set")
+
+	// errReplicatorStopped is the internal error of a replicator stopped by Close.
+	errReplicatorStopped = errors.New("replicator stopped")
+)
+
+// replicaHeader is the size of the offset a replica sends when it connects.
+const replicaHeader = 8
+
+// Replicator streams the data written to a ring buffer to a remote replica over TCP.
+// It is created by Replicate.
+type Replicator struct {
+	rb      *RingBuffer
+	backlog *RingBuffer // Data written since Replicate, in overwrite mode.
+	addr    string
+	retry   time.Duration
+	buf     []byte
+
+	stop chan struct{}
+	done chan struct{}
+	once sync.Once
+	err  error // Error that stopped the replicator, set before done is closed.
+}
+
+// Replicate starts a goroutine that streams everything written to the ring buffer
+// from now on to a replica listening at the TCP address addr, see ServeReplica,
+// while the local readers of the ring buffer read normally.
+// The data is captured with TeeWrites, so no other write tee can be set,
+// and ErrTeeInUse is returned if one is.
+//
+// The last backlog bytes written are kept for the replica.
+// If the connection fails, the replicator reconnects every retry,
+// or every second if retry is 0 or less, and the replica resumes
+// from the offset of the data it has received.
+// If that data is no longer in the backlog, the replicator stops with ErrReplicaGap.
+// ErrEncrypted is returned if the ring buffer is encrypted,
+// since the replica would receive the data in cleartext, see SetEncryptionKey.
+func (r *RingBuffer) Replicate(addr string, backlog int, retry time.Duration) (*Replicator, error) {
+	if retry <= 0 {
+		retry = time.Second
+	}
+	p := &Replicator{
+		rb:      r,
+		backlog: New(backlog).SetOverwrite(true),
+		addr:    addr,
+		retry:   retry,
+		buf:     make([]byte, 32*1024),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	r.mu.Lock()
+	defer r.unlock()
+	if r.enc != nil {
+		return nil, ErrEncrypted
+	}
+	if r.wTee != nil {
+		return nil, ErrTeeInUse
+	}
+	r.wTee = p.backlog
+	go p.run()
+	return p, nil
+}
+
+// Close stops the replicator and removes its write tee.
+// If a replica is connected, Close first waits until the data written so far has been sent,
+// so a replica that stops reading makes Close wait.
+// It returns the error that stopped the replicator, if any.
+func (p *Replicator) Close() error {
+	p.once.Do(func() { close(p.stop) })
+	<-p.done
+	if p.err == errReplicatorStopped {
+		return nil
+	}
+	return p.err
+}
+
+// Done returns a channel that is closed when the replicator has stopped.
+func (p *Replicator) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns the error that stopped the replicator, or nil if it is running or was closed.
+func (p *Replicator) Err() error {
+	select {
+	case <-p.done:
+		if p.err == errReplicatorStopped {
+			return nil
+		}
+		return p.err
+	default:
+		return nil
+	}
+}
+
+// run connects to the replica and streams the backlog until the replicator is stopped.
+func (p *Replicator) run() {
+	defer close(p.done)
+	defer func() {
+		p.rb.mu.Lock()
+		if p.rb.wTee == p.backlog {
+			p.rb.wTee = nil
+		}
+		p.rb.unlock()
+	}()
+	for {
+		err := p.connect()
+		if err == ErrReplicaGap || err == errReplicatorStopped {
+			p.err = err
+			return
+		}
+		select {
+		case <-time.After(p.retry):
+		case <-p.stop:
+			p.err = errReplicatorStopped
+			return
+		}
+	}
+}
+
+// connect connects to the replica, reads its offset and streams the backlog from there.
+func (p *Replicator) connect() error {
+	conn, err := net.DialTimeout("tcp", p.addr, p.retry)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var hdr [replicaHeader]byte
+	conn.SetReadDeadline(time.Now().Add(p.retry))
+	if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+		return err
+	}
+	return p.stream(conn, int64(binary.BigEndian.Uint64(hdr[:])))
+}
+
+// stream writes the backlog from offset off to conn until a write fails or the replicator is stopped.
+func (p *Replicator) stream(conn net.Conn, off int64) error {
+	readable := p.backlog.ReadableC()
+	for {
+		n, err := p.next(off)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			select {
+			case <-readable:
+				continue
+			case <-p.stop:
+				return errReplicatorStopped
+			}
+		}
+		if _, err := conn.Write(p.buf[:n]); err != nil {
+			return err
+		}
+		off += int64(n)
+	}
+}
+
+// next copies the backlog data at offset off into the buffer of the replicator
+// and returns its size, or ErrReplicaGap if the data is not in the backlog.
+func (p *Replicator) next(off int64) (n int, err error) {
+	b := p.backlog
+	b.mu.Lock()
+	defer b.unlock()
+	start := b.consumed()
+	if off < start || off > b.written {
+		return 0, ErrReplicaGap
+	}
+	return b.peekAt(p.buf, int(off-start)), nil
+}
+
+// ServeReplica accepts the connections of a Replicator on l, one at a time,
+// and writes the replicated data to w, for example a ring buffer of a standby process.
+// When the Replicator reconnects, it resumes after the data already written to w,
+// so w receives every byte once and in order.
+// ServeReplica returns the error of Accept, for example when l is closed,
+// or the first error of w.
+func ServeReplica(l net.Listener, w io.Writer) error {
+	ew := &replicaWriter{w: w}
+	var off int64
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		var hdr [replicaHeader]byte
+		binary.BigEndian.PutUint64(hdr[:], uint64(off))
+		if _, err := conn.Write(hdr[:]); err == nil {
+			n, _ := io.Copy(ew, conn)
+			off += n
+		}
+		conn.Close()
+		if ew.err != nil {
+			return ew.err
+		}
+	}
+}
+
+// replicaWriter is a writer that records the first error of w,
+// to tell it apart from the errors of the connection.
+type replicaWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (w *replicaWriter) Write(p []byte) (n int, err error) {
+	n, err = w.w.Write(p)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
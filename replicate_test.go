@@ -0,0 +1,93 @@
+package ringbuffer
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Replicate(t *testing.T) {
+	defer timeout(10 * time.Second)()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+
+	rb := New(8)
+	p, err := rb.Replicate(l.Addr().String(), 64, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := rb.Replicate(l.Addr().String(), 64, 0); err != ErrTeeInUse {
+		t.Fatalf("expected ErrTeeInUse, got %v", err)
+	}
+	rb.Write([]byte("hello "))
+	// The local reader reads normally.
+	buf := make([]byte, 8)
+	if n, _ := rb.Read(buf); string(buf[:n]) != "hello " {
+		t.Fatalf("expected hello, got %q", buf[:n])
+	}
+
+	// The first connection breaks after 3 bytes.
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hdr [replicaHeader]byte
+	conn.Write(hdr[:])
+	if _, err := io.ReadFull(conn, buf[:3]); err != nil || string(buf[:3]) != "hel" {
+		t.Fatalf("expected hel, got %q, %v", buf[:3], err)
+	}
+	conn.Close()
+
+	// The replica resumes from the start, which is still in the backlog.
+	dst := New(64).SetBlocking(true)
+	go ServeReplica(l, dst)
+	rb.Write([]byte("world"))
+	got := make([]byte, 11)
+	if _, err := io.ReadFull(dst, got); err != nil || string(got) != "hello world" {
+		t.Fatalf("expected hello world, got %q, %v", got, err)
+	}
+	if err := p.Close(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	rb.mu.Lock()
+	tee := rb.wTee
+	rb.mu.Unlock()
+	if tee != nil {
+		t.Fatalf("expected the tee to be removed")
+	}
+}
+
+func TestRingBuffer_ReplicateGap(t *testing.T) {
+	defer timeout(10 * time.Second)()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+
+	rb := New(8)
+	p, _ := rb.Replicate(l.Addr().String(), 4, 10*time.Millisecond)
+	rb.Write([]byte("abcdef"))
+
+	// The replica asks for data dropped from the backlog.
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	var hdr [replicaHeader]byte
+	binary.BigEndian.PutUint64(hdr[:], 1)
+	conn.Write(hdr[:])
+	<-p.Done()
+	if err := p.Err(); err != ErrReplicaGap {
+		t.Fatalf("expected ErrReplicaGap, got %v", err)
+	}
+	if err := p.Close(); err != ErrReplicaGap {
+		t.Fatalf("expected ErrReplicaGap, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "errors"
+
+var (
+	// ErrReserved is returned by Reserve when a reservation is already open.
+	ErrReserved = errors.New("write reservation already open")
+	// ErrNotReserved is returned by Commit and Abort when no reservation is open.
+	ErrNotReserved = errors.New("no write reservation open")
+)
+
+// Reserve returns n contiguous bytes of the free space of the buffer,
+// so an encoder can serialize directly into the ring buffer
+// instead of into an intermediate slice.
+// Commit publishes the data written into the slice to readers, and Abort discards it.
+// If the free space wraps around the end of the backing array,
+// the buffered data is first moved in place so n contiguous bytes are free.
+//
+// In blocking mode Reserve waits until n bytes are free,
+// and otherwise it returns ErrIsFull. In overwrite mode the oldest data is evicted.
+// ErrTooMuchDataToWrite is returned if n is larger than the buffer.
+// Only one reservation can be open at a time, ErrReserved is returned otherwise,
+// and there should be no other writer while it is open.
+// Until the reservation is closed, the buffer does not grow,
+// and SwapBuffer, Linearize and Release wait for it.
+func (r *RingBuffer) Reserve(n int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if n < 0 {
+		return nil, ErrInvalidLength
+	}
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	for {
+		if r.reserved != nil {
+			return nil, ErrReserved
+		}
+		if err := r.writeErr(); err != nil {
+			return nil, err
+		}
+		if r.remaining() < int64(n) {
+			return nil, ErrWriteOnClosed
+		}
+		r.grow(n)
+		if n > r.size {
+			return nil, ErrTooMuchDataToWrite
+		}
+		if r.overwrite {
+			r.evict(n - r.free())
+		}
+		if r.free() < n {
+			r.stalls++
+			if !r.block {
+				return nil, ErrIsFull
+			}
+			if err := r.waitReadDeadline(nil); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if a, _ := r.writable(); len(a) >= n {
+			break
+		}
+		if r.lent > 0 {
+			r.waitUnlent()
+			continue
+		}
+		r.moveToStart(r.r)
+	}
+	r.reserved = r.buf[r.w : r.w+n : r.w+n]
+	r.lend()
+	return r.reserved, nil
+}
+
+// Commit publishes the first n bytes of the reserved slice to readers
+// and closes the reservation.
+// ErrInvalidLength is returned, and the reservation is left open,
+// if n is more than was reserved.
+func (r *RingBuffer) Commit(n int) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.reserved == nil {
+		return ErrNotReserved
+	}
+	if n < 0 || n > len(r.reserved) {
+		return ErrInvalidLength
+	}
+	r.reserved = nil
+	r.unlend()
+	if err := r.writeErr(); err != nil {
+		return err
+	}
+	r.advanceWrite(n)
+	if r.block && n > 0 {
+		r.writeCond.Broadcast()
+	}
+	return nil
+}
+
+// Abort closes the reservation without publishing anything.
+func (r *RingBuffer) Abort() error {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.reserved == nil {
+		return ErrNotReserved
+	}
+	r.reserved = nil
+	r.unlend()
+	return nil
+}
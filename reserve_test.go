@@ -0,0 +1,118 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Reserve(t *testing.T) {
+	rb := New(8)
+	p, err := rb.Reserve(4)
+	if err != nil || len(p) != 4 {
+		t.Fatalf("expected 4 bytes, got %d, %v", len(p), err)
+	}
+	if _, err := rb.Reserve(1); err != ErrReserved {
+		t.Fatalf("expected ErrReserved, got %v", err)
+	}
+	copy(p, "abcd")
+	if rb.Length() != 0 {
+		t.Fatalf("expected nothing before Commit, got length %d", rb.Length())
+	}
+	if err := rb.Commit(5); err != ErrInvalidLength {
+		t.Fatalf("expected ErrInvalidLength, got %v", err)
+	}
+	if err := rb.Commit(3); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := rb.Commit(0); err != ErrNotReserved {
+		t.Fatalf("expected ErrNotReserved, got %v", err)
+	}
+	if s := string(rb.Bytes(nil)); s != "abc" {
+		t.Fatalf("expected abc, got %q", s)
+	}
+
+	p, _ = rb.Reserve(2)
+	copy(p, "xy")
+	if err := rb.Abort(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := rb.Abort(); err != ErrNotReserved {
+		t.Fatalf("expected ErrNotReserved, got %v", err)
+	}
+	if rb.Length() != 3 {
+		t.Fatalf("expected length 3 after Abort, got %d", rb.Length())
+	}
+}
+
+func TestRingBuffer_ReserveContiguous(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 5))
+
+	// 7 bytes are free, but only 2 at the end of the backing array.
+	p, err := rb.Reserve(7)
+	if err != nil || len(p) != 7 {
+		t.Fatalf("expected 7 bytes, got %d, %v", len(p), err)
+	}
+	copy(p, "ghijklm")
+	rb.Commit(7)
+	buf := make([]byte, 8)
+	n, _ := rb.Read(buf)
+	if !bytes.Equal(buf[:n], []byte("fghijklm")) {
+		t.Fatalf("expected fghijklm, got %q", buf[:n])
+	}
+}
+
+func TestRingBuffer_ReserveFull(t *testing.T) {
+	rb := New(4)
+	rb.Write([]byte("abc"))
+	if _, err := rb.Reserve(2); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	if _, err := rb.Reserve(5); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+
+	rb = New(4).SetOverwrite(true)
+	rb.Write([]byte("abc"))
+	p, err := rb.Reserve(3)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	copy(p, "def")
+	rb.Commit(3)
+	if s := string(rb.Bytes(nil)); s != "cdef" {
+		t.Fatalf("expected cdef, got %q", s)
+	}
+}
+
+func TestRingBuffer_ReserveBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true)
+	rb.Write([]byte("abcd"))
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.Read(make([]byte, 2))
+	}()
+	p, err := rb.Reserve(2)
+	if err != nil || len(p) != 2 {
+		t.Fatalf("expected 2 bytes, got %d, %v", len(p), err)
+	}
+	copy(p, "ef")
+	rb.Commit(2)
+	if s := string(rb.Bytes(nil)); s != "cdef" {
+		t.Fatalf("expected cdef, got %q", s)
+	}
+
+	// Reset drops the reservation.
+	rb.Read(make([]byte, 4))
+	rb.Reserve(2)
+	rb.Reset()
+	if err := rb.Commit(2); err != ErrNotReserved {
+		t.Fatalf("expected ErrNotReserved, got %v", err)
+	}
+	if _, err := rb.SwapBuffer(make([]byte, 8)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrReset is returned by operations that were aborted by Reset.
+	// It is temporary, since the operation can be retried on the reset buffer,
+	// which tells it apart from errors that closed the ring buffer.
+	ErrReset error = &ringError{msg: "reset called", temporary: true}
+
+	// ErrInFlight is returned by ResetWith in ResetFail mode when operations are in flight.
+	ErrInFlight = errors.New("operations in flight")
+)
+
+// ResetMode controls how ResetWith handles operations in flight.
+type ResetMode int
+
+const (
+	// ResetAbort aborts operations in flight with ErrReset
+	// and waits for them to return. This is the behavior of Reset.
+	ResetAbort ResetMode = iota
+	// ResetWait waits for operations in flight to complete normally.
+	ResetWait
+	// ResetFail returns ErrInFlight if operations are in flight.
+	ResetFail
+)
+
+// OpKind is the kind of an operation aborted by Reset.
+type OpKind int
+
+const (
+	// OpRead is a read, including WriteTo and the iterators.
+	OpRead OpKind = iota
+	// OpWrite is a write, including ReadFrom and Reserve.
+	OpWrite
+	// OpFlush is a wait for buffered data to be read, by Flush or WaitConsumed.
+	OpFlush
+
+	numOpKinds = iota
+)
+
+// String returns "read", "write" or "flush".
+func (k OpKind) String() string {
+	switch k {
+	case OpRead:
+		return "read"
+	case OpWrite:
+		return "write"
+	case OpFlush:
+		return "flush"
+	}
+	return "unknown"
+}
+
+// begin registers an operation that may wait or unlock the ring buffer,
+// so Reset can wait for it.
+// Must be called when locked.
+func (r *RingBuffer) begin(kind OpKind) {
+	r.inFlight++
+	r.ops[kind]++
+}
+
+// end unregisters an operation registered with begin.
+// Must be called when locked.
+func (r *RingBuffer) end(kind OpKind) {
+	r.inFlight--
+	r.ops[kind]--
+	if r.inFlight == 0 && r.idle != nil {
+		r.idle.Broadcast()
+	}
+}
+
+// waitIdle waits until no operations are in flight.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) waitIdle() {
+	if r.idle == nil {
+		r.idle = sync.NewCond(&r.mu)
+	}
+	for r.inFlight > 0 {
+		r.idle.Wait()
+	}
+}
+
+// inFlightOps returns the kinds of the operations in flight,
+// one entry per operation.
+// Must be called when locked.
+func (r *RingBuffer) inFlightOps() []OpKind {
+	if r.inFlight == 0 {
+		return nil
+	}
+	ops := make([]OpKind, 0, r.inFlight)
+	for k, n := range r.ops {
+		for i := 0; i < n; i++ {
+			ops = append(ops, OpKind(k))
+		}
+	}
+	return ops
+}
@@ -0,0 +1,125 @@
+package ringbuffer
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_ResetWith(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(10).SetBlocking(true)
+
+	startRead := func() chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := rb.Read(make([]byte, 1))
+			done <- err
+		}()
+		for {
+			rb.mu.Lock()
+			n := rb.inFlight
+			rb.mu.Unlock()
+			if n > 0 {
+				return done
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Fail.
+	done := startRead()
+	rb.Write([]byte("ab"))
+	<-done
+	if err := rb.ResetWith(ResetFail); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	done = startRead()
+	if err := rb.ResetWith(ResetFail); err != ErrInFlight {
+		t.Fatalf("expected ErrInFlight, got %v", err)
+	}
+
+	// Wait.
+	reset := make(chan error)
+	go func() {
+		reset <- rb.ResetWith(ResetWait)
+	}()
+	select {
+	case <-reset:
+		t.Fatalf("reset returned with operations in flight")
+	case <-time.After(10 * time.Millisecond):
+	}
+	rb.Write([]byte("abc"))
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-reset; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !rb.IsEmpty() {
+		t.Fatalf("expect IsEmpty is true but got false")
+	}
+
+	// Abort.
+	done = startRead()
+	if err := rb.ResetWith(ResetAbort); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := <-done; !errors.Is(err, ErrReset) {
+		t.Fatalf("expected ErrReset, got %v", err)
+	}
+	done = startRead()
+	rb.Reset()
+	if err := <-done; err != ErrReset {
+		t.Fatalf("expected ErrReset, got %v", err)
+	}
+}
+
+func TestRingBuffer_ResetReport(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(2).SetBlocking(true)
+	rb.Write([]byte("ab"))
+
+	done := make(chan error, 2)
+	go func() {
+		_, err := rb.Write([]byte("c"))
+		done <- err
+	}()
+	go func() {
+		done <- rb.Flush()
+	}()
+	for {
+		rb.mu.Lock()
+		n := rb.inFlight
+		rb.mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	aborted, err := rb.ResetReport(ResetAbort)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(aborted) != 2 || aborted[0] != OpWrite || aborted[1] != OpFlush {
+		t.Fatalf("expected a write and a flush, got %v", aborted)
+	}
+	for i := 0; i < 2; i++ {
+		err := <-done
+		if !errors.Is(err, ErrReset) {
+			t.Fatalf("expected ErrReset, got %v", err)
+		}
+		if tmp, ok := err.(interface{ Temporary() bool }); !ok || !tmp.Temporary() {
+			t.Fatalf("expected %v to be temporary", err)
+		}
+	}
+
+	aborted, err = rb.ResetReport(ResetAbort)
+	if err != nil || aborted != nil {
+		t.Fatalf("expected nothing aborted, got %v, %v", aborted, err)
+	}
+	if s := OpRead.String(); s != "read" {
+		t.Fatalf("expected read, got %s", s)
+	}
+}
@@ -0,0 +1,412 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"io"
+	"sync"
+)
+
+// Ring is a circular queue of elements of type T.
+// It is the element counterpart of RingBuffer: Write and Read copy elements
+// instead of bytes and follow the same blocking and error semantics.
+// It is safe to concurrently read and write a Ring.
+type Ring[T any] struct {
+	mu        sync.Mutex
+	buf       []T
+	r         int // next position to read
+	w         int // next position to write
+	isFull    bool
+	err       error
+	block     bool
+	overwrite bool       // Writes evict the oldest elements instead of failing when full.
+	resets    int        // Number of calls of Reset, so blocked reads and writes can tell.
+	readCond  *sync.Cond // Signaled when elements have been read.
+	writeCond *sync.Cond // Signaled when elements have been written.
+}
+
+// NewRing returns a new Ring whose capacity is size elements.
+// A ring of size 0 or less has no room, and writes to it fail with ErrTooMuchDataToWrite,
+// also in blocking mode.
+func NewRing[T any](size int) *Ring[T] {
+	if size < 0 {
+		size = 0
+	}
+	return &Ring[T]{buf: make([]T, size)}
+}
+
+// SetBlocking sets the blocking mode of the ring.
+// If block is true, Read and Write will block when there are no elements to read or no space to write.
+// If block is false, Read and Write will return ErrIsEmpty or ErrIsFull immediately.
+// By default, the ring is not blocking.
+// This setting should be called before any Read or Write operation or after a Reset.
+func (q *Ring[T]) SetBlocking(block bool) *Ring[T] {
+	q.block = block
+	if block {
+		q.readCond = sync.NewCond(&q.mu)
+		q.writeCond = sync.NewCond(&q.mu)
+	}
+	return q
+}
+
+// SetOverwrite sets whether writes evict the oldest unread elements when the ring is full,
+// instead of blocking or returning ErrIsFull or ErrTooMuchDataToWrite.
+// If a single write is larger than the ring, only its last elements are kept.
+func (q *Ring[T]) SetOverwrite(overwrite bool) *Ring[T] {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.overwrite = overwrite
+	return q
+}
+
+// setErr records err if it is not transient, like RingBuffer.setErr.
+// Must be called when locked.
+func (q *Ring[T]) setErr(err error) error {
+	if q.err != nil && q.err != io.EOF {
+		return q.err
+	}
+	switch err {
+	// Internal errors are transient
+	case nil, ErrIsEmpty, ErrIsFull, ErrAcquireLock, ErrTooMuchDataToWrite, ErrWriteOnClosed:
+		return err
+	default:
+		q.err = err
+		if q.block {
+			q.readCond.Broadcast()
+			q.writeCond.Broadcast()
+		}
+	}
+	return err
+}
+
+// readErr returns the error a read should fail with, if any.
+// Must be called when locked.
+func (q *Ring[T]) readErr() error {
+	if q.err == io.EOF && q.length() > 0 {
+		return nil
+	}
+	return q.err
+}
+
+// writeErr returns the error a write should fail with, if any.
+// Must be called when locked.
+func (q *Ring[T]) writeErr() error {
+	if q.err == io.EOF {
+		return ErrWriteOnClosed
+	}
+	return q.err
+}
+
+// Read reads up to len(p) elements into p. It returns the number of elements read (0 <= n <= len(p))
+// and any error encountered.
+// When the ring is empty, it waits for elements in blocking mode,
+// and returns ErrIsEmpty otherwise.
+// A waiting Read returns ErrReset if the ring is reset.
+// Read elements are cleared in the ring, so they can be garbage collected.
+func (q *Ring[T]) Read(p []T) (n int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(p) == 0 {
+		return 0, q.readErr()
+	}
+	resets := q.resets
+	for {
+		if err := q.readErr(); err != nil {
+			return 0, err
+		}
+		n, err = q.read(p)
+		if err == ErrIsEmpty && q.block {
+			q.writeCond.Wait()
+			if q.resets != resets {
+				return 0, ErrReset
+			}
+			continue
+		}
+		break
+	}
+	if q.block && n > 0 {
+		q.readCond.Broadcast()
+	}
+	return n, err
+}
+
+// TryRead reads like Read, but returns ErrAcquireLock
+// instead of waiting if the lock cannot be acquired,
+// and never waits for elements.
+func (q *Ring[T]) TryRead(p []T) (n int, err error) {
+	if !q.mu.TryLock() {
+		return 0, ErrAcquireLock
+	}
+	defer q.mu.Unlock()
+	if err := q.readErr(); err != nil {
+		return 0, err
+	}
+	n, err = q.read(p)
+	if q.block && n > 0 {
+		q.readCond.Broadcast()
+	}
+	return n, err
+}
+
+// read copies elements into p and clears them in the ring.
+// Must be called when locked.
+func (q *Ring[T]) read(p []T) (n int, err error) {
+	if q.length() == 0 {
+		return 0, ErrIsEmpty
+	}
+	var zero T
+	for n < len(p) && q.length() > 0 {
+		p[n] = q.buf[q.r]
+		q.buf[q.r] = zero
+		q.r++
+		if q.r == len(q.buf) {
+			q.r = 0
+		}
+		q.isFull = false
+		n++
+	}
+	return n, nil
+}
+
+// ReadOne reads and returns the next element.
+// When the ring is empty, it waits for an element in blocking mode,
+// and returns ErrIsEmpty otherwise.
+func (q *Ring[T]) ReadOne() (v T, err error) {
+	var p [1]T
+	_, err = q.Read(p[:])
+	return p[0], err
+}
+
+// TryReadOne reads the next element like ReadOne,
+// but returns ErrAcquireLock instead of waiting if the lock cannot be acquired,
+// and ErrIsEmpty if the ring is empty.
+func (q *Ring[T]) TryReadOne() (v T, err error) {
+	var p [1]T
+	_, err = q.TryRead(p[:])
+	return p[0], err
+}
+
+// Peek copies up to len(p) elements into p without removing them from the ring.
+// It returns ErrIsEmpty if the ring is empty.
+func (q *Ring[T]) Peek(p []T) (n int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.readErr(); err != nil {
+		return 0, err
+	}
+	if q.length() == 0 {
+		return 0, ErrIsEmpty
+	}
+	for i := q.r; n < len(p) && n < q.length(); n++ {
+		p[n] = q.buf[i]
+		i++
+		if i == len(q.buf) {
+			i = 0
+		}
+	}
+	return n, nil
+}
+
+// Write writes len(p) elements from p to the ring.
+// It returns the number of elements written from p (0 <= n <= len(p))
+// and any error encountered that caused the write to stop early.
+// In blocking mode it waits until all elements have been written,
+// and returns ErrReset if the ring is reset while waiting.
+// Otherwise it writes what fits and returns ErrIsFull if the ring is full,
+// or ErrTooMuchDataToWrite if not all elements fit.
+// In overwrite mode the oldest elements are evicted instead, see SetOverwrite.
+func (q *Ring[T]) Write(p []T) (n int, err error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if err := q.writeErr(); err != nil {
+		return 0, err
+	}
+	resets := q.resets
+	for {
+		m, err := q.write(p[n:])
+		n += m
+		if q.block && (err == ErrIsFull || err == ErrTooMuchDataToWrite) && len(q.buf) > 0 {
+			if m > 0 {
+				q.writeCond.Broadcast()
+			}
+			q.readCond.Wait()
+			if q.resets != resets {
+				return n, ErrReset
+			}
+			if err := q.writeErr(); err != nil {
+				return n, err
+			}
+			continue
+		}
+		if q.block && n > 0 {
+			q.writeCond.Broadcast()
+		}
+		return n, err
+	}
+}
+
+// TryWrite writes like Write, but returns ErrAcquireLock
+// instead of waiting if the lock cannot be acquired,
+// and never waits for free space.
+func (q *Ring[T]) TryWrite(p []T) (n int, err error) {
+	if !q.mu.TryLock() {
+		return 0, ErrAcquireLock
+	}
+	defer q.mu.Unlock()
+	if err := q.writeErr(); err != nil {
+		return 0, err
+	}
+	n, err = q.write(p)
+	if q.block && n > 0 {
+		q.writeCond.Broadcast()
+	}
+	return n, err
+}
+
+// write copies elements from p into the free space.
+// Must be called when locked.
+func (q *Ring[T]) write(p []T) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if len(q.buf) == 0 {
+		return 0, ErrTooMuchDataToWrite
+	}
+	if q.overwrite {
+		if len(p) > len(q.buf) {
+			// Only the last elements are kept.
+			n = len(p) - len(q.buf)
+		}
+		var zero T
+		for evict := len(p) - n - (len(q.buf) - q.length()); evict > 0; evict-- {
+			q.buf[q.r] = zero
+			q.r++
+			if q.r == len(q.buf) {
+				q.r = 0
+			}
+			q.isFull = false
+		}
+	}
+	if q.isFull {
+		return 0, ErrIsFull
+	}
+	for n < len(p) && !q.isFull {
+		q.buf[q.w] = p[n]
+		q.w++
+		if q.w == len(q.buf) {
+			q.w = 0
+		}
+		q.isFull = q.w == q.r
+		n++
+	}
+	if n < len(p) {
+		return n, ErrTooMuchDataToWrite
+	}
+	return n, nil
+}
+
+// WriteOne writes a single element to the ring.
+// When the ring is full, it waits for space in blocking mode,
+// and returns ErrIsFull otherwise.
+func (q *Ring[T]) WriteOne(v T) error {
+	p := [1]T{v}
+	_, err := q.Write(p[:])
+	return err
+}
+
+// TryWriteOne writes a single element like WriteOne,
+// but returns ErrAcquireLock instead of waiting if the lock cannot be acquired,
+// and ErrIsFull if the ring is full.
+func (q *Ring[T]) TryWriteOne(v T) error {
+	p := [1]T{v}
+	_, err := q.TryWrite(p[:])
+	return err
+}
+
+// Length returns the number of elements that can be read.
+func (q *Ring[T]) Length() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.length()
+}
+
+// length returns the number of elements that can be read.
+// Must be called when locked.
+func (q *Ring[T]) length() int {
+	switch {
+	case q.isFull:
+		return len(q.buf)
+	case q.w >= q.r:
+		return q.w - q.r
+	default:
+		return len(q.buf) - q.r + q.w
+	}
+}
+
+// Capacity returns the size of the ring in elements.
+func (q *Ring[T]) Capacity() int {
+	return len(q.buf)
+}
+
+// Free returns the number of elements that can be written without blocking.
+func (q *Ring[T]) Free() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.buf) - q.length()
+}
+
+// IsFull returns true when the ring is full.
+func (q *Ring[T]) IsFull() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.isFull
+}
+
+// IsEmpty returns true when the ring is empty.
+func (q *Ring[T]) IsEmpty() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.length() == 0
+}
+
+// CloseWithError closes the ring with err.
+// Reads and writes will return err, or, if err is nil,
+// reads will return the remaining elements and io.EOF.
+//
+// CloseWithError never overwrites the previous error if it exists.
+func (q *Ring[T]) CloseWithError(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.setErr(err)
+}
+
+// CloseWriter closes the writer.
+// Reads will return any remaining elements and io.EOF.
+func (q *Ring[T]) CloseWriter() {
+	q.CloseWithError(nil)
+}
+
+// Reset removes all elements and clears any error.
+// Blocked reads and writes return ErrReset.
+func (q *Ring[T]) Reset() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var zero T
+	for i := range q.buf {
+		q.buf[i] = zero
+	}
+	q.r = 0
+	q.w = 0
+	q.isFull = false
+	q.err = nil
+	q.resets++
+	if q.block {
+		q.readCond.Broadcast()
+		q.writeCond.Broadcast()
+	}
+}
@@ -5,10 +5,15 @@
 package ringbuffer
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"hash"
 	"io"
+	"runtime/pprof"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 )
@@ -18,16 +23,16 @@ var (
 	ErrTooMuchDataToWrite = errors.New("too much data to write")
 
 	// ErrIsFull is returned when the buffer is full and not blocking.
-	ErrIsFull = errors.New("ringbuffer is full")
+	ErrIsFull error = &ringError{msg: "ringbuffer is full", temporary: true}
 
 	// ErrIsEmpty is returned when the buffer is empty and not blocking.
-	ErrIsEmpty = errors.New("ringbuffer is empty")
+	ErrIsEmpty error = &ringError{msg: "ringbuffer is empty", temporary: true}
 
 	// ErrIsNotEmpty is returned when the buffer is not empty and not blocking.
 	ErrIsNotEmpty = errors.New("ringbuffer is not empty")
 
 	// ErrAcquireLock is returned when the lock is not acquired on Try operations.
-	ErrAcquireLock = errors.New("unable to acquire lock")
+	ErrAcquireLock error = &ringError{msg: "unable to acquire lock", temporary: true}
 
 	// ErrWriteOnClosed is returned when write on a closed ringbuffer.
 	ErrWriteOnClosed = errors.New("write on closed ringbuffer")
@@ -40,6 +45,9 @@ var (
 // It operates like a buffered pipe, where data is written to a RingBuffer
 // and can be read back from another goroutine.
 // It is safe to concurrently read and write RingBuffer.
+//
+// Read, Write, ReadByte, WriteByte, WriteString, Peek and their Try variants
+// do not allocate. Only waiting with a read or write timeout allocates a timer.
 type RingBuffer struct {
 	buf       []byte
 	size      int
@@ -48,12 +56,63 @@ type RingBuffer struct {
 	isFull    bool
 	err       error
 	block     bool
-	rTimeout  time.Duration // Applies to writes (waits for the read condition)
-	wTimeout  time.Duration // Applies to read (wait for the write condition)
-	mu        sync.Mutex
-	wg        sync.WaitGroup
-	readCond  *sync.Cond // Signaled when data has been read.
-	writeCond *sync.Cond // Signaled when data has been written.
+	rTimeout  time.Duration   // Applies to writes (waits for the read condition)
+	wTimeout  time.Duration   // Applies to read (wait for the write condition)
+	mu        sync.RWMutex    // Read-locked by methods that only inspect the buffer.
+	inFlight  int             // Operations that may wait or unlock.
+	ops       [numOpKinds]int // Operations in flight by kind.
+	idle      *sync.Cond      // Signaled when no operations are in flight.
+	readCond  *sync.Cond      // Signaled when data has been read.
+	writeCond *sync.Cond      // Signaled when data has been written.
+	wHash     hash.Hash       // Running hash of written data, if set.
+	rHash     hash.Hash       // Running hash of read data, if set.
+	highWater int             // Highest number of buffered bytes seen.
+	stalls    int64           // Number of writes that found the buffer full.
+	usage     usage           // Usage since the size last changed, see RecommendedCapacity.
+	sealed    bool            // Writes are rejected with ErrSealed.
+	sealR     int             // Read position when sealed.
+	sealLen   int             // Buffered bytes when sealed.
+	written   int64           // Total bytes written, the absolute offset of w.
+	pending   []Range         // Out-of-order data beyond w, sorted.
+	acct      Accountant      // Notified of memory allocations, if set.
+	limit     int64           // Total bytes after which the writer is closed, if > 0.
+	times     bool            // Whether lastRead, lastWrite and unread are kept, see LastRead.
+	lastRead  time.Time       // Time of the last read.
+	lastWrite time.Time       // Time of the last write.
+	unread    time.Time       // Time the buffer became non-empty, zero if empty.
+	lent      int             // Number of unlocked reads and writes using buf.
+	wipe      bool            // Zero consumed data.
+	maxSize   int             // Size up to which writes grow buf, if larger than size.
+	overwrite bool            // Writes evict the oldest data instead of failing when full.
+	name      string
+	rLabels   *pprof.LabelSet // Profiler labels of goroutines waiting for a read, if named.
+	wLabels   *pprof.LabelSet // Profiler labels of goroutines waiting for a write, if named.
+
+	onOverwrite  func(evicted []byte) // Called with data evicted in overwrite mode, if set.
+	line         []byte               // Data returned by ReadSlice, reused by the next call.
+	readers      []*BroadcastReader   // Attached broadcast readers.
+	atomicWrites bool                 // Writes are written completely or not at all.
+	mapped       []byte               // Memory-mapped file holding a header and buf, if set.
+	wm           *watermarks          // Thresholds and callbacks of SetWatermarks, if set.
+	readableC    chan struct{}        // Notified when data is written, if set.
+	writableC    chan struct{}        // Notified when data is read, if set.
+	ctr          counters             // Cumulative statistics, not cleared by Reset.
+	hooks        *hookState           // Hooks set with SetHooks and their pending events, if set.
+	reserved     []byte               // Free space handed out by Reserve, until Commit or Abort.
+	unreadable   int                  // Bytes of the last read that UnreadByte can push back.
+	runeSize     int                  // Size of the rune if the last read was a ReadRune.
+	enc          *encryption          // Encryption set with SetEncryptionKey, if set.
+	checksum     bool                 // Whether records carry a checksum.
+	ages         *ageState            // Maximum age and write times of SetMaxAge, if set.
+	onExpire     func(expired []byte) // Called with data dropped by SetMaxAge, if set.
+	wTee, rTee   io.Writer            // Tees set with TeeWrites and TeeReads, if set.
+	rClosed      error                // Error of CloseRead, which writes fail with, if set.
+	writeToChunk int                  // Maximum size of a write of WriteTo, if > 0.
+	watch        chan struct{}        // Closed when data is written, if ServeHTTP waits for it.
+	faults       *faultState          // Faults injected by SetFaults, if set.
+	publishing   atomic.Bool          // Whether nLength and nFree are kept, once Length or Free is used.
+	nLength      atomic.Int64         // Complement of length() when last unlocked, or 0 if never.
+	nFree        atomic.Int64         // Complement of free() when last unlocked, or 0 if never.
 }
 
 // New returns a new RingBuffer whose buffer has the given size.
@@ -107,7 +166,7 @@ func (r *RingBuffer) WithTimeout(d time.Duration) *RingBuffer {
 	r.mu.Lock()
 	r.rTimeout = d
 	r.wTimeout = d
-	r.mu.Unlock()
+	r.unlock()
 	return r
 }
 
@@ -121,7 +180,7 @@ func (r *RingBuffer) WithReadTimeout(d time.Duration) *RingBuffer {
 	// Read operations wait for writes to complete,
 	// therefore we set the wTimeout.
 	r.wTimeout = d
-	r.mu.Unlock()
+	r.unlock()
 	return r
 }
 
@@ -135,29 +194,40 @@ func (r *RingBuffer) WithWriteTimeout(d time.Duration) *RingBuffer {
 	// Write operations wait for reads to complete,
 	// therefore we set the rTimeout.
 	r.rTimeout = d
-	r.mu.Unlock()
+	r.unlock()
 	return r
 }
 
 func (r *RingBuffer) setErr(err error, locked bool) error {
 	if !locked {
 		r.mu.Lock()
-		defer r.mu.Unlock()
+		defer r.unlock()
 	}
 	if r.err != nil && r.err != io.EOF {
 		return r.err
 	}
+	if err != nil && err == r.rClosed {
+		// Writes failing after CloseRead do not close the read side.
+		return err
+	}
 
 	switch err {
 	// Internal errors are transient
-	case nil, ErrIsEmpty, ErrIsFull, ErrAcquireLock, ErrTooMuchDataToWrite, ErrIsNotEmpty:
+	case nil, ErrIsEmpty, ErrIsFull, ErrAcquireLock, ErrTooMuchDataToWrite, ErrIsNotEmpty, ErrSealed, ErrWriteOnClosed:
 		return err
 	default:
 		r.err = err
+		r.wipeClosed()
 		if r.block {
 			r.readCond.Broadcast()
 			r.writeCond.Broadcast()
 		}
+		notify(r.readableC)
+		notify(r.writableC)
+		r.wakeWatchers()
+		if err != ErrReset {
+			r.addHookEvent(evClose, 0, err)
+		}
 	}
 	return err
 }
@@ -165,8 +235,9 @@ func (r *RingBuffer) setErr(err error, locked bool) error {
 func (r *RingBuffer) readErr(locked bool) error {
 	if !locked {
 		r.mu.Lock()
-		defer r.mu.Unlock()
+		defer r.unlock()
 	}
+	r.expire()
 	if r.err != nil {
 		if r.err == io.EOF {
 			if r.w == r.r && !r.isFull {
@@ -176,6 +247,30 @@ func (r *RingBuffer) readErr(locked bool) error {
 		}
 		return r.err
 	}
+	if (r.sealed || r.rClosed != nil) && r.w == r.r && !r.isFull {
+		return io.EOF
+	}
+	return nil
+}
+
+// writeErr returns the error a write should fail with, if any.
+// Must be called when locked.
+func (r *RingBuffer) writeErr() error {
+	if err := r.err; err != nil {
+		if err == io.EOF {
+			err = ErrWriteOnClosed
+		}
+		return err
+	}
+	if r.rClosed != nil {
+		return r.rClosed
+	}
+	if r.sealed {
+		return ErrSealed
+	}
+	if len(r.pending) > 0 {
+		return ErrOutOfOrderPending
+	}
 	return nil
 }
 
@@ -192,13 +287,18 @@ func (r *RingBuffer) Read(p []byte) (n int, err error) {
 	}
 
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	defer r.unlock()
+	if r.faults != nil {
+		if p, err = r.faultRead(p); err != nil {
+			return 0, err
+		}
+	}
 	if err := r.readErr(true); err != nil {
 		return 0, err
 	}
 
-	r.wg.Add(1)
-	defer r.wg.Done()
+	r.begin(OpRead)
+	defer r.end(OpRead)
 	n, err = r.read(p)
 	for err == ErrIsEmpty && r.block {
 		if !r.waitWrite() {
@@ -215,6 +315,53 @@ func (r *RingBuffer) Read(p []byte) (n int, err error) {
 	return n, err
 }
 
+// ReadFull reads exactly len(p) bytes into p.
+// In blocking mode it waits until len(p) bytes have been read,
+// bounded by the read timeout. If they fit in the buffer,
+// it waits until they are all buffered and reads them at once.
+// If the writer is closed before len(p) bytes are read, ReadFull returns the bytes read
+// and io.ErrUnexpectedEOF, or io.EOF if no bytes were read, like io.ReadFull.
+// If not blocking ErrIsEmpty is returned, and nothing is read,
+// if fewer than len(p) bytes are buffered.
+func (r *RingBuffer) ReadFull(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	r.mu.Lock()
+	defer r.unlock()
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	for n < len(p) {
+		if err = r.readErr(true); err != nil {
+			break
+		}
+		need, avail := len(p)-n, r.length()
+		closed := r.err == io.EOF || r.sealed
+		// Read in parts if p does not fit in the buffer.
+		if avail >= need || closed || (r.block && need > r.size && avail > 0) {
+			m, _ := r.read(p[n:])
+			n += m
+			if r.block && m > 0 {
+				r.readCond.Broadcast()
+			}
+			continue
+		}
+		if !r.block {
+			return n, ErrIsEmpty
+		}
+		if !r.waitWrite() {
+			return n, context.DeadlineExceeded
+		}
+	}
+	if n == len(p) {
+		return n, nil
+	}
+	if err == io.EOF && n > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
 // TryRead read up to len(p) bytes into p like Read, but it is never blocking.
 // If it does not succeed to acquire the lock, it returns ErrAcquireLock.
 func (r *RingBuffer) TryRead(p []byte) (n int, err error) {
@@ -222,7 +369,7 @@ func (r *RingBuffer) TryRead(p []byte) (n int, err error) {
 	if !ok {
 		return 0, ErrAcquireLock
 	}
-	defer r.mu.Unlock()
+	defer r.unlock()
 	if err := r.readErr(true); err != nil {
 		return 0, err
 	}
@@ -239,6 +386,7 @@ func (r *RingBuffer) TryRead(p []byte) (n int, err error) {
 
 func (r *RingBuffer) read(p []byte) (n int, err error) {
 	if r.w == r.r && !r.isFull {
+		r.ctr.emptyHits++
 		return 0, ErrIsEmpty
 	}
 
@@ -248,7 +396,11 @@ func (r *RingBuffer) read(p []byte) (n int, err error) {
 			n = len(p)
 		}
 		copy(p, r.buf[r.r:r.r+n])
+		r.decrypt(p[:n], r.consumed())
+		r.wipeRead(n)
 		r.r = (r.r + n) % r.size
+		r.hashRead(p[:n])
+		r.markRead(n)
 		return
 	}
 
@@ -265,17 +417,55 @@ func (r *RingBuffer) read(p []byte) (n int, err error) {
 		c2 := n - c1
 		copy(p[c1:], r.buf[0:c2])
 	}
+	r.decrypt(p[:n], r.consumed())
+	r.wipeRead(n)
 	r.r = (r.r + n) % r.size
-
 	r.isFull = false
+	r.hashRead(p[:n])
+	r.markRead(n)
 
 	return n, r.readErr(true)
 }
 
+// discard consumes the next n buffered bytes without copying them.
+// n must not exceed the buffered length.
+// Must be called when locked.
+func (r *RingBuffer) discard(n int) {
+	if n <= 0 {
+		return
+	}
+	if r.rHash != nil || r.rTee != nil {
+		if r.enc != nil {
+			r.hashRead(r.plain(r.r, n, r.consumed()))
+		} else if r.r+n <= r.size {
+			r.hashRead(r.buf[r.r : r.r+n])
+		} else {
+			r.hashRead(r.buf[r.r:])
+			r.hashRead(r.buf[:n-(r.size-r.r)])
+		}
+	}
+	r.wipeRead(n)
+	r.r = (r.r + n) % r.size
+	r.isFull = false
+	r.markRead(n)
+	if r.block {
+		r.readCond.Broadcast()
+	}
+}
+
 // Returns true if a read may have happened.
 // Returns false if waited longer than rTimeout.
 // Must be called when locked and returns locked.
 func (r *RingBuffer) waitRead() (ok bool) {
+	// Publish the progress of the operation to Length and Free while waiting.
+	r.publish()
+	if r.hookBlock(BlockWrite) {
+		return true
+	}
+	defer r.addBlocked(&r.ctr.writeBlocked, time.Now())
+	if r.rLabels != nil {
+		defer label(r.rLabels)()
+	}
 	if r.rTimeout <= 0 {
 		r.readCond.Wait()
 		return true
@@ -291,14 +481,60 @@ func (r *RingBuffer) waitRead() (ok bool) {
 	return true
 }
 
+// wakeReadOnDone wakes writers waiting for a read when ctx is done,
+// so waits on readCond can be bounded by ctx instead of the read timeout.
+// The returned function must be called when waiting is over.
+// Must be called when locked.
+func (r *RingBuffer) wakeReadOnDone(ctx context.Context) (stop func()) {
+	return r.wakeOnDone(ctx, r.readCond, r.rLabels)
+}
+
+// wakeOnDone broadcasts c when ctx is done and, if labels is not nil,
+// adds labels to the profiler labels of ctx for the goroutine, like pprof.Do.
+// The returned function must be called when waiting is over,
+// and restores the labels of ctx.
+// Must be called when locked.
+func (r *RingBuffer) wakeOnDone(ctx context.Context, c *sync.Cond, labels *pprof.LabelSet) (stop func()) {
+	var unlabel func()
+	if labels != nil {
+		pprof.SetGoroutineLabels(pprof.WithLabels(ctx, *labels))
+		unlabel = func() { pprof.SetGoroutineLabels(ctx) }
+	}
+	if ctx.Done() == nil {
+		if unlabel == nil {
+			return func() {}
+		}
+		return unlabel
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.mu.Lock()
+			c.Broadcast()
+			r.unlock()
+		case <-done:
+		}
+	}()
+	return func() {
+		close(done)
+		if unlabel != nil {
+			unlabel()
+		}
+	}
+}
+
 // ReadByte reads and returns the next byte from the input or ErrIsEmpty.
 func (r *RingBuffer) ReadByte() (b byte, err error) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	defer r.unlock()
+	r.begin(OpRead)
+	defer r.end(OpRead)
 	if err = r.readErr(true); err != nil {
 		return 0, err
 	}
 	for r.w == r.r && !r.isFull {
+		r.ctr.emptyHits++
 		if r.block {
 			if !r.waitWrite() {
 				return 0, context.DeadlineExceeded
@@ -311,14 +547,52 @@ func (r *RingBuffer) ReadByte() (b byte, err error) {
 		}
 		return 0, ErrIsEmpty
 	}
-	b = r.buf[r.r]
+	b = r.readByte()
+	return b, r.readErr(true)
+}
+
+// TryReadByte reads and returns the next byte like ReadByte, but it is never blocking.
+// If it does not succeed to acquire the lock, it returns ErrAcquireLock.
+func (r *RingBuffer) TryReadByte() (b byte, err error) {
+	ok := r.mu.TryLock()
+	if !ok {
+		return 0, ErrAcquireLock
+	}
+	defer r.unlock()
+	if err = r.readErr(true); err != nil {
+		return 0, err
+	}
+	if r.w == r.r && !r.isFull {
+		r.ctr.emptyHits++
+		return 0, ErrIsEmpty
+	}
+	b = r.readByte()
+	if r.block {
+		r.readCond.Broadcast()
+	}
+	return b, r.readErr(true)
+}
+
+// readByte reads the next byte.
+// The buffer must not be empty.
+// Must be called when locked.
+func (r *RingBuffer) readByte() byte {
+	b := r.buf[r.r]
+	if r.enc != nil {
+		p := r.plain(r.r, 1, r.consumed())
+		b = p[0]
+		r.hashRead(p)
+	} else {
+		r.hashRead(r.buf[r.r : r.r+1])
+	}
+	r.wipeRead(1)
 	r.r++
 	if r.r == r.size {
 		r.r = 0
 	}
-
 	r.isFull = false
-	return b, r.readErr(true)
+	r.markRead(1)
+	return b
 }
 
 // Write writes len(p) bytes from p to the underlying buf.
@@ -332,11 +606,21 @@ func (r *RingBuffer) Write(p []byte) (n int, err error) {
 		return 0, r.setErr(nil, false)
 	}
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	if err := r.err; err != nil {
-		if err == io.EOF {
-			err = ErrWriteOnClosed
+	defer r.unlock()
+	short := false
+	if r.faults != nil {
+		q, err := r.faultWrite(p)
+		if err != nil {
+			return 0, err
 		}
+		p, short = q, len(q) < len(p)
+	}
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	if err := r.waitFree(len(p), r.block, nil); err != nil {
 		return 0, err
 	}
 	wrote := 0
@@ -359,15 +643,169 @@ func (r *RingBuffer) Write(p []byte) (n int, err error) {
 	if r.block && wrote > 0 {
 		r.writeCond.Broadcast()
 	}
+	if short && err == nil {
+		err = ErrTooMuchDataToWrite
+	}
 
 	return wrote, r.setErr(err, true)
 }
 
+// Fill writes n copies of c into the buffer,
+// without the caller having to allocate a slice of n bytes.
+// It returns the number of bytes written and follows the same
+// blocking and error semantics as Write.
+func (r *RingBuffer) Fill(c byte, n int) (wrote int, err error) {
+	if n <= 0 {
+		return 0, r.setErr(nil, false)
+	}
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	for n > 0 {
+		var nw int
+		nw, err = r.fill(c, n)
+		wrote += nw
+		if !r.block || err == nil {
+			break
+		}
+		err = r.setErr(err, true)
+		if err == ErrIsFull || err == ErrTooMuchDataToWrite {
+			r.writeCond.Broadcast()
+			r.waitRead()
+			n -= nw
+			err = nil
+			continue
+		}
+		break
+	}
+	if r.block && wrote > 0 {
+		r.writeCond.Broadcast()
+	}
+
+	return wrote, r.setErr(err, true)
+}
+
+// WriteZeros advances the write pointer over n zeroed bytes.
+// It is useful for producers that need to fill gaps of known length,
+// without materializing a buffer of zeros.
+// It follows the same blocking and error semantics as Write.
+func (r *RingBuffer) WriteZeros(n int) (int, error) {
+	return r.Fill(0, n)
+}
+
+func (r *RingBuffer) fill(c byte, n int) (int, error) {
+	if r.rClosed != nil {
+		return 0, r.rClosed
+	}
+	if r.sealed {
+		return 0, ErrSealed
+	}
+	r.grow(n)
+	if r.isFull {
+		r.stalls++
+		return 0, ErrIsFull
+	}
+	var err error
+	if avail := r.free(); n > avail {
+		r.stalls++
+		err = ErrTooMuchDataToWrite
+		n = avail
+	}
+	if rem := r.remaining(); int64(n) > rem {
+		err = ErrWriteOnClosed
+		n = int(rem)
+	}
+	a, b := r.writable()
+	if len(a) > n {
+		a = a[:n]
+	}
+	b = b[:n-len(a)]
+	for _, seg := range [2][]byte{a, b} {
+		if len(seg) == 0 {
+			continue
+		}
+		// Double the filled part on every copy.
+		seg[0] = c
+		for i := 1; i < len(seg); i *= 2 {
+			copy(seg[i:], seg[:i])
+		}
+	}
+	r.advanceWrite(n)
+	return n, err
+}
+
+// writable returns the free space of the buffer as up to two slices,
+// in the order they should be written.
+// Must be called when locked.
+func (r *RingBuffer) writable() (a, b []byte) {
+	if r.isFull {
+		return nil, nil
+	}
+	if r.w < r.r {
+		return r.buf[r.w:r.r], nil
+	}
+	return r.buf[r.w:], r.buf[:r.r]
+}
+
+// readable returns the buffered data as up to two slices,
+// in the order they should be read.
+// Must be called when locked.
+func (r *RingBuffer) readable() (a, b []byte) {
+	if r.w == r.r && !r.isFull {
+		return nil, nil
+	}
+	if r.r < r.w {
+		return r.buf[r.r:r.w], nil
+	}
+	return r.buf[r.r:], r.buf[:r.w]
+}
+
+// advanceWrite publishes n bytes that have been written
+// directly into the free space of the buffer.
+// n must not exceed the free space.
+// Must be called when locked.
+func (r *RingBuffer) advanceWrite(n int) {
+	if n <= 0 {
+		return
+	}
+	if r.wHash != nil || r.wTee != nil {
+		a, b := r.writable()
+		if len(a) >= n {
+			r.hashWrite(a[:n])
+		} else {
+			r.hashWrite(a)
+			r.hashWrite(b[:n-len(a)])
+		}
+	}
+	r.cryptBuf(r.w, n, r.written)
+	r.w = (r.w + n) % r.size
+	if r.w == r.r {
+		r.isFull = true
+	}
+	r.written += int64(n)
+	r.updateHighWater()
+	r.markWrite(n)
+	r.checkLimit()
+}
+
 // waitWrite will wait for a write event.
 // Returns true if a write may have happened.
 // Returns false if waited longer than wTimeout.
 // Must be called when locked and returns locked.
 func (r *RingBuffer) waitWrite() (ok bool) {
+	// Publish the progress of the operation to Length and Free while waiting.
+	r.publish()
+	if r.hookBlock(BlockRead) {
+		return true
+	}
+	defer r.addBlocked(&r.ctr.readBlocked, time.Now())
+	if r.wLabels != nil {
+		defer label(r.wLabels)()
+	}
 	if r.wTimeout <= 0 {
 		r.writeCond.Wait()
 		return true
@@ -384,9 +822,58 @@ func (r *RingBuffer) waitWrite() (ok bool) {
 	return true
 }
 
+// waitReadUntil waits for a read like waitRead, but is bounded by deadline
+// instead of rTimeout and leaves the ring buffer open when it passes.
+// A zero deadline waits without limit.
+// Returns false without waiting if the deadline has passed.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) waitReadUntil(deadline time.Time) (ok bool) {
+	return r.waitUntil(r.readCond, deadline)
+}
+
+// waitWriteUntil waits for a write like waitWrite, but is bounded by deadline
+// instead of wTimeout and leaves the ring buffer open when it passes.
+// A zero deadline waits without limit.
+// Returns false without waiting if the deadline has passed.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) waitWriteUntil(deadline time.Time) (ok bool) {
+	return r.waitUntil(r.writeCond, deadline)
+}
+
+func (r *RingBuffer) waitUntil(c *sync.Cond, deadline time.Time) bool {
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			return false
+		}
+		defer time.AfterFunc(d, func() {
+			r.mu.Lock()
+			c.Broadcast()
+			r.unlock()
+		}).Stop()
+	}
+	r.publish()
+	kind := BlockRead
+	if c == r.readCond {
+		kind = BlockWrite
+	}
+	if r.hookBlock(kind) {
+		return true
+	}
+	if c == r.readCond {
+		defer r.addBlocked(&r.ctr.writeBlocked, time.Now())
+	} else {
+		defer r.addBlocked(&r.ctr.readBlocked, time.Now())
+	}
+	c.Wait()
+	return true
+}
+
 // ReadFrom will fulfill the write side of the ringbuffer.
 // This will do writes directly into the buffer,
 // therefore avoiding a mem-copy when using the Write.
+// The unread bytes of a *bytes.Reader, *bytes.Buffer or *strings.Reader
+// are copied directly into the free space, without unlocking.
 //
 // ReadFrom will not automatically close the buffer even after returning.
 // For that call CloseWriter().
@@ -395,25 +882,77 @@ func (r *RingBuffer) waitWrite() (ok bool) {
 // The return value n is the number of bytes read.
 // Any error except EOF encountered during the read is also returned,
 // and the error will cause the Read side to fail as well.
-// ReadFrom only available in blocking mode.
+//
+// If not blocking, ReadFrom fills the available space and returns ErrIsFull
+// when the buffer becomes full before EOF is reached on rd.
+// It never waits for a read to free up space.
 func (r *RingBuffer) ReadFrom(rd io.Reader) (n int64, err error) {
-	if !r.block {
-		return 0, errors.New("RingBuffer: ReadFrom only available in blocking mode")
+	return r.readFrom(rd, -1)
+}
+
+// ReadFromN reads at most n bytes from rd into the buffer, like ReadFrom,
+// so a source of known length can be ingested without reading past it
+// and without closing the ring buffer.
+// io.EOF is returned if rd reaches EOF before n bytes have been read.
+func (r *RingBuffer) ReadFromN(rd io.Reader, n int64) (read int64, err error) {
+	if n <= 0 {
+		return 0, nil
+	}
+	read, err = r.readFrom(rd, n)
+	if err == nil && read < n {
+		err = io.EOF
 	}
+	return read, err
+}
+
+// readFrom reads from rd into the buffer until EOF or error.
+// If limit is not negative at most limit bytes are read.
+func (r *RingBuffer) readFrom(rd io.Reader, limit int64) (n int64, err error) {
 	zeroReads := 0
+	mem := inMemory(rd)
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	for {
+	defer r.unlock()
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	for limit < 0 || n < limit {
+		if err = r.writeErr(); err == ErrSealed || err == ErrOutOfOrderPending || (err != nil && err == r.rClosed) {
+			return n, err
+		}
+		if r.remaining() == 0 {
+			// The limit has been reached.
+			return n, nil
+		}
 		if err = r.readErr(true); err != nil {
 			return n, err
 		}
+		r.grow(1)
 		if r.isFull {
+			r.stalls++
+			if !r.block {
+				return n, ErrIsFull
+			}
 			// Wait for a read
 			if !r.waitRead() {
 				return 0, context.DeadlineExceeded
 			}
 			continue
 		}
+		if mem {
+			// In-memory readers never block, so copy from them without unlocking.
+			max := r.remaining()
+			if limit >= 0 && limit-n < max {
+				max = limit - n
+			}
+			nr, eof := r.copyFromMem(rd.(io.WriterTo), max)
+			n += int64(nr)
+			if r.block && nr > 0 {
+				r.writeCond.Broadcast()
+			}
+			if eof {
+				break
+			}
+			continue
+		}
 
 		var toRead []byte
 		if r.w >= r.r {
@@ -423,12 +962,23 @@ func (r *RingBuffer) ReadFrom(rd io.Reader) (n int64, err error) {
 			// Before reader, read until reader.
 			toRead = r.buf[r.w:r.r]
 		}
+		if rem := r.remaining(); int64(len(toRead)) > rem {
+			toRead = toRead[:rem]
+		}
+		if limit >= 0 && int64(len(toRead)) > limit-n {
+			toRead = toRead[:limit-n]
+		}
 		// Unlock while reading
-		r.mu.Unlock()
+		r.lend()
+		r.unlock()
 		nr, rerr := rd.Read(toRead)
 		r.mu.Lock()
+		r.unlend()
+		if r.released() {
+			return n, ErrReleased
+		}
 		if rerr != nil && rerr != io.EOF {
-			err = r.setErr(err, true)
+			err = r.setErr(rerr, true)
 			break
 		}
 		if nr == 0 && rerr == nil {
@@ -439,13 +989,21 @@ func (r *RingBuffer) ReadFrom(rd io.Reader) (n int64, err error) {
 			continue
 		}
 		zeroReads = 0
+		r.hashWrite(toRead[:nr])
+		r.cryptBuf(r.w, nr, r.written)
 		r.w += nr
 		if r.w == r.size {
 			r.w = 0
 		}
 		r.isFull = r.r == r.w && nr > 0
+		r.written += int64(nr)
+		r.updateHighWater()
+		r.markWrite(nr)
+		r.checkLimit()
 		n += int64(nr)
-		r.writeCond.Broadcast()
+		if r.block {
+			r.writeCond.Broadcast()
+		}
 		if rerr == io.EOF {
 			// We do not close.
 			break
@@ -454,32 +1012,183 @@ func (r *RingBuffer) ReadFrom(rd io.Reader) (n int64, err error) {
 	return n, err
 }
 
+// copyFromMem copies up to max unread bytes of an in-memory reader into the free space,
+// directly from the bytes of the reader, which its WriteTo method passes to a single write.
+// It returns the number of bytes copied and whether the reader is exhausted.
+// Must be called when locked, with free space.
+func (r *RingBuffer) copyFromMem(wt io.WriterTo, max int64) (n int, eof bool) {
+	a, b := r.writable()
+	if int64(len(a)) >= max {
+		a, b = a[:max], nil
+	} else if int64(len(a)+len(b)) > max {
+		b = b[:max-int64(len(a))]
+	}
+	s := memSink{a: a, b: b}
+	_, err := wt.WriteTo(&s)
+	r.advanceWrite(s.n)
+	return s.n, err != errSinkFull
+}
+
+// errSinkFull is returned by a memSink when it cannot hold all the data written to it.
+var errSinkFull = errors.New("no space left")
+
+// memSink is the writer copyFromMem passes to in-memory readers.
+// It copies into a and then b, and accepts short writes so the reader
+// only consumes the bytes that have been copied.
+type memSink struct {
+	a, b []byte
+	n    int
+}
+
+// Write copies as much of p as fits.
+func (s *memSink) Write(p []byte) (int, error) {
+	k := copy(s.a, p)
+	s.a = s.a[k:]
+	m := copy(s.b, p[k:])
+	s.b = s.b[m:]
+	return s.wrote(k+m, len(p))
+}
+
+// WriteString copies as much of p as fits, without converting it to a byte slice.
+func (s *memSink) WriteString(p string) (int, error) {
+	k := copy(s.a, p)
+	s.a = s.a[k:]
+	m := copy(s.b, p[k:])
+	s.b = s.b[m:]
+	return s.wrote(k+m, len(p))
+}
+
+// wrote records that n of the size bytes of a write have been copied.
+func (s *memSink) wrote(n, size int) (int, error) {
+	s.n += n
+	if n < size {
+		return n, errSinkFull
+	}
+	return n, nil
+}
+
+// inMemory returns true if v is a reader or writer of the standard library
+// that copies to or from memory, so it never blocks and can be used
+// in large chunks while locked.
+func inMemory(v interface{}) bool {
+	switch v.(type) {
+	case *bytes.Buffer, *bytes.Reader, *strings.Reader:
+		return true
+	}
+	return false
+}
+
+// SetWriteToChunk sets the maximum number of bytes WriteTo and CopyTo
+// pass to a single call of the writer.
+// Larger chunks suit writers with a high overhead per call,
+// and smaller ones let writers blocked on a full buffer resume earlier.
+// By default, or if n <= 0, at most half the buffer is written at once,
+// but at least 8KiB, and the whole buffer to in-memory writers.
+func (r *RingBuffer) SetWriteToChunk(n int) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.writeToChunk = n
+	return r
+}
+
 // WriteTo writes data to w until there's no more data to write or
 // when an error occurs. The return value n is the number of bytes
 // written. Any error encountered during the write is also returned.
+// The size of the writes can be set with SetWriteToChunk.
+//
+// In blocking mode WriteTo waits for more data until the writer is closed.
+// In non-blocking mode it writes the data buffered so far and returns,
+// so it can drain the buffer periodically without copying the data first;
+// this is what WriteToAvailable does in either mode.
 //
 // If a non-nil error is returned the write side will also see the error.
 func (r *RingBuffer) WriteTo(w io.Writer) (n int64, err error) {
-	if !r.block {
-		return 0, errors.New("RingBuffer: WriteTo only available in blocking mode")
+	r.mu.Lock()
+	defer r.unlock()
+	return r.writeTo(w, r.block, -1)
+}
+
+// WriteToAvailable writes the data currently in the buffer to w
+// and returns without waiting for more data to be written.
+// The return value n is the number of bytes written.
+// Any error encountered during the write is also returned.
+//
+// If a non-nil error is returned the write side will also see the error.
+// WriteToAvailable is available in both blocking and non-blocking mode.
+// In non-blocking mode it is the same as WriteTo,
+// while in blocking mode it does not wait for the writer to be closed like WriteTo does.
+func (r *RingBuffer) WriteToAvailable(w io.Writer) (n int64, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.writeTo(w, false, -1)
+}
+
+// CopyTo consumes up to n bytes from the buffer and writes them to w,
+// leaving the rest buffered, so it is WriteTo with a byte limit.
+// In blocking mode it waits for more data until n bytes have been written,
+// and returns io.EOF if the writer closes the buffer before that.
+// If not blocking only the currently buffered data is written
+// and ErrIsEmpty is returned if it is less than n bytes.
+// Any error encountered during the write is also returned,
+// and the error will cause the write side to fail as well.
+func (r *RingBuffer) CopyTo(w io.Writer, n int64) (written int64, err error) {
+	if n <= 0 {
+		return 0, r.readErr(false)
 	}
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	defer r.unlock()
+	written, err = r.writeTo(w, r.block, n)
+	if err == nil && written < n {
+		if err = r.readErr(true); err == nil {
+			err = ErrIsEmpty
+		}
+	}
+	return written, err
+}
 
+// CopyN copies n bytes from src to dst, like io.CopyN.
+// If src is a ring buffer, the data is written to dst directly from its buffer
+// with CopyTo, and the rest of the data stays buffered.
+// Otherwise, if dst is a ring buffer, the data is read directly into its buffer with ReadFromN.
+func CopyN(dst io.Writer, src io.Reader, n int64) (written int64, err error) {
+	if r, ok := src.(*RingBuffer); ok {
+		return r.CopyTo(dst, n)
+	}
+	if r, ok := dst.(*RingBuffer); ok {
+		return r.ReadFromN(src, n)
+	}
+	return io.CopyN(dst, src, n)
+}
+
+// writeTo writes buffered data to w.
+// If wait is true it waits for more data until the buffer is closed.
+// If limit is not negative at most limit bytes are written.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) writeTo(w io.Writer, wait bool, limit int64) (n int64, err error) {
+	r.begin(OpRead)
+	defer r.end(OpRead)
 	// Don't write more than half, to unblock reads earlier.
 	maxWrite := len(r.buf) / 2
-	// But write at least 8K if possible
-	if maxWrite < 8<<10 {
+	// But write at least 8K if possible,
+	// and everything at once to in-memory writers.
+	mem := inMemory(w)
+	if maxWrite < 8<<10 || mem {
 		maxWrite = len(r.buf)
 	}
-	for {
+	if r.writeToChunk > 0 {
+		maxWrite = r.writeToChunk
+	}
+	for limit < 0 || n < limit {
 		if err = r.readErr(true); err != nil {
 			break
 		}
 		if r.r == r.w && !r.isFull {
+			if !wait {
+				break
+			}
 			// Wait for a write to make space
 			if !r.waitWrite() {
-				return 0, context.DeadlineExceeded
+				return n, context.DeadlineExceeded
 			}
 			continue
 		}
@@ -495,10 +1204,29 @@ func (r *RingBuffer) WriteTo(w io.Writer) (n int64, err error) {
 		if len(toWrite) > maxWrite {
 			toWrite = toWrite[:maxWrite]
 		}
-		// Unlock while reading
-		r.mu.Unlock()
-		nr, werr := w.Write(toWrite)
-		r.mu.Lock()
+		if limit >= 0 && int64(len(toWrite)) > limit-n {
+			toWrite = toWrite[:limit-n]
+		}
+		if r.enc != nil {
+			// Write a decrypted copy.
+			toWrite = r.plain(r.r, len(toWrite), r.consumed())
+		}
+		var nr int
+		var werr error
+		if mem {
+			// In-memory writers never block, so keep the lock.
+			nr, werr = w.Write(toWrite)
+		} else {
+			// Unlock while reading
+			r.lend()
+			r.unlock()
+			nr, werr = w.Write(toWrite)
+			r.mu.Lock()
+			r.unlend()
+			if r.released() {
+				return n, ErrReleased
+			}
+		}
 		if werr != nil {
 			err = r.setErr(werr, true)
 			break
@@ -507,13 +1235,18 @@ func (r *RingBuffer) WriteTo(w io.Writer) (n int64, err error) {
 			err = r.setErr(io.ErrShortWrite, true)
 			break
 		}
+		r.hashRead(toWrite)
+		r.wipeRead(nr)
 		r.r += nr
 		if r.r == r.size {
 			r.r = 0
 		}
 		r.isFull = false
+		r.markRead(nr)
 		n += int64(nr)
-		r.readCond.Broadcast()
+		if r.block {
+			r.readCond.Broadcast()
+		}
 	}
 	if err == io.EOF {
 		err = nil
@@ -543,6 +1276,39 @@ func (r *RingBuffer) Copy(dst io.Writer, src io.Reader) (written int64, err erro
 	return r.WriteTo(dst)
 }
 
+// CopyContext is like Copy, but cancels the transfer when ctx is done
+// and then returns ctx.Err(), so a canceled copy can be told apart from a failed one.
+// Like with CloseWithError, it returns once the ongoing read of src
+// and write to dst have finished, since they cannot be interrupted.
+// A canceled ringbuffer is left closed with ctx.Err(),
+// while ctx being done after the copy finished leaves the ringbuffer untouched.
+func (r *RingBuffer) CopyContext(ctx context.Context, dst io.Writer, src io.Reader) (written int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	// state is 0 while copying, then 1 if the copy finished or 2 if it was canceled first.
+	var state atomic.Int32
+	done, exited := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			if state.CompareAndSwap(0, 2) {
+				r.CloseWithError(ctx.Err())
+			}
+		case <-done:
+		}
+	}()
+	written, err = r.Copy(dst, src)
+	canceled := !state.CompareAndSwap(0, 1)
+	close(done)
+	<-exited
+	if canceled || (err != nil && ctx.Err() != nil) {
+		err = ctx.Err()
+	}
+	return written, err
+}
+
 // TryWrite writes len(p) bytes from p to the underlying buf like Write, but it is not blocking.
 // If it does not succeed to acquire the lock, it returns ErrAcquireLock.
 func (r *RingBuffer) TryWrite(p []byte) (n int, err error) {
@@ -553,11 +1319,11 @@ func (r *RingBuffer) TryWrite(p []byte) (n int, err error) {
 	if !ok {
 		return 0, ErrAcquireLock
 	}
-	defer r.mu.Unlock()
-	if err := r.err; err != nil {
-		if err == io.EOF {
-			err = ErrWriteOnClosed
-		}
+	defer r.unlock()
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+	if err := r.waitFree(len(p), false, nil); err != nil {
 		return 0, err
 	}
 
@@ -569,7 +1335,19 @@ func (r *RingBuffer) TryWrite(p []byte) (n int, err error) {
 }
 
 func (r *RingBuffer) write(p []byte) (n int, err error) {
+	if r.rClosed != nil {
+		return 0, r.rClosed
+	}
+	if r.sealed {
+		return 0, ErrSealed
+	}
+	r.grow(len(p))
+	dropped := 0
+	if r.overwrite {
+		p, dropped = r.makeRoom(p)
+	}
 	if r.isFull {
+		r.stalls++
 		return 0, ErrIsFull
 	}
 
@@ -581,10 +1359,17 @@ func (r *RingBuffer) write(p []byte) (n int, err error) {
 	}
 
 	if len(p) > avail {
+		r.stalls++
 		err = ErrTooMuchDataToWrite
 		p = p[:avail]
 	}
+	if rem := r.remaining(); int64(len(p)) > rem {
+		err = ErrWriteOnClosed
+		p = p[:rem]
+	}
 	n = len(p)
+	r.hashWrite(p)
+	start := r.w
 
 	if r.w >= r.r {
 		c1 := r.size - r.w
@@ -601,6 +1386,7 @@ func (r *RingBuffer) write(p []byte) (n int, err error) {
 		copy(r.buf[r.w:], p)
 		r.w += n
 	}
+	r.cryptBuf(start, n, r.written)
 
 	if r.w == r.size {
 		r.w = 0
@@ -608,18 +1394,21 @@ func (r *RingBuffer) write(p []byte) (n int, err error) {
 	if r.w == r.r {
 		r.isFull = true
 	}
+	r.written += int64(n)
+	r.updateHighWater()
+	r.markWrite(n)
+	r.checkLimit()
 
-	return n, err
+	return n + dropped, err
 }
 
 // WriteByte writes one byte into buffer, and returns ErrIsFull if the buffer is full.
 func (r *RingBuffer) WriteByte(c byte) error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
-	if err := r.err; err != nil {
-		if err == io.EOF {
-			err = ErrWriteOnClosed
-		}
+	defer r.unlock()
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	if err := r.writeErr(); err != nil {
 		return err
 	}
 	err := r.writeByte(c)
@@ -642,11 +1431,8 @@ func (r *RingBuffer) TryWriteByte(c byte) error {
 	if !ok {
 		return ErrAcquireLock
 	}
-	defer r.mu.Unlock()
-	if err := r.err; err != nil {
-		if err == io.EOF {
-			err = ErrWriteOnClosed
-		}
+	defer r.unlock()
+	if err := r.writeErr(); err != nil {
 		return err
 	}
 
@@ -661,10 +1447,23 @@ func (r *RingBuffer) writeByte(c byte) error {
 	if r.err != nil {
 		return r.err
 	}
+	if r.rClosed != nil {
+		return r.rClosed
+	}
+	if r.sealed {
+		return ErrSealed
+	}
+	r.grow(1)
+	if r.overwrite && r.isFull {
+		r.evict(1)
+	}
 	if r.w == r.r && r.isFull {
+		r.stalls++
 		return ErrIsFull
 	}
 	r.buf[r.w] = c
+	r.hashWrite(r.buf[r.w : r.w+1])
+	r.cryptBuf(r.w, 1, r.written)
 	r.w++
 
 	if r.w == r.size {
@@ -673,15 +1472,78 @@ func (r *RingBuffer) writeByte(c byte) error {
 	if r.w == r.r {
 		r.isFull = true
 	}
+	r.written++
+	r.updateHighWater()
+	r.markWrite(1)
+	r.checkLimit()
 
 	return nil
 }
 
+// rlock locks the ring buffer for methods that only inspect it,
+// so they can run concurrently with each other.
+// The lock is exclusive if inspecting the buffer mutates it anyway,
+// which is the case with encryption, whose key stream uses scratch space,
+// and with SetMaxAge, which drops expired data first.
+// It returns whether the lock is exclusive, to be passed to runlock.
+func (r *RingBuffer) rlock() (exclusive bool) {
+	r.mu.RLock()
+	if r.enc == nil && r.ages == nil {
+		return false
+	}
+	r.mu.RUnlock()
+	r.mu.Lock()
+	return true
+}
+
+// runlock unlocks the ring buffer locked with rlock.
+func (r *RingBuffer) runlock(exclusive bool) {
+	if exclusive {
+		r.unlock()
+		return
+	}
+	r.mu.RUnlock()
+}
+
 // Length returns the number of bytes that can be read without blocking.
+// It does not take the lock, so it can be polled without contending with reads and writes,
+// and returns the length as of the last operation that released the lock
+// or started waiting.
+// The first call of Length, Free, IsEmpty or IsFull takes the lock,
+// and makes operations publish the length from then on.
 func (r *RingBuffer) Length() int {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	if n := r.nLength.Load(); n != 0 {
+		return int(^n)
+	}
+	defer r.runlock(r.rlock())
+	r.startPublishing()
+	return r.length()
+}
+
+// startPublishing makes operations publish the length and free space
+// for the lock-free accessors, and publishes them.
+// Must be called when locked or read-locked.
+func (r *RingBuffer) startPublishing() {
+	r.publishing.Store(true)
+	r.nLength.Store(^int64(r.length()))
+	r.nFree.Store(^int64(r.free()))
+}
+
+// publish stores the length and free space for the lock-free accessors,
+// once they have been used.
+// They are stored complemented, so the zero value means nothing was published yet.
+// Must be called when locked.
+func (r *RingBuffer) publish() {
+	if !r.publishing.Load() {
+		return
+	}
+	r.nLength.Store(^int64(r.length()))
+	r.nFree.Store(^int64(r.free()))
+}
 
+// length returns the number of buffered bytes.
+// Must be called when locked.
+func (r *RingBuffer) length() int {
 	if r.w == r.r {
 		if r.isFull {
 			return r.size
@@ -697,15 +1559,26 @@ func (r *RingBuffer) Length() int {
 }
 
 // Capacity returns the size of the underlying buffer.
+// Unlike Length and Free it takes the lock, since Resize and SetAutoGrow change the size.
 func (r *RingBuffer) Capacity() int {
+	defer r.runlock(r.rlock())
 	return r.size
 }
 
 // Free returns the number of bytes that can be written without blocking.
+// Like Length it does not take the lock.
 func (r *RingBuffer) Free() int {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	if n := r.nFree.Load(); n != 0 {
+		return int(^n)
+	}
+	defer r.runlock(r.rlock())
+	r.startPublishing()
+	return r.free()
+}
 
+// free returns the number of bytes that can be written.
+// Must be called when locked.
+func (r *RingBuffer) free() int {
 	if r.w == r.r {
 		if r.isFull {
 			return 0
@@ -733,8 +1606,7 @@ func (r *RingBuffer) WriteString(s string) (n int, err error) {
 // If the dst is big enough, it will be used as destination,
 // otherwise a new buffer will be allocated.
 func (r *RingBuffer) Bytes(dst []byte) []byte {
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	defer r.runlock(r.rlock())
 	getDst := func(n int) []byte {
 		if cap(dst) < n {
 			return make([]byte, n)
@@ -747,6 +1619,7 @@ func (r *RingBuffer) Bytes(dst []byte) []byte {
 			buf := getDst(r.size)
 			copy(buf, r.buf[r.r:])
 			copy(buf[r.size-r.r:], r.buf[:r.w])
+			r.decrypt(buf, r.consumed())
 			return buf
 		}
 		return nil
@@ -755,6 +1628,7 @@ func (r *RingBuffer) Bytes(dst []byte) []byte {
 	if r.w > r.r {
 		buf := getDst(r.w - r.r)
 		copy(buf, r.buf[r.r:r.w])
+		r.decrypt(buf, r.consumed())
 		return buf
 	}
 
@@ -769,24 +1643,21 @@ func (r *RingBuffer) Bytes(dst []byte) []byte {
 		c2 := n - c1
 		copy(buf[c1:], r.buf[0:c2])
 	}
+	r.decrypt(buf, r.consumed())
 
 	return buf
 }
 
 // IsFull returns true when the ringbuffer is full.
+// Like Length it does not take the lock.
 func (r *RingBuffer) IsFull() bool {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	return r.isFull
+	return r.Free() == 0 && r.Length() > 0
 }
 
 // IsEmpty returns true when the ringbuffer is empty.
+// Like Length it does not take the lock.
 func (r *RingBuffer) IsEmpty() bool {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	return !r.isFull && r.w == r.r
+	return r.Length() == 0
 }
 
 // CloseWithError closes the writer; reads will return
@@ -807,11 +1678,39 @@ func (r *RingBuffer) CloseWriter() {
 	r.setErr(io.EOF, false)
 }
 
+// CloseRead closes the read side, like a half-close of a TCP connection.
+// Writes, including blocked ones, fail with err, or ErrReaderClosed if err is nil,
+// while reads still return the remaining bytes and then io.EOF.
+// Unlike CloseWithError, the buffered data is not abandoned.
+//
+// CloseRead never overwrites the previous error of CloseRead.
+func (r *RingBuffer) CloseRead(err error) {
+	if err == nil {
+		err = ErrReaderClosed
+	}
+	r.mu.Lock()
+	defer r.unlock()
+	if r.rClosed != nil {
+		return
+	}
+	r.rClosed = err
+	if r.block {
+		r.readCond.Broadcast()
+		r.writeCond.Broadcast()
+	}
+	notify(r.readableC)
+	notify(r.writableC)
+	r.wakeWatchers()
+	r.addHookEvent(evClose, 0, err)
+}
+
 // Flush waits for the buffer to be empty and fully read.
 // If not blocking ErrIsNotEmpty will be returned if the buffer still contains data.
 func (r *RingBuffer) Flush() error {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	defer r.unlock()
+	r.begin(OpFlush)
+	defer r.end(OpFlush)
 	for r.w != r.r || r.isFull {
 		err := r.readErr(true)
 		if err != nil {
@@ -835,56 +1734,282 @@ func (r *RingBuffer) Flush() error {
 	return err
 }
 
+// FlushContext waits for the buffer to be empty and fully read,
+// like Flush, but the wait is bounded by ctx instead of the read timeout.
+// If ctx is done before the buffer drains, ctx.Err() is returned
+// and the buffer is left untouched.
+func (r *RingBuffer) FlushContext(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.unlock()
+	r.begin(OpFlush)
+	defer r.end(OpFlush)
+	if r.block {
+		defer r.wakeReadOnDone(ctx)()
+	}
+	for r.w != r.r || r.isFull {
+		err := r.readErr(true)
+		if err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return err
+		}
+		if !r.block {
+			return ErrIsNotEmpty
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.readCond.Wait()
+	}
+
+	err := r.readErr(true)
+	if err == io.EOF {
+		return nil
+	}
+	return err
+}
+
 // Reset the read pointer and writer pointer to zero.
+// Operations in flight are aborted with ErrReset,
+// and Reset waits for them to return.
 func (r *RingBuffer) Reset() {
+	r.ResetWith(ResetAbort)
+}
+
+// ResetWith resets the read pointer and writer pointer to zero,
+// handling operations in flight according to mode.
+// Operations in flight are blocking reads and writes,
+// including ReadFrom, WriteTo and Flush.
+// ErrInFlight is returned if mode is ResetFail and operations are in flight,
+// in which case the ring buffer is left untouched.
+func (r *RingBuffer) ResetWith(mode ResetMode) error {
+	_, err := r.ResetReport(mode)
+	return err
+}
+
+// ResetReport resets the ring buffer like ResetWith
+// and returns the kinds of the operations it aborted with ErrReset,
+// one entry per operation, ordered by kind.
+// Operations are only aborted in ResetAbort mode.
+func (r *RingBuffer) ResetReport(mode ResetMode) (aborted []OpKind, err error) {
 	r.mu.Lock()
-	defer r.mu.Unlock()
+	defer r.unlock()
 
-	// Set error so any readers/writers will return immediately.
-	r.setErr(errors.New("reset called"), true)
-	if r.block {
-		r.readCond.Broadcast()
-		r.writeCond.Broadcast()
+	switch mode {
+	case ResetFail:
+		if r.inFlight > 0 {
+			return nil, ErrInFlight
+		}
+	case ResetWait:
+		r.waitIdle()
+	default:
+		aborted = r.inFlightOps()
+		// Set error so any readers/writers will return immediately.
+		r.setErr(ErrReset, true)
+		// Unlock the mutex so readers/writers can finish.
+		r.waitIdle()
 	}
 
-	// Unlock the mutex so readers/writers can finish.
-	r.mu.Unlock()
-	r.wg.Wait()
-	r.mu.Lock()
+	if r.wipe {
+		zero(r.buf)
+	}
 	r.r = 0
 	r.w = 0
 	r.err = nil
+	if r.buf == nil {
+		// Released buffers cannot be reused.
+		r.err = ErrReleased
+	}
 	r.isFull = false
+	r.sealed = false
+	r.rClosed = nil
+	r.rebase()
+	r.written = 0
+	if r.ages != nil {
+		r.ages.stamps = r.ages.stamps[:0]
+	}
+	r.unreadable = 0
+	for _, c := range r.readers {
+		c.off = 0
+	}
+	r.pending = nil
+	if r.reserved != nil {
+		// The reservation is lost, Commit and Abort will fail.
+		r.reserved = nil
+		r.unlend()
+	}
+	r.unread = time.Time{}
+	r.storeHeader()
+	r.checkWatermarks()
+	notify(r.writableC)
+	if r.wHash != nil {
+		r.wHash.Reset()
+	}
+	if r.rHash != nil {
+		r.rHash.Reset()
+	}
+	return aborted, nil
 }
 
 // WriteCloser returns a WriteCloser that writes to the ring buffer.
 // When the returned WriteCloser is closed, it will wait for all data to be read before returning.
+// It is a *WriteCloser, which WriteHandle returns as such.
 func (r *RingBuffer) WriteCloser() io.WriteCloser {
-	return &writeCloser{RingBuffer: r}
+	return r.WriteHandle()
 }
 
-type writeCloser struct {
-	*RingBuffer
+// WriteHandle returns a WriteCloser that writes to the ring buffer, like WriteCloser.
+// Additional handles sharing the same writer can be obtained with Dup.
+func (r *RingBuffer) WriteHandle() *WriteCloser {
+	refs := new(atomic.Int32)
+	refs.Store(1)
+	return &WriteCloser{rb: r, refs: refs}
+}
+
+// A WriteCloser is an io.WriteCloser that writes to the ring buffer.
+// It only exposes the write side of the ring buffer,
+// and its methods return ErrWriteOnClosed once the handle has been closed,
+// even if other handles are still open.
+type WriteCloser struct {
+	rb     *RingBuffer
+	refs   *atomic.Int32 // Open handles, shared with handles returned by Dup.
+	closed atomic.Bool
+}
+
+// Dup returns a new handle to the same writer.
+// The write side of the ring buffer is only closed when all handles
+// have been closed with Close or Abort, like a duplicated file descriptor.
+// This allows several producers to close their own handle
+// without coordinating who closes the writer.
+func (wc *WriteCloser) Dup() *WriteCloser {
+	wc.refs.Add(1)
+	return &WriteCloser{rb: wc.rb, refs: wc.refs}
+}
+
+// Write writes p to the ring buffer.
+// It returns ErrWriteOnClosed if this handle has been closed.
+func (wc *WriteCloser) Write(p []byte) (n int, err error) {
+	if wc.closed.Load() {
+		return 0, ErrWriteOnClosed
+	}
+	return wc.rb.Write(p)
+}
+
+// TryWrite writes p to the ring buffer like RingBuffer.TryWrite.
+// It returns ErrWriteOnClosed if this handle has been closed.
+func (wc *WriteCloser) TryWrite(p []byte) (n int, err error) {
+	if wc.closed.Load() {
+		return 0, ErrWriteOnClosed
+	}
+	return wc.rb.TryWrite(p)
+}
+
+// WriteString writes s to the ring buffer.
+// It returns ErrWriteOnClosed if this handle has been closed.
+func (wc *WriteCloser) WriteString(s string) (n int, err error) {
+	if wc.closed.Load() {
+		return 0, ErrWriteOnClosed
+	}
+	return wc.rb.WriteString(s)
+}
+
+// WriteByte writes c to the ring buffer.
+// It returns ErrWriteOnClosed if this handle has been closed.
+func (wc *WriteCloser) WriteByte(c byte) error {
+	if wc.closed.Load() {
+		return ErrWriteOnClosed
+	}
+	return wc.rb.WriteByte(c)
+}
+
+// WriteRune writes the UTF-8 encoding of c to the ring buffer.
+// It returns ErrWriteOnClosed if this handle has been closed.
+func (wc *WriteCloser) WriteRune(c rune) (size int, err error) {
+	if wc.closed.Load() {
+		return 0, ErrWriteOnClosed
+	}
+	return wc.rb.WriteRune(c)
+}
+
+// ReadFrom writes data from rd to the ring buffer like RingBuffer.ReadFrom.
+// It returns ErrWriteOnClosed if this handle has been closed.
+func (wc *WriteCloser) ReadFrom(rd io.Reader) (n int64, err error) {
+	if wc.closed.Load() {
+		return 0, ErrWriteOnClosed
+	}
+	return wc.rb.ReadFrom(rd)
+}
+
+// release closes the handle and reports whether it was the last open handle.
+func (wc *WriteCloser) release() bool {
+	if !wc.closed.CompareAndSwap(false, true) {
+		return false
+	}
+	return wc.refs.Add(-1) == 0
 }
 
 // Close provides a close method for the WriteCloser.
-func (wc *writeCloser) Close() error {
-	wc.CloseWriter()
-	return wc.Flush()
+// When the last handle is closed, it closes the writer and waits for all data to be read.
+// Closing other handles returns immediately.
+func (wc *WriteCloser) Close() error {
+	if !wc.release() {
+		return nil
+	}
+	wc.rb.CloseWriter()
+	return wc.rb.Flush()
+}
+
+// CloseWithError closes the writer; subsequent reads will return
+// no bytes and the error err.
+// The error is propagated immediately, even if other handles are still open.
+// If err is nil it behaves like Close.
+// Unlike Close it does not wait for the data to be read.
+//
+// CloseWithError never overwrites the previous error if it exists.
+// It returns ErrWriteOnClosed if this handle has been closed, and nil otherwise.
+func (wc *WriteCloser) CloseWithError(err error) error {
+	if err == nil {
+		return wc.Close()
+	}
+	if wc.closed.Swap(true) {
+		return ErrWriteOnClosed
+	}
+	wc.refs.Add(-1)
+	wc.rb.CloseWithError(err)
+	return nil
+}
+
+// Abort closes the handle without waiting for the data to be read.
+// When the last handle is closed, reads will return any remaining bytes and io.EOF.
+// This should be used on shutdown when the reader may have gone away.
+func (wc *WriteCloser) Abort() error {
+	if wc.release() {
+		wc.rb.CloseWriter()
+	}
+	return nil
 }
 
 // ReadCloser returns a io.ReadCloser that reads to the ring buffer.
 // When the returned ReadCloser is closed, ErrReaderClosed will be returned on any writes done afterwards.
+// It is a *ReadCloser, which ReadHandle returns as such.
 func (r *RingBuffer) ReadCloser() io.ReadCloser {
-	return &readCloser{RingBuffer: r}
+	return r.ReadHandle()
 }
 
-type readCloser struct {
+// ReadHandle returns a ReadCloser that reads from the ring buffer, like ReadCloser,
+// with CloseRemaining.
+func (r *RingBuffer) ReadHandle() *ReadCloser {
+	return &ReadCloser{RingBuffer: r}
+}
+
+// A ReadCloser is an io.ReadCloser that reads from the ring buffer.
+type ReadCloser struct {
 	*RingBuffer
 }
 
 // Close provides a close method for the ReadCloser.
-func (rc *readCloser) Close() error {
+func (rc *ReadCloser) Close() error {
 	rc.CloseWithError(ErrReaderClosed)
 	err := rc.readErr(false)
 	if err == ErrReaderClosed {
@@ -893,14 +2018,32 @@ func (rc *readCloser) Close() error {
 	return err
 }
 
+// CloseRemaining closes the reader like Close and returns the number of
+// unread bytes that were abandoned in the buffer.
+// If drain is true the abandoned bytes are consumed before closing,
+// so they are included in the read hash and the buffer is left empty.
+func (rc *ReadCloser) CloseRemaining(drain bool) (remaining int, err error) {
+	rc.mu.Lock()
+	defer rc.unlock()
+	remaining = rc.length()
+	if drain {
+		rc.discard(remaining)
+	}
+	rc.setErr(ErrReaderClosed, true)
+	err = rc.readErr(true)
+	if err == ErrReaderClosed {
+		err = nil
+	}
+	return remaining, err
+}
+
 // Peek reads up to len(p) bytes into p without moving the read pointer.
 func (r *RingBuffer) Peek(p []byte) (n int, err error) {
 	if len(p) == 0 {
 		return 0, r.readErr(false)
 	}
 
-	r.mu.Lock()
-	defer r.mu.Unlock()
+	defer r.runlock(r.rlock())
 	if err := r.readErr(true); err != nil {
 		return 0, err
 	}
@@ -908,6 +2051,42 @@ func (r *RingBuffer) Peek(p []byte) (n int, err error) {
 	return r.peek(p)
 }
 
+// PeekByte returns the next byte without moving the read pointer,
+// or ErrIsEmpty if there is none.
+func (r *RingBuffer) PeekByte() (b byte, err error) {
+	defer r.runlock(r.rlock())
+	if err := r.readErr(true); err != nil {
+		return 0, err
+	}
+	if r.w == r.r && !r.isFull {
+		return 0, ErrIsEmpty
+	}
+	var p [1]byte
+	r.peekAt(p[:], 0)
+	return p[0], nil
+}
+
+// PeekAt reads up to len(p) bytes into p, starting offset bytes after the read pointer,
+// without moving the read pointer. Like Peek it returns fewer than len(p) bytes
+// if fewer are buffered, and ErrIsEmpty if no byte is buffered at offset.
+// ErrInvalidLength is returned if offset is negative.
+func (r *RingBuffer) PeekAt(offset int, p []byte) (n int, err error) {
+	if offset < 0 {
+		return 0, ErrInvalidLength
+	}
+	defer r.runlock(r.rlock())
+	if err := r.readErr(true); err != nil {
+		return 0, err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if offset >= r.length() {
+		return 0, ErrIsEmpty
+	}
+	return r.peekAt(p, offset), nil
+}
+
 func (r *RingBuffer) peek(p []byte) (n int, err error) {
 	if r.w == r.r && !r.isFull {
 		return 0, ErrIsEmpty
@@ -919,6 +2098,7 @@ func (r *RingBuffer) peek(p []byte) (n int, err error) {
 			n = len(p)
 		}
 		copy(p, r.buf[r.r:r.r+n])
+		r.decrypt(p[:n], r.consumed())
 		return
 	}
 
@@ -935,6 +2115,54 @@ func (r *RingBuffer) peek(p []byte) (n int, err error) {
 		c2 := n - c1
 		copy(p[c1:], r.buf[0:c2])
 	}
+	r.decrypt(p[:n], r.consumed())
 
 	return n, r.readErr(true)
 }
+
+// PeekTo writes up to n unread bytes to w without moving the read pointer.
+// It returns the number of bytes written and any error encountered during the write.
+// Errors from w do not affect the state of the ring buffer.
+// ErrInvalidLength is returned if n is negative.
+//
+// The ring buffer is locked while writing to w,
+// so w should not block for long.
+func (r *RingBuffer) PeekTo(w io.Writer, n int) (written int64, err error) {
+	if n < 0 {
+		return 0, ErrInvalidLength
+	}
+	defer r.runlock(r.rlock())
+	if err := r.readErr(true); err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, nil
+	}
+	if r.w == r.r && !r.isFull {
+		return 0, ErrIsEmpty
+	}
+	a, b := r.readable()
+	if len(a) > n {
+		a = a[:n]
+	}
+	if len(b) > n-len(a) {
+		b = b[:n-len(a)]
+	}
+	if r.enc != nil {
+		a, b = r.plain(r.r, len(a)+len(b), r.consumed()), nil
+	}
+	for _, p := range [2][]byte{a, b} {
+		if len(p) == 0 {
+			continue
+		}
+		nw, err := w.Write(p)
+		written += int64(nw)
+		if err != nil {
+			return written, err
+		}
+		if nw != len(p) {
+			return written, io.ErrShortWrite
+		}
+	}
+	return written, nil
+}
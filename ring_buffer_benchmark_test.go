@@ -1,6 +1,7 @@
 package ringbuffer
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"strings"
@@ -150,6 +151,57 @@ func BenchmarkRingBuffer_WriteTo(b *testing.B) {
 	rb.CloseWithError(context.Canceled)
 }
 
+func BenchmarkRingBuffer_ReadFromBytesReader(b *testing.B) {
+	const sz = 4096
+	rb := New(sz)
+	data := []byte(strings.Repeat("a", sz))
+	src := bytes.NewReader(data)
+
+	b.ResetTimer()
+	b.SetBytes(sz)
+	for i := 0; i < b.N; i++ {
+		src.Reset(data)
+		rb.ReadFrom(src)
+		rb.Reset()
+	}
+}
+
+func BenchmarkRingBuffer_WriteToBytesBuffer(b *testing.B) {
+	const sz = 4096
+	rb := New(sz)
+	data := []byte(strings.Repeat("a", sz))
+	var dst bytes.Buffer
+
+	b.ResetTimer()
+	b.SetBytes(sz)
+	for i := 0; i < b.N; i++ {
+		rb.Write(data)
+		dst.Reset()
+		rb.WriteToAvailable(&dst)
+	}
+}
+
+func BenchmarkSPSC(b *testing.B) {
+	s := NewSPSC(1024)
+	data := []byte(strings.Repeat("a", 512))
+	buf := make([]byte, 512)
+
+	go func() {
+		for {
+			if _, err := s.Write(data); err == ErrWriteOnClosed {
+				return
+			}
+		}
+	}()
+
+	b.ResetTimer()
+	b.SetBytes(512)
+	for i := 0; i < b.N; i++ {
+		s.Read(buf)
+	}
+	s.CloseWriter()
+}
+
 func BenchmarkIoPipeReader(b *testing.B) {
 	pr, pw := io.Pipe()
 	data := []byte(strings.Repeat("a", 512))
@@ -167,3 +219,14 @@ func BenchmarkIoPipeReader(b *testing.B) {
 		pr.Read(buf)
 	}
 }
+
+func BenchmarkPool(b *testing.B) {
+	var p Pool
+	data := []byte("hello")
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rb := p.Get(4096)
+		rb.Write(data)
+		p.Put(rb)
+	}
+}
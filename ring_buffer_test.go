@@ -13,6 +13,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"testing/iotest"
 	"time"
 )
 
@@ -1428,3 +1429,811 @@ func TestRingBuffer_Peek(t *testing.T) {
 		t.Fatalf("expected %s, got %s", string(data), string(buf))
 	}
 }
+
+func TestRingBuffer_LengthUnlocked(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4)
+	if rb.Length() != 0 || rb.Free() != 4 || !rb.IsEmpty() || rb.IsFull() {
+		t.Fatalf("expected an empty buffer, got length %d and free %d", rb.Length(), rb.Free())
+	}
+	rb.Write([]byte("abcd"))
+
+	// The accessors must not wait for the lock.
+	rb.mu.Lock()
+	if rb.Length() != 4 || rb.Free() != 0 || rb.IsEmpty() || !rb.IsFull() {
+		t.Fatalf("expected a full buffer, got length %d and free %d", rb.Length(), rb.Free())
+	}
+	rb.mu.Unlock()
+
+	// A blocked write publishes its progress before it is done.
+	rb = New(4).SetBlocking(true)
+	done := make(chan struct{})
+	go func() {
+		rb.Write([]byte("abcdef"))
+		close(done)
+	}()
+	for rb.Length() != 4 {
+		time.Sleep(time.Millisecond)
+	}
+	rb.Read(make([]byte, 4))
+	<-done
+	if rb.Length() != 2 || rb.Free() != 2 {
+		t.Fatalf("expected length 2 and free 2, got %d and %d", rb.Length(), rb.Free())
+	}
+}
+
+func TestRingBuffer_InspectShared(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8)
+	rb.Write([]byte("abc"))
+
+	// Inspection methods share the lock with each other.
+	rb.mu.RLock()
+	buf := make([]byte, 3)
+	if n, err := rb.Peek(buf); err != nil || string(buf[:n]) != "abc" {
+		t.Fatalf("expected abc, got %q, %v", buf[:n], err)
+	}
+	if s := string(rb.Bytes(nil)); s != "abc" {
+		t.Fatalf("expected abc, got %q", s)
+	}
+	if st := rb.Stats(); st.Length != 3 {
+		t.Fatalf("expected length 3, got %d", st.Length)
+	}
+	rb.mu.RUnlock()
+
+	// Mutations still exclude them.
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				rb.Write([]byte("de"))
+				rb.Read(make([]byte, 2))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				// Writes and reads are 2 bytes, so a torn snapshot shows as an even length.
+				if b := rb.Bytes(nil); len(b)%2 != 1 {
+					t.Errorf("expected an odd length, got %q", b)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestRingBuffer_PeekAt(t *testing.T) {
+	rb := New(8)
+	if _, err := rb.PeekByte(); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	// Wrap the data around the end of the buffer.
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghijk"))
+
+	b, err := rb.PeekByte()
+	if err != nil || b != 'e' {
+		t.Fatalf("expected e, got %q, %v", b, err)
+	}
+	buf := make([]byte, 4)
+	n, err := rb.PeekAt(2, buf)
+	if err != nil || string(buf[:n]) != "ghij" {
+		t.Fatalf("expected ghij, got %q, %v", buf[:n], err)
+	}
+	n, err = rb.PeekAt(5, buf)
+	if err != nil || string(buf[:n]) != "jk" {
+		t.Fatalf("expected jk, got %q, %v", buf[:n], err)
+	}
+	if _, err := rb.PeekAt(7, buf); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	if _, err := rb.PeekAt(-1, buf); err != ErrInvalidLength {
+		t.Fatalf("expected ErrInvalidLength, got %v", err)
+	}
+	if rb.Length() != 7 {
+		t.Fatalf("expected length 7, got %d", rb.Length())
+	}
+}
+
+func TestRingBuffer_ReadFromNonBlocking(t *testing.T) {
+	rb := New(10)
+	n, err := rb.ReadFrom(strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 5 {
+		t.Fatalf("expected 5 bytes, got %d", n)
+	}
+
+	// Fill the rest and overflow.
+	n, err = rb.ReadFrom(strings.NewReader("hello world"))
+	if err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	if n != 5 {
+		t.Fatalf("expected 5 bytes, got %d", n)
+	}
+	if got := string(rb.Bytes(nil)); got != "hellohello" {
+		t.Fatalf("expected hellohello, got %q", got)
+	}
+
+	// Wrap around.
+	buf := make([]byte, 7)
+	rb.Read(buf)
+	n, err = rb.ReadFrom(strings.NewReader("abcdef"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 6 {
+		t.Fatalf("expected 6 bytes, got %d", n)
+	}
+	if got := string(rb.Bytes(nil)); got != "lloabcdef" {
+		t.Fatalf("expected lloabcdef, got %q", got)
+	}
+
+	// Errors from the source are propagated.
+	rb.Reset()
+	testErr := errors.New("test error")
+	if _, err = rb.ReadFrom(iotest.ErrReader(testErr)); err != testErr {
+		t.Fatalf("expected %v, got %v", testErr, err)
+	}
+	if _, err = rb.Read(buf); err != testErr {
+		t.Fatalf("expected %v, got %v", testErr, err)
+	}
+}
+
+func TestRingBuffer_WriteToAvailable(t *testing.T) {
+	for _, block := range []bool{false, true} {
+		rb := New(10).SetBlocking(block)
+		var out bytes.Buffer
+
+		n, err := rb.WriteToAvailable(&out)
+		if err != nil || n != 0 {
+			t.Fatalf("expected 0, nil; got %d, %v", n, err)
+		}
+
+		rb.Write([]byte("hello wor"))
+		buf := make([]byte, 6)
+		rb.Read(buf)
+		rb.Write([]byte("ld!!!"))
+		n, err = rb.WriteToAvailable(&out)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if n != 8 {
+			t.Fatalf("expected 8 bytes, got %d", n)
+		}
+		if got := out.String(); got != "world!!!" {
+			t.Fatalf("expected world!!!, got %q", got)
+		}
+		if !rb.IsEmpty() {
+			t.Fatalf("expect IsEmpty is true but got false")
+		}
+
+		// Remaining data is written after close, followed by no error.
+		out.Reset()
+		rb.Write([]byte("bye"))
+		rb.CloseWriter()
+		n, err = rb.WriteToAvailable(&out)
+		if err != nil || n != 3 || out.String() != "bye" {
+			t.Fatalf("expected 3, nil, bye; got %d, %v, %q", n, err, out.String())
+		}
+	}
+}
+
+func TestRingBuffer_WriteCloser(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	var _ io.WriteCloser = New(1).WriteCloser()
+
+	// Abort does not wait for the data to be read.
+	rb := New(100).SetBlocking(true)
+	wc := rb.WriteHandle()
+	wc.Write([]byte("hello"))
+	if err := wc.Abort(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := wc.Write([]byte("world")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+	got, err := io.ReadAll(rb)
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("expected hello, nil; got %q, %v", got, err)
+	}
+
+	// CloseWithError does not wait and passes the error to readers.
+	rb = New(100).SetBlocking(true)
+	wc = rb.WriteHandle()
+	wc.Write([]byte("hello"))
+	testErr := errors.New("test error")
+	if err := wc.CloseWithError(testErr); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := rb.Read(make([]byte, 10)); err != testErr {
+		t.Fatalf("expected %v, got %v", testErr, err)
+	}
+
+	// Close waits for the data to be read.
+	rb = New(100).SetBlocking(true)
+	wc = rb.WriteHandle()
+	wc.Write([]byte("hello"))
+	done := make(chan error)
+	go func() {
+		done <- wc.CloseWithError(nil)
+	}()
+	select {
+	case err := <-done:
+		t.Fatalf("close returned before data was read: %v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	got, err = io.ReadAll(rb)
+	if err != nil || string(got) != "hello" {
+		t.Fatalf("expected hello, nil; got %q, %v", got, err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRingBuffer_ReadCloserRemaining(t *testing.T) {
+	var _ io.ReadCloser = New(1).ReadCloser()
+
+	rb := New(10).WithReadHash(crc32.NewIEEE())
+	rc := rb.ReadHandle()
+	rb.Write([]byte("hello world"))
+	rc.Read(make([]byte, 2))
+	n, err := rc.CloseRemaining(false)
+	if err != nil || n != 8 {
+		t.Fatalf("expected 8, nil; got %d, %v", n, err)
+	}
+	if rb.Length() != 8 {
+		t.Fatalf("expected 8 bytes left, got %d", rb.Length())
+	}
+	if _, err := rb.Write([]byte("x")); err != ErrReaderClosed {
+		t.Fatalf("expected ErrReaderClosed, got %v", err)
+	}
+
+	rb = New(10).WithReadHash(crc32.NewIEEE())
+	rc = rb.ReadHandle()
+	rb.Write([]byte("hello world"))
+	rb.CloseWriter()
+	n, err = rc.CloseRemaining(true)
+	if err != nil || n != 10 {
+		t.Fatalf("expected 10, nil; got %d, %v", n, err)
+	}
+	if !rb.IsEmpty() {
+		t.Fatalf("expect IsEmpty is true but got false")
+	}
+	want := crc32.NewIEEE()
+	want.Write([]byte("hello worl"))
+	if got := rb.ReadSum(nil); !bytes.Equal(got, want.Sum(nil)) {
+		t.Fatalf("expected drained bytes to be hashed")
+	}
+}
+
+func TestRingBuffer_WriteCloserDup(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(100).SetBlocking(true)
+	wc := rb.WriteHandle()
+
+	const writers = 4
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(wc *WriteCloser) {
+			defer wg.Done()
+			wc.Write([]byte("hello"))
+			wc.Abort()
+		}(wc.Dup())
+	}
+	wg.Wait()
+
+	// The original handle is still open.
+	if rb.readErr(false) != nil {
+		t.Fatalf("writer closed before all handles were closed")
+	}
+	wc.Write([]byte("hello"))
+	wc.Abort()
+	// Closing again must not affect the count.
+	wc.Abort()
+	if _, err := wc.Write([]byte("x")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+
+	got, err := io.ReadAll(rb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := strings.Repeat("hello", writers+1); string(got) != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRingBuffer_TryReadByte(t *testing.T) {
+	rb := New(2)
+	if _, err := rb.TryReadByte(); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	rb.WriteByte('a')
+	rb.WriteByte('b')
+	for _, want := range []byte("ab") {
+		b, err := rb.TryReadByte()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if b != want {
+			t.Fatalf("expected %q, got %q", want, b)
+		}
+	}
+
+	rb.mu.Lock()
+	if _, err := rb.TryReadByte(); err != ErrAcquireLock {
+		t.Fatalf("expected ErrAcquireLock, got %v", err)
+	}
+	rb.mu.Unlock()
+
+	rb.WriteByte('c')
+	rb.CloseWriter()
+	if b, err := rb.TryReadByte(); b != 'c' || err != io.EOF {
+		t.Fatalf("expected 'c', EOF; got %q, %v", b, err)
+	}
+	if _, err := rb.TryReadByte(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestRingBuffer_PeekTo(t *testing.T) {
+	rb := New(10)
+	var out bytes.Buffer
+	if _, err := rb.PeekTo(&out, 10); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+
+	rb.Write([]byte("01234567"))
+	rb.Read(make([]byte, 6))
+	rb.Write([]byte("abcdef"))
+
+	for n, want := range map[int]string{1: "6", 4: "67ab", 8: "67abcdef", 100: "67abcdef"} {
+		out.Reset()
+		written, err := rb.PeekTo(&out, n)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if written != int64(len(want)) || out.String() != want {
+			t.Fatalf("expected %d, %q; got %d, %q", len(want), want, written, out.String())
+		}
+	}
+	if rb.Length() != 8 {
+		t.Fatalf("expected 8 bytes left, got %d", rb.Length())
+	}
+	out.Reset()
+	if written, err := rb.PeekTo(&out, 0); err != nil || written != 0 || out.Len() != 0 {
+		t.Fatalf("expected nothing written, got %d, %v", written, err)
+	}
+	if _, err := rb.PeekTo(&out, -1); err != ErrInvalidLength {
+		t.Fatalf("expected ErrInvalidLength, got %v", err)
+	}
+
+	testErr := errors.New("test error")
+	if _, err := rb.PeekTo(errWriter{testErr}, 4); err != testErr {
+		t.Fatalf("expected %v, got %v", testErr, err)
+	}
+	if _, err := rb.Write([]byte("x")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+type errWriter struct{ err error }
+
+func (e errWriter) Write(p []byte) (int, error) { return 0, e.err }
+
+// slowReader returns one byte per read, after a delay, and never io.EOF.
+type slowReader struct{ d time.Duration }
+
+func (r slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.d)
+	if len(p) == 0 {
+		return 0, nil
+	}
+	p[0] = 'a'
+	return 1, nil
+}
+
+func TestRingBuffer_CopyContext(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	var out bytes.Buffer
+	n, err := New(64).CopyContext(ctx, &out, slowReader{time.Millisecond})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if n == 0 || int(n) != out.Len() {
+		t.Fatalf("expected the bytes copied before the deadline, got %d of %d", n, out.Len())
+	}
+
+	out.Reset()
+	n, err = New(64).CopyContext(context.Background(), &out, strings.NewReader("hello"))
+	if err != nil || n != 5 || out.String() != "hello" {
+		t.Fatalf("expected hello, got %q, %d, %v", out.String(), n, err)
+	}
+
+	cancel()
+	if _, err := New(64).CopyContext(ctx, &out, strings.NewReader("hello")); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestRingBuffer_CopyContextCancelAfterCopy(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	for i := 0; i < 100; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		rb := New(64)
+		var out bytes.Buffer
+		_, err := rb.CopyContext(ctx, &out, strings.NewReader("hello"))
+		cancel()
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+		runtime.Gosched()
+		if _, err := rb.CloseState(); err != io.EOF {
+			t.Fatalf("expected the ring buffer to stay closed with io.EOF, got %v", err)
+		}
+	}
+}
+
+func TestRingBuffer_CopyTo(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(10)
+	var out bytes.Buffer
+	rb.Write([]byte("01234567"))
+	rb.Read(make([]byte, 6))
+	rb.Write([]byte("abcdef"))
+
+	n, err := rb.CopyTo(&out, 4)
+	if err != nil || n != 4 || out.String() != "67ab" {
+		t.Fatalf("expected 4, nil, 67ab; got %d, %v, %q", n, err, out.String())
+	}
+	out.Reset()
+	n, err = rb.CopyTo(&out, 10)
+	if err != ErrIsEmpty || n != 4 || out.String() != "cdef" {
+		t.Fatalf("expected 4, ErrIsEmpty, cdef; got %d, %v, %q", n, err, out.String())
+	}
+
+	// Blocking mode waits for the remaining bytes.
+	rb = New(4).SetBlocking(true)
+	go func() {
+		rb.Write([]byte("hello world"))
+		rb.CloseWriter()
+	}()
+	out.Reset()
+	n, err = rb.CopyTo(&out, 7)
+	if err != nil || n != 7 || out.String() != "hello w" {
+		t.Fatalf("expected 7, nil, hello w; got %d, %v, %q", n, err, out.String())
+	}
+	out.Reset()
+	n, err = rb.CopyTo(&out, 7)
+	if err != io.EOF || n != 4 || out.String() != "orld" {
+		t.Fatalf("expected 4, EOF, orld; got %d, %v, %q", n, err, out.String())
+	}
+}
+
+func TestRingBuffer_WriteToNonBlocking(t *testing.T) {
+	rb := New(8)
+	var out bytes.Buffer
+	if n, err := rb.WriteTo(&out); err != nil || n != 0 {
+		t.Fatalf("expected nothing written, got %d, %v", n, err)
+	}
+	// Wrap the data around the end of the buffer.
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghij"))
+	if n, err := rb.WriteTo(&out); err != nil || n != 6 || out.String() != "efghij" {
+		t.Fatalf("expected efghij, got %q, %d, %v", out.String(), n, err)
+	}
+	if !rb.IsEmpty() {
+		t.Fatalf("expected an empty buffer, got length %d", rb.Length())
+	}
+
+	rb.Write([]byte("k"))
+	rb.CloseWriter()
+	out.Reset()
+	if n, err := rb.WriteTo(&out); err != nil || n != 1 || out.String() != "k" {
+		t.Fatalf("expected k, got %q, %d, %v", out.String(), n, err)
+	}
+}
+
+// sizeWriter records the size of each write.
+type sizeWriter struct{ sizes []int }
+
+func (w *sizeWriter) Write(p []byte) (int, error) {
+	w.sizes = append(w.sizes, len(p))
+	return len(p), nil
+}
+
+func TestRingBuffer_SetWriteToChunk(t *testing.T) {
+	rb := New(16).SetWriteToChunk(3)
+	rb.Write([]byte("0123456789"))
+	var w sizeWriter
+	if n, err := rb.CopyTo(&w, 10); err != nil || n != 10 {
+		t.Fatalf("expected 10 bytes, got %d, %v", n, err)
+	}
+	if fmt.Sprint(w.sizes) != "[3 3 3 1]" {
+		t.Fatalf("expected writes of 3, 3, 3 and 1 bytes, got %v", w.sizes)
+	}
+
+	// The default writes everything buffered to small buffers at once.
+	rb.SetWriteToChunk(0)
+	rb.Write([]byte("abcdef"))
+	w.sizes = nil
+	rb.CopyTo(&w, 6)
+	if fmt.Sprint(w.sizes) != "[6]" {
+		t.Fatalf("expected a single write of 6 bytes, got %v", w.sizes)
+	}
+}
+
+func TestRingBuffer_CopyN(t *testing.T) {
+	rb := New(16)
+	rb.Write([]byte("chunk1chunk2"))
+	var out bytes.Buffer
+	n, err := rb.CopyTo(&out, 6)
+	if err != nil || n != 6 || out.String() != "chunk1" {
+		t.Fatalf("expected 6, nil, chunk1; got %d, %v, %q", n, err, out.String())
+	}
+	if rb.Length() != 6 {
+		t.Fatalf("expected the rest to stay buffered, got length %d", rb.Length())
+	}
+
+	out.Reset()
+	n, err = CopyN(&out, rb, 4)
+	if err != nil || n != 4 || out.String() != "chun" || rb.Length() != 2 {
+		t.Fatalf("expected 4, nil, chun; got %d, %v, %q", n, err, out.String())
+	}
+	n, err = CopyN(rb, strings.NewReader("abcdef"), 3)
+	if err != nil || n != 3 || string(rb.Bytes(nil)) != "k2abc" {
+		t.Fatalf("expected 3, nil, k2abc; got %d, %v, %q", n, err, rb.Bytes(nil))
+	}
+}
+
+func TestRingBuffer_ReadFromN(t *testing.T) {
+	rb := New(16)
+	src := strings.NewReader("headerbody")
+	n, err := rb.ReadFromN(src, 6)
+	if err != nil || n != 6 || string(rb.Bytes(nil)) != "header" {
+		t.Fatalf("expected 6, nil, header; got %d, %v, %q", n, err, rb.Bytes(nil))
+	}
+	if src.Len() != 4 {
+		t.Fatalf("expected the source not to be read further, got %d bytes left", src.Len())
+	}
+	n, err = rb.ReadFromN(src, 8)
+	if err != io.EOF || n != 4 {
+		t.Fatalf("expected 4, EOF; got %d, %v", n, err)
+	}
+
+	// Non-blocking mode stops when the buffer is full.
+	n, err = rb.ReadFromN(strings.NewReader("0123456789"), 10)
+	if err != ErrIsFull || n != 6 {
+		t.Fatalf("expected 6, ErrIsFull; got %d, %v", n, err)
+	}
+}
+
+func TestRingBuffer_Fill(t *testing.T) {
+	rb := New(10).WithHash(crc32.NewIEEE())
+	rb.Write([]byte("0123"))
+	rb.Read(make([]byte, 3))
+
+	n, err := rb.Fill('x', 7)
+	if err != nil || n != 7 {
+		t.Fatalf("expected 7, nil; got %d, %v", n, err)
+	}
+	n, err = rb.Fill('y', 5)
+	if err != ErrTooMuchDataToWrite || n != 2 {
+		t.Fatalf("expected 2, ErrTooMuchDataToWrite; got %d, %v", n, err)
+	}
+	if got := string(rb.Bytes(nil)); got != "3xxxxxxxyy" {
+		t.Fatalf("expected 3xxxxxxxyy, got %q", got)
+	}
+	if _, err = rb.Fill('z', 1); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	want := crc32.NewIEEE()
+	want.Write([]byte("0123xxxxxxxyy"))
+	if !bytes.Equal(rb.Sum(nil), want.Sum(nil)) {
+		t.Fatalf("filled bytes are not hashed")
+	}
+
+	// Blocking mode waits for space.
+	defer timeout(5 * time.Second)()
+	rb = New(10).SetBlocking(true)
+	go func() {
+		rb.Fill('a', 1000)
+		rb.CloseWriter()
+	}()
+	got, err := io.ReadAll(rb)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(got) != strings.Repeat("a", 1000) {
+		t.Fatalf("unexpected data: %q", got)
+	}
+}
+
+func TestRingBuffer_WriteZeros(t *testing.T) {
+	rb := New(10)
+	rb.Write([]byte("ab"))
+	n, err := rb.WriteZeros(3)
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3, nil; got %d, %v", n, err)
+	}
+	rb.Write([]byte("cd"))
+	if got := rb.Bytes(nil); !bytes.Equal(got, []byte("ab\x00\x00\x00cd")) {
+		t.Fatalf("unexpected data: %q", got)
+	}
+	n, err = rb.WriteZeros(10)
+	if err != ErrTooMuchDataToWrite || n != 3 {
+		t.Fatalf("expected 3, ErrTooMuchDataToWrite; got %d, %v", n, err)
+	}
+}
+
+func TestRingBuffer_FlushContext(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(10).SetBlocking(true)
+	if err := rb.FlushContext(context.Background()); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	rb.Write([]byte("hello"))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := rb.FlushContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if rb.Length() != 5 {
+		t.Fatalf("expected length 5, got %d", rb.Length())
+	}
+	if _, err := rb.Write([]byte("!")); err != nil {
+		t.Fatalf("expected buffer to remain usable, got %v", err)
+	}
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		buf := make([]byte, 6)
+		io.ReadFull(rb, buf)
+	}()
+	if err := rb.FlushContext(context.Background()); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+
+	rb = New(10)
+	rb.Write([]byte("hello"))
+	if err := rb.FlushContext(context.Background()); err != ErrIsNotEmpty {
+		t.Fatalf("expected ErrIsNotEmpty, got %v", err)
+	}
+}
+
+func TestRingBuffer_ReadFromInMemory(t *testing.T) {
+	sources := map[string]func() io.Reader{
+		"bytes.Reader":   func() io.Reader { return bytes.NewReader([]byte("0123456789abcdef")) },
+		"bytes.Buffer":   func() io.Reader { return bytes.NewBufferString("0123456789abcdef") },
+		"strings.Reader": func() io.Reader { return strings.NewReader("0123456789abcdef") },
+	}
+	for name, src := range sources {
+		t.Run(name, func(t *testing.T) {
+			rb := New(10)
+			rb.Write([]byte("xyz"))
+			rb.Read(make([]byte, 3))
+			rd := src()
+			n, err := rb.ReadFrom(rd)
+			if err != ErrIsFull || n != 10 {
+				t.Fatalf("expected 10 bytes and ErrIsFull, got %d, %v", n, err)
+			}
+			if got := string(rb.Bytes(nil)); got != "0123456789" {
+				t.Fatalf("expected 0123456789, got %q", got)
+			}
+			rb.Read(make([]byte, 10))
+			n, err = rb.ReadFrom(rd)
+			if err != nil || n != 6 {
+				t.Fatalf("expected 6 bytes, got %d, %v", n, err)
+			}
+			if got := string(rb.Bytes(nil)); got != "abcdef" {
+				t.Fatalf("expected abcdef, got %q", got)
+			}
+		})
+	}
+}
+
+func TestRingBuffer_WriteToBytesBuffer(t *testing.T) {
+	rb := New(10)
+	rb.Write([]byte("0123456"))
+	rb.Read(make([]byte, 5))
+	rb.Write([]byte("789ab"))
+	var buf bytes.Buffer
+	n, err := rb.WriteToAvailable(&buf)
+	if err != nil || n != 7 {
+		t.Fatalf("expected 7 bytes, got %d, %v", n, err)
+	}
+	if buf.String() != "56789ab" {
+		t.Fatalf("expected 56789ab, got %q", buf.String())
+	}
+
+	var _ io.WriterTo = rb.ReadHandle()
+	var _ io.ReaderFrom = rb.WriteHandle()
+	if _, ok := rb.WriteCloser().(io.ReaderFrom); !ok {
+		t.Fatalf("expected WriteCloser to implement io.ReaderFrom")
+	}
+}
+
+func TestRingBuffer_ReadFull(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8).SetBlocking(true)
+	go func() {
+		for _, s := range []string{"ab", "cd", "efghijklmn", "op"} {
+			time.Sleep(5 * time.Millisecond)
+			rb.Write([]byte(s))
+		}
+		rb.CloseWriter()
+	}()
+
+	buf := make([]byte, 4)
+	if n, err := rb.ReadFull(buf); err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", buf[:n], err)
+	}
+	// Larger than the buffer.
+	buf = make([]byte, 10)
+	if n, err := rb.ReadFull(buf); err != nil || string(buf[:n]) != "efghijklmn" {
+		t.Fatalf("expected efghijklmn, got %q, %v", buf[:n], err)
+	}
+	buf = make([]byte, 4)
+	if n, err := rb.ReadFull(buf); err != io.ErrUnexpectedEOF || string(buf[:n]) != "op" {
+		t.Fatalf("expected op and io.ErrUnexpectedEOF, got %q, %v", buf[:n], err)
+	}
+	if n, err := rb.ReadFull(buf); err != io.EOF || n != 0 {
+		t.Fatalf("expected io.EOF, got %d, %v", n, err)
+	}
+}
+
+func TestRingBuffer_ReadFullNonBlocking(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abc"))
+	buf := make([]byte, 4)
+	if n, err := rb.ReadFull(buf); err != ErrIsEmpty || n != 0 {
+		t.Fatalf("expected ErrIsEmpty, got %d, %v", n, err)
+	}
+	if rb.Length() != 3 {
+		t.Fatalf("expected nothing to be read, got length %d", rb.Length())
+	}
+	rb.Write([]byte("d"))
+	if n, err := rb.ReadFull(buf); err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", buf[:n], err)
+	}
+}
+
+func TestRingBuffer_WriteCloserClosedHandle(t *testing.T) {
+	rb := New(100)
+	wc := rb.WriteHandle()
+	dup := wc.Dup()
+	dup.Abort()
+	if _, err := dup.WriteString("x"); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+	if err := dup.WriteByte('x'); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+	if _, err := dup.ReadFrom(strings.NewReader("x")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+	if err := dup.CloseWithError(errors.New("test")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+	// The writer is still open for the other handle.
+	if _, err := wc.WriteString("hello"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if rb.Length() != 5 {
+		t.Fatalf("expected length 5, got %d", rb.Length())
+	}
+}
@@ -0,0 +1,167 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+type point struct{ X, Y int }
+
+func TestRing(t *testing.T) {
+	q := NewRing[point](4)
+	n, err := q.Write([]point{{1, 1}, {2, 2}, {3, 3}})
+	if err != nil || n != 3 {
+		t.Fatalf("expected 3 elements written, got %d, %v", n, err)
+	}
+	n, err = q.Write([]point{{4, 4}, {5, 5}})
+	if err != ErrTooMuchDataToWrite || n != 1 {
+		t.Fatalf("expected 1 element and ErrTooMuchDataToWrite, got %d, %v", n, err)
+	}
+	if err := q.WriteOne(point{6, 6}); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+
+	p := make([]point, 2)
+	if n, err := q.Peek(p); err != nil || n != 2 || p[0] != (point{1, 1}) {
+		t.Fatalf("expected to peek {1 1}, got %d, %v, %v", n, p, err)
+	}
+	if n, err := q.Read(p); err != nil || n != 2 || p[1] != (point{2, 2}) {
+		t.Fatalf("expected to read {1 1} {2 2}, got %d, %v, %v", n, p, err)
+	}
+	q.Write([]point{{7, 7}, {8, 8}}) // wraps
+	if q.Length() != 4 || !q.IsFull() {
+		t.Fatalf("expected a full ring, got length %d", q.Length())
+	}
+
+	var got []point
+	for {
+		v, err := q.TryReadOne()
+		if err == ErrIsEmpty {
+			break
+		}
+		if err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+		got = append(got, v)
+	}
+	want := []point{{3, 3}, {4, 4}, {7, 7}, {8, 8}}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, got)
+		}
+	}
+}
+
+func TestRingClearsRead(t *testing.T) {
+	q := NewRing[*point](2)
+	q.WriteOne(&point{1, 1})
+	q.ReadOne()
+	if q.buf[0] != nil {
+		t.Fatalf("expected read element to be cleared")
+	}
+}
+
+func TestRingBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	q := NewRing[int](3).SetBlocking(true)
+	go func() {
+		for i := 0; i < 100; i++ {
+			if err := q.WriteOne(i); err != nil {
+				t.Errorf("write: %v", err)
+				return
+			}
+		}
+		q.CloseWriter()
+	}()
+	for i := 0; ; i++ {
+		v, err := q.ReadOne()
+		if err == io.EOF {
+			if i != 100 {
+				t.Fatalf("expected 100 elements, got %d", i)
+			}
+			break
+		}
+		if err != nil || v != i {
+			t.Fatalf("expected %d, got %d, %v", i, v, err)
+		}
+	}
+	if err := q.WriteOne(1); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+}
+
+func TestRingCloseWithError(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	q := NewRing[int](1).SetBlocking(true)
+	errClosed := errors.New("closed")
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		q.CloseWithError(errClosed)
+	}()
+	if _, err := q.ReadOne(); err != errClosed {
+		t.Fatalf("expected errClosed, got %v", err)
+	}
+	q.Reset()
+	if err := q.WriteOne(1); err != nil {
+		t.Fatalf("expected nil after Reset, got %v", err)
+	}
+}
+
+func TestRingZeroSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		q := NewRing[int](size).SetBlocking(true)
+		if err := q.WriteOne(1); err != ErrTooMuchDataToWrite {
+			t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+		}
+		if q.Capacity() != 0 {
+			t.Fatalf("expected capacity 0, got %d", q.Capacity())
+		}
+	}
+}
+
+func TestRingOverwrite(t *testing.T) {
+	q := NewRing[int](3).SetOverwrite(true)
+	q.Write([]int{1, 2})
+	if n, err := q.Write([]int{3, 4}); err != nil || n != 2 {
+		t.Fatalf("expected 2 elements written, got %d, %v", n, err)
+	}
+	buf := make([]int, 3)
+	if n, _ := q.Peek(buf); n != 3 || buf[0] != 2 || buf[2] != 4 {
+		t.Fatalf("expected [2 3 4], got %v", buf[:n])
+	}
+	if n, err := q.Write([]int{5, 6, 7, 8, 9}); err != nil || n != 5 {
+		t.Fatalf("expected 5 elements written, got %d, %v", n, err)
+	}
+	if n, _ := q.Read(buf); n != 3 || buf[0] != 7 || buf[2] != 9 {
+		t.Fatalf("expected [7 8 9], got %v", buf[:n])
+	}
+}
+
+func TestRingResetWakesUp(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	q := NewRing[int](1).SetBlocking(true)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.Reset()
+	}()
+	if _, err := q.ReadOne(); err != ErrReset {
+		t.Fatalf("expected ErrReset, got %v", err)
+	}
+
+	q.WriteOne(1)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		q.Reset()
+	}()
+	if err := q.WriteOne(2); err != ErrReset {
+		t.Fatalf("expected ErrReset, got %v", err)
+	}
+	if q.Length() != 0 {
+		t.Fatalf("expected an empty ring, got length %d", q.Length())
+	}
+}
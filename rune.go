@@ -0,0 +1,81 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"unicode/utf8"
+)
+
+// ErrInvalidUnreadRune is returned by UnreadRune when the last read was not a ReadRune
+// or the rune cannot be pushed back.
+var ErrInvalidUnreadRune = errors.New("invalid use of UnreadRune")
+
+// ReadRune reads a single UTF-8 encoded rune and returns it with its size in bytes,
+// which together with UnreadRune implements io.RuneScanner.
+// Runes that wrap around the end of the backing array are decoded like any other.
+// If the encoded rune is invalid, it consumes one byte and returns utf8.RuneError and 1.
+// In blocking mode it waits for a complete rune, and otherwise it returns ErrIsEmpty,
+// without reading anything, if none is buffered.
+// A rune left incomplete when the writer is closed is invalid.
+func (r *RingBuffer) ReadRune() (ch rune, size int, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	for {
+		if err := r.readErr(true); err != nil {
+			return 0, 0, err
+		}
+		var b [utf8.UTFMax]byte
+		n := r.peekAt(b[:], 0)
+		if n > 0 && (utf8.FullRune(b[:n]) || r.isFull || r.err == io.EOF || r.sealed) {
+			ch, size = utf8.DecodeRune(b[:n])
+			r.discard(size)
+			r.runeSize = size
+			return ch, size, nil
+		}
+		if n == 0 {
+			r.ctr.emptyHits++
+		}
+		if !r.block {
+			return 0, 0, ErrIsEmpty
+		}
+		if !r.waitWrite() {
+			return 0, 0, context.DeadlineExceeded
+		}
+	}
+}
+
+// UnreadRune pushes back the last rune read by ReadRune, so the next read returns it again.
+// ErrInvalidUnreadRune is returned if the last read was not a ReadRune,
+// or in the cases UnreadByte fails.
+func (r *RingBuffer) UnreadRune() error {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.runeSize == 0 || !r.pushBack(r.runeSize) {
+		return ErrInvalidUnreadRune
+	}
+	return nil
+}
+
+// WriteRune writes the UTF-8 encoding of c and returns the number of bytes written.
+// Like WriteV, the encoding is written completely or not at all,
+// so it is never split by the data of a concurrent writer.
+func (r *RingBuffer) WriteRune(c rune) (size int, err error) {
+	if uint32(c) < utf8.RuneSelf {
+		if err = r.WriteByte(byte(c)); err != nil {
+			return 0, err
+		}
+		return 1, nil
+	}
+	var b [utf8.UTFMax]byte
+	n := utf8.EncodeRune(b[:], c)
+	nn, err := r.WriteV(net.Buffers{b[:n]})
+	return int(nn), err
+}
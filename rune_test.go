@@ -0,0 +1,84 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+var _ io.RuneScanner = (*RingBuffer)(nil)
+
+func TestRingBuffer_Runes(t *testing.T) {
+	rb := New(8)
+	for _, c := range "aé€" {
+		if _, err := rb.WriteRune(c); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	}
+	if rb.Length() != 6 {
+		t.Fatalf("expected length 6, got %d", rb.Length())
+	}
+	if _, err := rb.WriteRune('😀'); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+
+	for _, want := range []struct {
+		c    rune
+		size int
+	}{{'a', 1}, {'é', 2}} {
+		c, size, err := rb.ReadRune()
+		if err != nil || c != want.c || size != want.size {
+			t.Fatalf("expected %c of %d bytes, got %c of %d bytes, %v", want.c, want.size, c, size, err)
+		}
+	}
+	// The emoji wraps around the end of the backing array.
+	rb.WriteRune('😀')
+	rb.ReadRune()
+	c, size, _ := rb.ReadRune()
+	if c != '😀' || size != 4 {
+		t.Fatalf("expected 😀, got %c of %d bytes", c, size)
+	}
+	if err := rb.UnreadRune(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := rb.UnreadRune(); err != ErrInvalidUnreadRune {
+		t.Fatalf("expected ErrInvalidUnreadRune, got %v", err)
+	}
+	if c, _, _ := rb.ReadRune(); c != '😀' {
+		t.Fatalf("expected 😀 again, got %c", c)
+	}
+	if _, _, err := rb.ReadRune(); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+
+	rb.Write([]byte("ab"))
+	rb.Read(make([]byte, 2))
+	if err := rb.UnreadRune(); err != ErrInvalidUnreadRune {
+		t.Fatalf("expected ErrInvalidUnreadRune after Read, got %v", err)
+	}
+}
+
+func TestRingBuffer_ReadRuneIncomplete(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	enc := []byte("€")
+	rb := New(8)
+	rb.Write(enc[:2])
+	if _, _, err := rb.ReadRune(); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	rb.CloseWriter()
+	if c, size, _ := rb.ReadRune(); c != utf8.RuneError || size != 1 {
+		t.Fatalf("expected RuneError of 1 byte, got %c of %d bytes", c, size)
+	}
+
+	rb = New(8).SetBlocking(true)
+	rb.Write(enc[:1])
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.Write(enc[1:])
+	}()
+	if c, _, err := rb.ReadRune(); c != '€' || err != nil {
+		t.Fatalf("expected €, got %c, %v", c, err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "errors"
+
+var (
+	// ErrSealed is returned when writing to a sealed ringbuffer.
+	ErrSealed = errors.New("ringbuffer is sealed")
+
+	// ErrNotSealed is returned by Rewind when the ringbuffer is not sealed.
+	ErrNotSealed = errors.New("ringbuffer is not sealed")
+)
+
+// Seal freezes the contents of the ring buffer.
+// All further writes are rejected with ErrSealed, until Reset is called.
+// Unlike CloseWriter the buffer is not closed, so the error state
+// is left untouched and no close is reported to other holders.
+//
+// Reads and peeks continue to work on the remaining data,
+// and return io.EOF once it has been consumed.
+// Rewind can be used to read the sealed contents again.
+func (r *RingBuffer) Seal() {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.sealed {
+		return
+	}
+	r.sealed = true
+	r.sealR = r.r
+	r.sealLen = r.length()
+	r.wakeWatchers()
+	if r.block {
+		// Wake up blocked readers and writers.
+		r.readCond.Broadcast()
+		r.writeCond.Broadcast()
+	}
+}
+
+// IsSealed returns true when the ring buffer has been sealed.
+func (r *RingBuffer) IsSealed() bool {
+	r.mu.Lock()
+	defer r.unlock()
+	return r.sealed
+}
+
+// Rewind moves the read pointer back to where it was when the ring buffer was sealed,
+// so all data that was buffered at that time can be read again.
+// ErrNotSealed is returned if the ring buffer is not sealed.
+func (r *RingBuffer) Rewind() error {
+	r.mu.Lock()
+	defer r.unlock()
+	if !r.sealed {
+		return ErrNotSealed
+	}
+	r.r = r.sealR
+	r.isFull = r.sealLen == r.size
+	return nil
+}
@@ -0,0 +1,80 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Seal(t *testing.T) {
+	rb := New(10)
+	if err := rb.Rewind(); err != ErrNotSealed {
+		t.Fatalf("expected ErrNotSealed, got %v", err)
+	}
+	rb.Write([]byte("01234567"))
+	rb.Read(make([]byte, 6))
+	rb.Write([]byte("abcdefgh"))
+	rb.Seal()
+	if !rb.IsSealed() {
+		t.Fatalf("expect IsSealed is true but got false")
+	}
+
+	if _, err := rb.Write([]byte("x")); err != ErrSealed {
+		t.Fatalf("expected ErrSealed, got %v", err)
+	}
+	if err := rb.WriteByte('x'); err != ErrSealed {
+		t.Fatalf("expected ErrSealed, got %v", err)
+	}
+	if _, err := rb.TryWrite([]byte("x")); err != ErrSealed {
+		t.Fatalf("expected ErrSealed, got %v", err)
+	}
+	if _, err := rb.ReadFrom(strings.NewReader("x")); err != ErrSealed {
+		t.Fatalf("expected ErrSealed, got %v", err)
+	}
+	if reason, _ := rb.CloseState(); reason != NotClosed {
+		t.Fatalf("expected NotClosed, got %v", reason)
+	}
+
+	for i := 0; i < 2; i++ {
+		got, err := io.ReadAll(rb)
+		if err != nil || string(got) != "67abcdefgh" {
+			t.Fatalf("expected 67abcdefgh, nil; got %q, %v", got, err)
+		}
+		if err := rb.Rewind(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	rb.Reset()
+	if rb.IsSealed() {
+		t.Fatalf("expect IsSealed is false after Reset")
+	}
+	if _, err := rb.Write([]byte("x")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestRingBuffer_SealUnblocks(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true)
+	done := make(chan error)
+	go func() {
+		_, err := rb.Write([]byte("hello world"))
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	rb.Seal()
+	if err := <-done; err != ErrSealed {
+		t.Fatalf("expected ErrSealed, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if _, err := rb.WriteTo(&out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.String() != "hell" {
+		t.Fatalf("expected hell, got %q", out.String())
+	}
+}
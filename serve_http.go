@@ -0,0 +1,159 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ServeHTTP streams the data written to the ring buffer to the client,
+// like tail -f, so a live in-memory log can be exposed over HTTP:
+//
+//	http.Handle("/debug/logbuffer", rb)
+//
+// The data is not consumed, so ServeHTTP can serve any number of clients
+// while the ring buffer is read normally, and it is best suited to ring buffers
+// in overwrite mode that retain the most recent data.
+// A client that falls behind skips the data that is no longer buffered.
+//
+// By default the response starts with the buffered data, the retention window,
+// and the query parameter from=now starts it with the data written from now on.
+// The query parameter follow=0 ends the response once the buffered data has been sent,
+// and otherwise it ends when the writer is closed or the client disconnects.
+// The data is sent as a chunked text/plain response, flushed as it is written,
+// or as server-sent events with one event per line if the client accepts
+// text/event-stream or the query parameter sse=1 is set.
+// The data of an encrypted ring buffer is not served,
+// the response is 403 Forbidden with ErrEncrypted, see SetEncryptionKey.
+func (r *RingBuffer) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if r.encrypted() {
+		http.Error(w, ErrEncrypted.Error(), http.StatusForbidden)
+		return
+	}
+	sse := req.FormValue("sse") == "1" || strings.Contains(req.Header.Get("Accept"), "text/event-stream")
+	follow := req.FormValue("follow") != "0"
+	if sse {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	flusher, _ := w.(http.Flusher)
+
+	r.mu.Lock()
+	off := r.consumed()
+	if req.FormValue("from") == "now" {
+		off = r.written
+	}
+	r.unlock()
+
+	buf := make([]byte, 32*1024)
+	var line []byte // Incomplete last line of an event stream.
+	for {
+		n, wait, err := r.tail(buf, &off)
+		if n > 0 {
+			var werr error
+			if sse {
+				line, werr = writeEvents(w, append(line, buf[:n]...), len(buf))
+			} else {
+				_, werr = w.Write(buf[:n])
+			}
+			if werr != nil {
+				return
+			}
+			continue
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if err != nil || !follow {
+			break
+		}
+		select {
+		case <-wait:
+		case <-req.Context().Done():
+			return
+		}
+	}
+	if sse && len(line) > 0 {
+		writeEvents(w, append(line, '\n'), 0)
+	}
+}
+
+// writeEvents writes the complete lines of p to w as server-sent events,
+// and returns the incomplete last line, unless it is longer than max.
+func writeEvents(w io.Writer, p []byte, max int) (rest []byte, err error) {
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			if len(p) <= max {
+				return p, nil
+			}
+			i = len(p)
+		}
+		if _, err := io.WriteString(w, "data: "); err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(bytes.TrimSuffix(p[:i], []byte("\r"))); err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(w, "\n\n"); err != nil {
+			return nil, err
+		}
+		if i == len(p) {
+			return nil, nil
+		}
+		p = p[i+1:]
+	}
+}
+
+// tail copies the data written after the absolute offset *off into p,
+// without consuming it, and moves *off past it.
+// If the data at *off is no longer buffered, it continues with the oldest buffered data.
+// If there is nothing to copy, it returns a channel that is closed
+// when data is written or the ring buffer is closed,
+// or io.EOF if no more data will be written.
+// ErrEncrypted is returned if the ring buffer has been encrypted since.
+func (r *RingBuffer) tail(p []byte, off *int64) (n int, wait <-chan struct{}, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.enc != nil {
+		return 0, nil, ErrEncrypted
+	}
+	if start := r.consumed(); *off < start || *off > r.written {
+		*off = start
+	}
+	if avail := r.written - *off; avail > 0 {
+		if int64(len(p)) > avail {
+			p = p[:avail]
+		}
+		n = r.peekAt(p, int(*off-r.consumed()))
+		*off += int64(n)
+		return n, nil, nil
+	}
+	if r.err != nil {
+		return 0, nil, r.err
+	}
+	if r.sealed || r.rClosed != nil {
+		return 0, nil, io.EOF
+	}
+	if r.watch == nil {
+		r.watch = make(chan struct{})
+	}
+	return 0, r.watch, nil
+}
+
+// wakeWatchers wakes the goroutines waiting in ServeHTTP for data to be written.
+// Must be called when locked.
+func (r *RingBuffer) wakeWatchers() {
+	if r.watch != nil {
+		close(r.watch)
+		r.watch = nil
+	}
+}
@@ -0,0 +1,70 @@
+package ringbuffer
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_ServeHTTP(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(64).SetOverwrite(true)
+	rb.Write([]byte("line1\nline2\npartial"))
+	// Data that was read is not served.
+	rb.Read(make([]byte, 6))
+
+	rec := httptest.NewRecorder()
+	rb.ServeHTTP(rec, httptest.NewRequest("GET", "/?follow=0", nil))
+	if body := rec.Body.String(); body != "line2\npartial" {
+		t.Fatalf("expected the buffered data, got %q", body)
+	}
+
+	rec = httptest.NewRecorder()
+	rb.ServeHTTP(rec, httptest.NewRequest("GET", "/?follow=0&sse=1", nil))
+	if body := rec.Body.String(); body != "data: line2\n\ndata: partial\n\n" {
+		t.Fatalf("expected events, got %q", body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	// A disconnected client ends the response.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	rec = httptest.NewRecorder()
+	rb.ServeHTTP(rec, httptest.NewRequest("GET", "/?from=now", nil).WithContext(ctx))
+	if rec.Body.Len() != 0 {
+		t.Fatalf("expected no data, got %q", rec.Body.String())
+	}
+	if rb.Length() != 13 {
+		t.Fatalf("expected the data not to be consumed, got length %d", rb.Length())
+	}
+}
+
+func TestRingBuffer_ServeHTTPFollow(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(64).SetOverwrite(true)
+	rb.Write([]byte("old\n"))
+	srv := httptest.NewServer(rb)
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "?from=now")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	go func() {
+		for _, s := range []string{"hello ", "world\n"} {
+			time.Sleep(10 * time.Millisecond)
+			rb.Write([]byte(s))
+		}
+		rb.CloseWriter()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "hello world\n" {
+		t.Fatalf("expected hello world, got %q, %v", body, err)
+	}
+}
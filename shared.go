@@ -0,0 +1,263 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"unsafe"
+)
+
+// sharedMagic identifies a file created by NewShared.
+const sharedMagic = "RBSHARED"
+
+// Layout of the header of a shared ring buffer.
+// Fields written by different processes are kept on separate cache lines.
+const (
+	sharedSize    = 8             // uint64 size of the data.
+	sharedHead    = cacheLine     // uint64 total bytes read.
+	sharedTail    = 2 * cacheLine // uint64 total bytes written.
+	sharedData    = 3 * cacheLine // uint32 bumped when data is written, and its waiters.
+	sharedSpace   = 4 * cacheLine // uint32 bumped when data is read, and its waiters.
+	sharedClosed  = 5 * cacheLine // uint32 set when the writer is closed.
+	sharedHeader  = 8 * cacheLine // Size of the header.
+	sharedWaiters = 4             // Offset of the waiters counter after a sequence.
+)
+
+// SharedRing is a ring buffer in shared memory, for a writer and a reader
+// in different processes, typically mapped from a file in /dev/shm.
+// Like SPSC, there must be exactly one writer and one reader, which exchange
+// their positions with atomic operations instead of a lock.
+// Unlike SPSC, Write and Read block, and wait for each other with futexes on Linux,
+// so both processes can sleep in the kernel; other platforms poll.
+// Shared ring buffers, like NewMmap, are only supported on Unix platforms.
+type SharedRing struct {
+	m    []byte
+	buf  []byte
+	size uint64
+
+	head, tail        *atomic.Uint64
+	dataSeq, dataWait *atomic.Uint32
+	spaceSeq, spWait  *atomic.Uint32
+	closed            *atomic.Uint32
+}
+
+// NewShared maps the shared ring buffer at path, creating the file with room
+// for size bytes of data if it doesn't exist.
+// Both processes call NewShared with the same path and size;
+// ErrMmapSize is returned if the file holds a ring buffer of another size.
+// The file is created and initialized under a temporary name and then linked to path,
+// so a process opening it concurrently never sees it half initialized.
+// A file left by a previous run keeps its data and closed writer; Reset clears them.
+// Close unmaps it.
+func NewShared(path string, size int) (*SharedRing, error) {
+	if size <= 0 {
+		return nil, ErrBufferTooSmall
+	}
+	n := sharedHeader + size
+	for {
+		f, err := os.OpenFile(path, os.O_RDWR, 0)
+		if os.IsNotExist(err) {
+			if err = createShared(path, size); err == nil || os.IsExist(err) {
+				// Created by this process or another one, open it.
+				continue
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return openShared(f, n, size)
+	}
+}
+
+// createShared creates the initialized file of a shared ring buffer at path,
+// and returns an error satisfying os.IsExist if another process created it first.
+func createShared(path string, size int) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if err := f.Chmod(0o600); err != nil {
+		return err
+	}
+	if err := f.Truncate(int64(sharedHeader + size)); err != nil {
+		return err
+	}
+	// The size is stored in native byte order, since it is loaded atomically.
+	hdr := struct {
+		magic [sharedSize]byte
+		size  uint64
+	}{size: uint64(size)}
+	copy(hdr.magic[:], sharedMagic)
+	if _, err := f.WriteAt((*[unsafe.Sizeof(hdr)]byte)(unsafe.Pointer(&hdr))[:], 0); err != nil {
+		return err
+	}
+	// Unlike a rename, a link fails if path exists.
+	return os.Link(f.Name(), path)
+}
+
+// openShared maps the initialized file of a shared ring buffer of n bytes.
+func openShared(f *os.File, n, size int) (*SharedRing, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() != int64(n) {
+		return nil, ErrMmapSize
+	}
+	m, err := mmapFile(f, n)
+	if err != nil {
+		return nil, err
+	}
+	sizeField := (*atomic.Uint64)(unsafe.Pointer(&m[sharedSize]))
+	if string(m[:sharedSize]) != sharedMagic || sizeField.Load() != uint64(size) {
+		munmapFile(m)
+		return nil, ErrInvalidEncoding
+	}
+	return &SharedRing{
+		m:        m,
+		buf:      m[sharedHeader:],
+		size:     uint64(size),
+		head:     (*atomic.Uint64)(unsafe.Pointer(&m[sharedHead])),
+		tail:     (*atomic.Uint64)(unsafe.Pointer(&m[sharedTail])),
+		dataSeq:  (*atomic.Uint32)(unsafe.Pointer(&m[sharedData])),
+		dataWait: (*atomic.Uint32)(unsafe.Pointer(&m[sharedData+sharedWaiters])),
+		spaceSeq: (*atomic.Uint32)(unsafe.Pointer(&m[sharedSpace])),
+		spWait:   (*atomic.Uint32)(unsafe.Pointer(&m[sharedSpace+sharedWaiters])),
+		closed:   (*atomic.Uint32)(unsafe.Pointer(&m[sharedClosed])),
+	}, nil
+}
+
+// Reset discards the data and reopens the writer of a ring buffer left by a previous run,
+// for example after the writer was closed or a process crashed.
+// It must only be called while no other process uses the ring buffer.
+func (s *SharedRing) Reset() {
+	s.head.Store(0)
+	s.tail.Store(0)
+	s.closed.Store(0)
+	s.dataWait.Store(0)
+	s.spWait.Store(0)
+}
+
+// Write writes all of p to the ring buffer, waiting for the reader to free space
+// as needed, and returns ErrWriteOnClosed if the writer has been closed.
+// Write must only be called by the writer.
+func (s *SharedRing) Write(p []byte) (n int, err error) {
+	for n < len(p) {
+		if s.closed.Load() != 0 {
+			return n, ErrWriteOnClosed
+		}
+		seq := s.spaceSeq.Load()
+		tail := s.tail.Load()
+		free := s.size - (tail - s.head.Load())
+		if free == 0 {
+			if err := sharedWait(s.spaceSeq, s.spWait, seq); err != nil {
+				return n, err
+			}
+			continue
+		}
+		chunk := p[n:]
+		if uint64(len(chunk)) > free {
+			chunk = chunk[:free]
+		}
+		w := tail % s.size
+		c := copy(s.buf[w:], chunk)
+		copy(s.buf, chunk[c:])
+		// Publish the data to the reader.
+		s.tail.Store(tail + uint64(len(chunk)))
+		n += len(chunk)
+		if err := sharedWake(s.dataSeq, s.dataWait); err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// Read reads up to len(p) bytes into p, waiting for the writer if there is no data.
+// It returns io.EOF once the writer is closed and all data has been read.
+// Read must only be called by the reader.
+func (s *SharedRing) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for {
+		seq := s.dataSeq.Load()
+		head := s.head.Load()
+		avail := s.tail.Load() - head
+		if avail == 0 {
+			if s.closed.Load() != 0 {
+				// Data may have been written before closing.
+				if s.tail.Load() == head {
+					return 0, io.EOF
+				}
+				continue
+			}
+			if err := sharedWait(s.dataSeq, s.dataWait, seq); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		if uint64(len(p)) > avail {
+			p = p[:avail]
+		}
+		r := head % s.size
+		c := copy(p, s.buf[r:])
+		copy(p[c:], s.buf)
+		// Release the space to the writer.
+		s.head.Store(head + uint64(len(p)))
+		return len(p), sharedWake(s.spaceSeq, s.spWait)
+	}
+}
+
+// CloseWriter closes the writer.
+// The reader reads the remaining data and then io.EOF.
+// An error is returned if the processes waiting for the ring buffer could not be woken up.
+func (s *SharedRing) CloseWriter() error {
+	s.closed.Store(1)
+	err := sharedWake(s.dataSeq, s.dataWait)
+	if werr := sharedWake(s.spaceSeq, s.spWait); err == nil {
+		err = werr
+	}
+	return err
+}
+
+// Length returns the number of bytes that can be read.
+func (s *SharedRing) Length() int {
+	head := s.head.Load()
+	return int(s.tail.Load() - head)
+}
+
+// Capacity returns the size of the buffer.
+func (s *SharedRing) Capacity() int {
+	return int(s.size)
+}
+
+// Close unmaps the ring buffer from this process.
+// It must not be used afterwards. The file is left for the other process.
+func (s *SharedRing) Close() error {
+	return munmapFile(s.m)
+}
+
+// sharedWait waits until seq no longer holds old, counting itself in waiters
+// so sharedWake knows to wake it up.
+func sharedWait(seq, waiters *atomic.Uint32, old uint32) error {
+	waiters.Add(1)
+	defer waiters.Add(^uint32(0))
+	return futexWait(seq, old)
+}
+
+// sharedWake bumps seq and wakes up the processes waiting for it, if any.
+func sharedWake(seq, waiters *atomic.Uint32) error {
+	seq.Add(1)
+	if waiters.Load() > 0 {
+		return futexWake(seq)
+	}
+	return nil
+}
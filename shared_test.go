@@ -0,0 +1,120 @@
+//go:build unix
+
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSharedRing(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	path := filepath.Join(t.TempDir(), "shm")
+	// Two mappings of the same file, as two processes would have.
+	w, err := NewShared(path, 64)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer w.Close()
+	r, err := NewShared(path, 64)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer r.Close()
+
+	data := bytes.Repeat([]byte("0123456789abcdef"), 1000)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for p := data; len(p) > 0; {
+			n := 7
+			if n > len(p) {
+				n = len(p)
+			}
+			if _, err := w.Write(p[:n]); err != nil {
+				t.Errorf("expected nil, got %v", err)
+				return
+			}
+			p = p[n:]
+		}
+		w.CloseWriter()
+	}()
+
+	got, err := io.ReadAll(r)
+	// The writer must be done with its mapping before it is unmapped.
+	<-done
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("expected %d bytes, got %d", len(data), len(got))
+	}
+	if _, err := w.Write([]byte("x")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+
+	if _, err := NewShared(path, 32); err != ErrMmapSize {
+		t.Fatalf("expected ErrMmapSize, got %v", err)
+	}
+}
+
+func TestSharedRingCreateRace(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shm")
+	const n = 8
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			s, err := NewShared(path, 64)
+			if err == nil {
+				s.Close()
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < n; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("expected nil, got %v", err)
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Fatalf("expected only the ring buffer file, got %d files", len(entries))
+	}
+}
+
+func TestSharedRingReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shm")
+	s, err := NewShared(path, 64)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	s.Write([]byte("stale"))
+	s.CloseWriter()
+	s.Close()
+
+	// A later run finds the closed writer until it resets the ring buffer.
+	s, err = NewShared(path, 64)
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	defer s.Close()
+	if _, err := s.Write([]byte("x")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+	s.Reset()
+	if s.Length() != 0 {
+		t.Fatalf("expected an empty ring buffer, got length %d", s.Length())
+	}
+	if _, err := s.Write([]byte("fresh")); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	buf := make([]byte, 8)
+	if n, err := s.Read(buf); err != nil || string(buf[:n]) != "fresh" {
+		t.Fatalf("expected fresh, got %q, %v", buf[:n], err)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// cacheLine is the assumed size of a CPU cache line,
+// used to keep fields written by different goroutines apart.
+const cacheLine = 64
+
+// SPSC is a lock-free ring buffer for exactly one writer goroutine
+// and one reader goroutine.
+// Instead of a mutex the reader and the writer each own an index,
+// which they publish to each other with atomic loads and stores,
+// so they never contend for a lock.
+//
+// SPSC is never blocking: Read returns ErrIsEmpty when there is no data
+// and Write returns ErrIsFull or ErrTooMuchDataToWrite when there is no space.
+// Calling Write from more than one goroutine at a time,
+// or Read from more than one goroutine at a time, corrupts the data.
+type SPSC struct {
+	buf  []byte
+	size uint64
+
+	// The reader and the writer each keep their index on a cache line of its own,
+	// together with their last load of the other index,
+	// so they only touch each other's line when the cached index runs out.
+	// The groups are a full cache line apart, so they never share a line
+	// whatever the alignment of the SPSC, for example when it is embedded in another struct.
+	_         [cacheLine]byte
+	head      atomic.Uint64 // Total bytes read, only stored by the reader.
+	tailCache uint64        // Last tail loaded by the reader.
+	_         [cacheLine]byte
+	tail      atomic.Uint64 // Total bytes written, only stored by the writer.
+	headCache uint64        // Last head loaded by the writer.
+	_         [cacheLine]byte
+
+	closed atomic.Bool
+	_      [cacheLine]byte
+}
+
+// NewSPSC returns a new single-producer single-consumer ring buffer
+// whose buffer has the given size.
+func NewSPSC(size int) *SPSC {
+	return &SPSC{
+		buf:  make([]byte, size),
+		size: uint64(size),
+	}
+}
+
+// Write writes len(p) bytes from p to the buffer.
+// It returns the number of bytes written from p (0 <= n <= len(p))
+// and ErrIsFull if the buffer is full,
+// or ErrTooMuchDataToWrite if not all of p fits.
+// Write must only be called by the writer goroutine.
+func (s *SPSC) Write(p []byte) (n int, err error) {
+	if s.closed.Load() {
+		return 0, ErrWriteOnClosed
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	tail := s.tail.Load()
+	free := s.size - (tail - s.headCache)
+	if free < uint64(len(p)) {
+		s.headCache = s.head.Load()
+		free = s.size - (tail - s.headCache)
+	}
+	if free == 0 {
+		return 0, ErrIsFull
+	}
+	if uint64(len(p)) > free {
+		p = p[:free]
+		err = ErrTooMuchDataToWrite
+	}
+	w := tail % s.size
+	c := copy(s.buf[w:], p)
+	copy(s.buf, p[c:])
+	// Publish the data to the reader.
+	s.tail.Store(tail + uint64(len(p)))
+	return len(p), err
+}
+
+// Read reads up to len(p) bytes into p.
+// It returns the number of bytes read (0 <= n <= len(p))
+// and ErrIsEmpty if the buffer is empty,
+// or io.EOF if the buffer is empty and the writer has been closed.
+// Read must only be called by the reader goroutine.
+func (s *SPSC) Read(p []byte) (n int, err error) {
+	head := s.head.Load()
+	avail := s.tailCache - head
+	if avail == 0 || avail < uint64(len(p)) {
+		s.tailCache = s.tail.Load()
+		avail = s.tailCache - head
+	}
+	if avail == 0 {
+		if !s.closed.Load() {
+			return 0, ErrIsEmpty
+		}
+		// Data may have been written before closing.
+		s.tailCache = s.tail.Load()
+		if avail = s.tailCache - head; avail == 0 {
+			return 0, io.EOF
+		}
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if uint64(len(p)) > avail {
+		p = p[:avail]
+	}
+	r := head % s.size
+	c := copy(p, s.buf[r:])
+	copy(p[c:], s.buf)
+	// Release the space to the writer.
+	s.head.Store(head + uint64(len(p)))
+	return len(p), nil
+}
+
+// CloseWriter closes the writer.
+// Reads will return any remaining bytes and io.EOF,
+// and writes will return ErrWriteOnClosed.
+// CloseWriter must only be called by the writer goroutine.
+func (s *SPSC) CloseWriter() {
+	s.closed.Store(true)
+}
+
+// Length returns the number of bytes that can be read.
+func (s *SPSC) Length() int {
+	head := s.head.Load()
+	return int(s.tail.Load() - head)
+}
+
+// Free returns the number of bytes that can be written.
+func (s *SPSC) Free() int {
+	return int(s.size) - s.Length()
+}
+
+// Capacity returns the size of the underlying buffer.
+func (s *SPSC) Capacity() int {
+	return int(s.size)
+}
+
+// IsEmpty returns true when the buffer is empty.
+func (s *SPSC) IsEmpty() bool {
+	return s.Length() == 0
+}
+
+// IsFull returns true when the buffer is full.
+func (s *SPSC) IsFull() bool {
+	return s.Length() == int(s.size)
+}
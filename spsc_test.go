@@ -0,0 +1,112 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestSPSC(t *testing.T) {
+	s := NewSPSC(8)
+	if n, err := s.Write([]byte("abcdef")); err != nil || n != 6 {
+		t.Fatalf("expected 6 bytes written, got %d, %v", n, err)
+	}
+	buf := make([]byte, 4)
+	if n, err := s.Read(buf); err != nil || string(buf[:n]) != "abcd" {
+		t.Fatalf("expected abcd, got %q, %v", buf[:n], err)
+	}
+	if n, err := s.Write([]byte("ghijklmn")); err != ErrTooMuchDataToWrite || n != 6 {
+		t.Fatalf("expected 6 bytes and ErrTooMuchDataToWrite, got %d, %v", n, err)
+	}
+	if !s.IsFull() {
+		t.Fatalf("expected buffer to be full")
+	}
+	if _, err := s.Write([]byte("x")); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	buf = make([]byte, 16)
+	if n, err := s.Read(buf); err != nil || string(buf[:n]) != "efghijkl" {
+		t.Fatalf("expected efghijkl, got %q, %v", buf[:n], err)
+	}
+	if _, err := s.Read(buf); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+
+	s.Write([]byte("z"))
+	s.CloseWriter()
+	if _, err := s.Write([]byte("x")); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+	if n, err := s.Read(buf); err != nil || string(buf[:n]) != "z" {
+		t.Fatalf("expected z, got %q, %v", buf[:n], err)
+	}
+	if _, err := s.Read(buf); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestSPSCConcurrent(t *testing.T) {
+	defer timeout(10 * time.Second)()
+	data := make([]byte, 1<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	s := NewSPSC(4096)
+
+	go func() {
+		p := data
+		for len(p) > 0 {
+			chunk := rand.Intn(1000) + 1
+			if chunk > len(p) {
+				chunk = len(p)
+			}
+			n, _ := s.Write(p[:chunk])
+			p = p[n:]
+			if n == 0 {
+				runtime.Gosched()
+			}
+		}
+		s.CloseWriter()
+	}()
+
+	var got bytes.Buffer
+	buf := make([]byte, 1500)
+	for {
+		n, err := s.Read(buf)
+		got.Write(buf[:n])
+		if err == io.EOF {
+			break
+		}
+		if err == ErrIsEmpty {
+			runtime.Gosched()
+		}
+	}
+	if !bytes.Equal(got.Bytes(), data) {
+		t.Fatalf("data mismatch, got %d bytes", got.Len())
+	}
+}
+
+func TestSPSCLayout(t *testing.T) {
+	var s SPSC
+	// The bytes between the end of a group and the start of the next one must fill
+	// at least a cache line for them never to share one, whatever the alignment.
+	groups := []struct {
+		name       string
+		end, start uintptr
+	}{
+		{"size and head", unsafe.Offsetof(s.size) + unsafe.Sizeof(s.size), unsafe.Offsetof(s.head)},
+		{"tailCache and tail", unsafe.Offsetof(s.tailCache) + unsafe.Sizeof(s.tailCache), unsafe.Offsetof(s.tail)},
+		{"headCache and closed", unsafe.Offsetof(s.headCache) + unsafe.Sizeof(s.headCache), unsafe.Offsetof(s.closed)},
+		{"closed and the end", unsafe.Offsetof(s.closed) + unsafe.Sizeof(s.closed), unsafe.Sizeof(s)},
+	}
+	for _, g := range groups {
+		if d := g.start - g.end; d < cacheLine {
+			t.Fatalf("expected at least %d bytes between %s, got %d", cacheLine, g.name, d)
+		}
+	}
+	if unsafe.Offsetof(s.tailCache) != unsafe.Offsetof(s.head)+8 || unsafe.Offsetof(s.headCache) != unsafe.Offsetof(s.tail)+8 {
+		t.Fatalf("expected the cached indices next to the own index")
+	}
+}
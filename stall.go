@@ -0,0 +1,125 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "time"
+
+// LastRead returns the time data was last read from the ring buffer,
+// or the zero time if nothing has been read.
+//
+// Read and write times are only kept from the first call of LastRead, LastWrite or IsStalled on,
+// so ring buffers that are not monitored do not read the clock on every read and write.
+// That first call approximates earlier times by the time of the call.
+func (r *RingBuffer) LastRead() time.Time {
+	r.mu.Lock()
+	defer r.unlock()
+	r.keepTimes()
+	return r.lastRead
+}
+
+// LastWrite returns the time data was last written to the ring buffer,
+// or the zero time if nothing has been written.
+// Like LastRead, it starts keeping read and write times.
+func (r *RingBuffer) LastWrite() time.Time {
+	r.mu.Lock()
+	defer r.unlock()
+	r.keepTimes()
+	return r.lastWrite
+}
+
+// IsStalled reports whether buffered data has been waiting longer than d
+// without any of it being read.
+// This is the case when the consumer is wedged,
+// including when writers are blocked on a full buffer.
+// An empty ring buffer is never stalled.
+// Like LastRead, it starts keeping read and write times,
+// so the first call treats buffered data as waiting since that call:
+// a consumer that was already wedged is only reported once d has passed after it.
+// Call IsStalled or LastRead once when creating the ring buffer to monitor it from the start.
+func (r *RingBuffer) IsStalled(d time.Duration) bool {
+	r.mu.Lock()
+	defer r.unlock()
+	r.keepTimes()
+	if r.w == r.r && !r.isFull {
+		return false
+	}
+	return time.Since(r.waitingSince()) > d
+}
+
+// keepTimes starts keeping read and write times, if they are not kept yet.
+// Must be called when locked.
+func (r *RingBuffer) keepTimes() {
+	if r.times {
+		return
+	}
+	r.times = true
+	now := time.Now()
+	if r.ctr.reads > 0 {
+		r.lastRead = now
+	}
+	if r.ctr.writes > 0 {
+		r.lastWrite = now
+	}
+	r.unread = time.Time{}
+	if r.length() > 0 {
+		r.unread = now
+	}
+}
+
+// waitingSince returns the time since which the buffered data has been waiting for a read:
+// the time of the last read, or when the buffer became non-empty if that was later.
+// Must be called when locked.
+func (r *RingBuffer) waitingSince() time.Time {
+	if r.lastRead.After(r.unread) {
+		return r.lastRead
+	}
+	return r.unread
+}
+
+// markRead records that n bytes have been read.
+// Must be called when locked, after the read pointer has been moved.
+func (r *RingBuffer) markRead(n int) {
+	r.ctr.reads++
+	r.ctr.bytesRead += int64(n)
+	r.unreadable = n
+	r.runeSize = 0
+	r.addHookEvent(evRead, n, nil)
+	if r.times {
+		r.lastRead = time.Now()
+		if r.w == r.r && !r.isFull {
+			r.unread = time.Time{}
+		}
+	}
+	r.storeHeader()
+	r.checkWatermarks()
+	notify(r.writableC)
+}
+
+// markWrite records that n bytes have been written.
+// Must be called when locked, after the write pointer has been moved.
+func (r *RingBuffer) markWrite(n int) {
+	if n > 0 {
+		r.ctr.writes++
+		r.ctr.bytesWritten += int64(n)
+	}
+	r.addHookEvent(evWrite, n, nil)
+	if r.times || (r.ages != nil && n > 0) {
+		now := time.Now()
+		if r.times {
+			r.lastWrite = now
+			if r.unread.IsZero() {
+				// Only stamped when the buffer becomes non-empty.
+				r.unread = now
+			}
+		}
+		if r.ages != nil && n > 0 {
+			r.ages.stamp(r.written, now)
+		}
+	}
+	r.storeHeader()
+	r.checkWatermarks()
+	notify(r.readableC)
+	r.wakeWatchers()
+}
@@ -0,0 +1,85 @@
+package ringbuffer
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_IsStalled(t *testing.T) {
+	rb := New(10)
+	if !rb.LastRead().IsZero() || !rb.LastWrite().IsZero() {
+		t.Fatalf("expected zero timestamps")
+	}
+	if rb.IsStalled(0) {
+		t.Fatalf("empty buffer is stalled")
+	}
+
+	start := time.Now()
+	rb.Write([]byte("hello"))
+	if rb.LastWrite().Before(start) {
+		t.Fatalf("last write not updated")
+	}
+	time.Sleep(20 * time.Millisecond)
+	if !rb.IsStalled(10 * time.Millisecond) {
+		t.Fatalf("expect IsStalled is true but got false")
+	}
+	if rb.IsStalled(time.Hour) {
+		t.Fatalf("expect IsStalled is false for long duration")
+	}
+
+	// A read is progress.
+	rb.ReadByte()
+	if rb.LastRead().Before(start) {
+		t.Fatalf("last read not updated")
+	}
+	if rb.IsStalled(10 * time.Millisecond) {
+		t.Fatalf("expect IsStalled is false after read")
+	}
+
+	// Draining the buffer.
+	rb.Read(make([]byte, 10))
+	time.Sleep(20 * time.Millisecond)
+	if rb.IsStalled(10 * time.Millisecond) {
+		t.Fatalf("empty buffer is stalled")
+	}
+	rb.Write([]byte("x"))
+	if rb.IsStalled(10 * time.Millisecond) {
+		t.Fatalf("new data is stalled")
+	}
+}
+
+func TestRingBuffer_TimesKeptWhenMonitored(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abc"))
+	rb.ReadByte()
+	time.Sleep(10 * time.Millisecond)
+
+	// The first call approximates earlier times by the time of the call.
+	monitored := time.Now()
+	if rb.LastWrite().Before(monitored) || rb.LastRead().Before(monitored) {
+		t.Fatalf("expected approximated timestamps")
+	}
+	if rb.IsStalled(time.Hour) {
+		t.Fatalf("expected IsStalled to be false")
+	}
+	if rb.Length() != 2 {
+		t.Fatalf("expected length 2, got %d", rb.Length())
+	}
+
+	// From then on the times are kept.
+	before := time.Now()
+	rb.WriteByte('d')
+	if rb.Length() != 3 {
+		t.Fatalf("expected length 3, got %d", rb.Length())
+	}
+	if last := rb.LastWrite(); last.Before(before) {
+		t.Fatalf("expected the time of the last write, got %v", last)
+	}
+	if !rb.IsStalled(0) {
+		t.Fatalf("expected buffered data to be stalled")
+	}
+	rb.Read(make([]byte, 3))
+	if rb.IsStalled(0) {
+		t.Fatalf("expected an empty buffer not to be stalled")
+	}
+}
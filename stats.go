@@ -0,0 +1,90 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "time"
+
+// Stats describes the state of a ring buffer.
+// The counters after Err are cumulative since the ring buffer was created:
+// unlike the offsets, they are not cleared by Reset or restored by UnmarshalBinary.
+type Stats struct {
+	Size          int   // Size of the buffer.
+	Length        int   // Number of bytes that can be read.
+	ReadOffset    int64 // Total number of bytes consumed, as returned by ReadOffset.
+	WriteOffset   int64 // Total number of bytes written, as returned by WriteOffset.
+	HighWaterMark int   // Highest number of bytes buffered, as returned by HighWaterMark.
+	Stalls        int64 // Number of writes that found the buffer full, as returned by Stalls.
+	Sealed        bool  // Whether the ring buffer is sealed.
+	Err           error // Error the ring buffer was closed with, or nil if it is open.
+
+	Writes       int64         // Number of writes that stored data.
+	Reads        int64         // Number of reads that consumed data.
+	BytesWritten int64         // Number of bytes written.
+	BytesRead    int64         // Number of bytes read, not including overwritten bytes.
+	EmptyHits    int64         // Number of reads that found the buffer empty.
+	Overwritten  int64         // Number of bytes evicted or dropped in overwrite mode.
+	Expired      int64         // Number of bytes dropped because they were older than SetMaxAge.
+	WriteBlocked time.Duration // Total time writers spent waiting for free space.
+	ReadBlocked  time.Duration // Total time readers spent waiting for data.
+}
+
+// counters are the cumulative statistics of a ring buffer.
+// They are plain fields updated under the lock, so collecting them costs
+// a few additions per operation.
+type counters struct {
+	writes, reads             int64
+	bytesWritten, bytesRead   int64
+	emptyHits, overwritten    int64
+	expired                   int64
+	writeBlocked, readBlocked time.Duration
+}
+
+// Stats returns the state of the ring buffer and its cumulative statistics.
+func (r *RingBuffer) Stats() Stats {
+	defer r.runlock(r.rlock())
+	return r.stats()
+}
+
+// Snapshot returns a copy of the unread data together with the state of the ring buffer,
+// both captured at the same instant, for example to dump the ring buffer when a program panics.
+// It does not move the read pointer.
+// The data of an encrypted ring buffer is decrypted, see SetEncryptionKey.
+func (r *RingBuffer) Snapshot() (data []byte, stats Stats) {
+	defer r.runlock(r.rlock())
+	data = make([]byte, r.length())
+	r.peekAt(data, 0)
+	return data, r.stats()
+}
+
+// stats returns the state of the ring buffer.
+// Must be called when locked.
+func (r *RingBuffer) stats() Stats {
+	return Stats{
+		Size:          r.size,
+		Length:        r.length(),
+		ReadOffset:    r.consumed(),
+		WriteOffset:   r.written,
+		HighWaterMark: r.highWater,
+		Stalls:        r.stalls,
+		Sealed:        r.sealed,
+		Err:           r.err,
+		Writes:        r.ctr.writes,
+		Reads:         r.ctr.reads,
+		BytesWritten:  r.ctr.bytesWritten,
+		BytesRead:     r.ctr.bytesRead,
+		EmptyHits:     r.ctr.emptyHits,
+		Overwritten:   r.ctr.overwritten,
+		Expired:       r.ctr.expired,
+		WriteBlocked:  r.ctr.writeBlocked,
+		ReadBlocked:   r.ctr.readBlocked,
+	}
+}
+
+// addBlocked adds the time since start to *d.
+// It is deferred by waits to account for the time spent blocked.
+// Must be called when locked.
+func (r *RingBuffer) addBlocked(d *time.Duration, start time.Time) {
+	*d += time.Since(start)
+}
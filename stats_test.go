@@ -0,0 +1,110 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Snapshot(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghij"))
+	rb.CloseWriter()
+
+	data, stats := rb.Snapshot()
+	if string(data) != "efghij" {
+		t.Fatalf("expected efghij, got %q", data)
+	}
+	want := Stats{
+		Size:          8,
+		Length:        6,
+		ReadOffset:    4,
+		WriteOffset:   10,
+		HighWaterMark: 6,
+		Err:           io.EOF,
+		Writes:        2,
+		Reads:         1,
+		BytesWritten:  10,
+		BytesRead:     4,
+	}
+	if stats != want {
+		t.Fatalf("expected %+v, got %+v", want, stats)
+	}
+	if rb.Length() != 6 {
+		t.Fatalf("expected nothing to be read, got length %d", rb.Length())
+	}
+}
+
+func TestRingBuffer_Stats(t *testing.T) {
+	rb := New(4)
+	rb.Read(make([]byte, 2))
+	rb.Write([]byte("abc"))
+	rb.Write([]byte("de"))
+	rb.ReadByte()
+	rb.Read(make([]byte, 4))
+	rb.ReadByte()
+
+	stats := rb.Stats()
+	if stats.Writes != 2 || stats.BytesWritten != 4 {
+		t.Fatalf("expected 2 writes of 4 bytes, got %d writes of %d bytes", stats.Writes, stats.BytesWritten)
+	}
+	if stats.Reads != 2 || stats.BytesRead != 4 {
+		t.Fatalf("expected 2 reads of 4 bytes, got %d reads of %d bytes", stats.Reads, stats.BytesRead)
+	}
+	if stats.EmptyHits != 2 {
+		t.Fatalf("expected 2 empty hits, got %d", stats.EmptyHits)
+	}
+	if stats.Stalls != 1 {
+		t.Fatalf("expected 1 stall, got %d", stats.Stalls)
+	}
+
+	rb.Reset()
+	if stats := rb.Stats(); stats.Writes != 2 || stats.WriteOffset != 0 {
+		t.Fatalf("expected counters to survive Reset, got %+v", stats)
+	}
+}
+
+func TestRingBuffer_StatsOverwritten(t *testing.T) {
+	rb := New(4).SetOverwrite(true)
+	rb.Write([]byte("abc"))
+	rb.Write([]byte("de"))
+	rb.Write([]byte("fghijk"))
+
+	stats := rb.Stats()
+	if stats.Overwritten != 7 {
+		t.Fatalf("expected 7 bytes overwritten, got %d", stats.Overwritten)
+	}
+	if stats.BytesWritten != 11 {
+		t.Fatalf("expected 11 bytes written, got %d", stats.BytesWritten)
+	}
+}
+
+func TestRingBuffer_StatsBlocked(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(4).SetBlocking(true)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		rb.Write([]byte("abcd"))
+	}()
+	buf := make([]byte, 4)
+	if _, err := rb.Read(buf); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if d := rb.Stats().ReadBlocked; d < 40*time.Millisecond {
+		t.Fatalf("expected readers to be blocked for 50ms, got %v", d)
+	}
+
+	rb.Write([]byte("abcd"))
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		rb.Read(buf)
+	}()
+	if _, err := rb.Write([]byte("e")); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if d := rb.Stats().WriteBlocked; d < 40*time.Millisecond {
+		t.Fatalf("expected writers to be blocked for 50ms, got %v", d)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrBufferTooSmall is returned when a new backing buffer cannot hold the buffered data.
+var ErrBufferTooSmall = errors.New("buffer too small for buffered data")
+
+// SwapBuffer replaces the backing array of the ring buffer with newBuf,
+// copying the unread data into it, and returns the old backing array for reuse.
+// The capacity of the ring buffer becomes len(newBuf).
+// Data that can be read again after Rewind, and out-of-order data
+// written with WriteAtOffset, is kept as well.
+//
+// ErrBufferTooSmall is returned and nothing is changed if newBuf cannot hold that data.
+// SwapBuffer waits for ReadFrom and WriteTo calls that are copying
+// directly to or from the old backing array.
+// Descriptors returned by ReadDescriptors and WriteDescriptors
+// must not be used after the buffer is swapped.
+func (r *RingBuffer) SwapBuffer(newBuf []byte) (old []byte, err error) {
+	r.mu.Lock()
+	defer r.unlock()
+	if r.buf == nil {
+		return nil, ErrReleased
+	}
+	r.waitUnlent()
+	return r.replaceBuffer(newBuf)
+}
+
+// replaceBuffer relocates the retained data into buf, makes it the backing array
+// and returns the old one, which is wiped if secure wipe is enabled.
+// The accountant is notified of the change.
+// Must be called when locked, with no unlocked reads or writes using the current buffer.
+func (r *RingBuffer) replaceBuffer(buf []byte) (old []byte, err error) {
+	if r.mapped != nil {
+		return nil, ErrMapped
+	}
+	old = r.buf
+	if err := r.relocate(buf); err != nil {
+		return nil, err
+	}
+	if r.wipe {
+		zero(old)
+	}
+	r.freed(len(old))
+	r.allocated(len(buf))
+	return old, nil
+}
+
+// relocate copies the retained data to the start of buf and makes it the backing array.
+// The retained data is the unread data, preceded by the data that can be read
+// again after Rewind and followed by pending out-of-order data.
+// Must be called when locked, with no unlocked reads or writes using the current buffer.
+func (r *RingBuffer) relocate(buf []byte) error {
+	length := r.length()
+	start, retained, total := r.retained()
+	if len(buf) == 0 || total > len(buf) {
+		return ErrBufferTooSmall
+	}
+
+	if c := copy(buf[:total], r.buf[start:]); c < total {
+		copy(buf[c:total], r.buf)
+	}
+	resized := len(buf) != r.size
+	r.buf = buf
+	r.size = len(buf)
+	if resized {
+		r.resized()
+	}
+	r.r = retained - length
+	r.w = retained % r.size
+	r.isFull = length == r.size
+	r.sealR = 0
+	r.unreadable = 0
+	if r.block {
+		// Writers waiting for space can retry.
+		r.readCond.Broadcast()
+	}
+	return nil
+}
+
+// retained returns the position and size of the data that must be kept
+// when the buffer is moved: the unread data, preceded by the data that
+// can be read again after Rewind. total includes the pending
+// out-of-order data that follows it.
+// Must be called when locked.
+func (r *RingBuffer) retained() (start, retained, total int) {
+	start, retained = r.r, r.length()
+	if r.sealed {
+		start, retained = r.sealR, r.sealLen
+	}
+	total = retained
+	if len(r.pending) > 0 {
+		total += int(r.pending[len(r.pending)-1].End - r.written)
+	}
+	return start, retained, total
+}
+
+// lend marks the backing array as in use by an unlocked read or write.
+// Must be called when locked.
+func (r *RingBuffer) lend() {
+	r.lent++
+}
+
+// unlend releases the backing array after an unlocked read or write.
+// Must be called when locked.
+func (r *RingBuffer) unlend() {
+	r.lent--
+	if r.lent == 0 {
+		r.wipeClosed()
+		if r.idle != nil {
+			r.idle.Broadcast()
+		}
+	}
+}
+
+// waitUnlent waits until no unlocked reads or writes use the backing array.
+// Must be called when locked and returns locked.
+func (r *RingBuffer) waitUnlent() {
+	if r.idle == nil {
+		r.idle = sync.NewCond(&r.mu)
+	}
+	for r.lent > 0 {
+		r.idle.Wait()
+	}
+}
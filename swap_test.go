@@ -0,0 +1,97 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRingBuffer_SwapBuffer(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghij")) // wraps
+
+	old, err := rb.SwapBuffer(make([]byte, 16))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if len(old) != 8 {
+		t.Fatalf("expected old buffer of 8 bytes, got %d", len(old))
+	}
+	if rb.Capacity() != 16 {
+		t.Fatalf("expected capacity 16, got %d", rb.Capacity())
+	}
+	if rb.Free() != 10 {
+		t.Fatalf("expected 10 free bytes, got %d", rb.Free())
+	}
+	rb.Write([]byte("klmnopqrst"))
+	if !rb.IsFull() {
+		t.Fatalf("expected buffer to be full")
+	}
+	got := make([]byte, 16)
+	if n, _ := rb.Read(got); !bytes.Equal(got[:n], []byte("efghijklmnopqrst")) {
+		t.Fatalf("expected efghijklmnopqrst, got %q", got[:n])
+	}
+	if rb.WriteOffset() != 20 || rb.ReadOffset() != 20 {
+		t.Fatalf("expected offsets 20, got %d and %d", rb.WriteOffset(), rb.ReadOffset())
+	}
+}
+
+func TestRingBuffer_SwapBufferTooSmall(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abcdef"))
+	if _, err := rb.SwapBuffer(make([]byte, 4)); err != ErrBufferTooSmall {
+		t.Fatalf("expected ErrBufferTooSmall, got %v", err)
+	}
+	if rb.Capacity() != 8 || rb.Length() != 6 {
+		t.Fatalf("expected buffer to be unchanged, got capacity %d and length %d", rb.Capacity(), rb.Length())
+	}
+
+	old, err := rb.SwapBuffer(make([]byte, 6))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if !rb.IsFull() {
+		t.Fatalf("expected buffer to be full")
+	}
+	if _, err := rb.SwapBuffer(old); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if got := string(rb.Bytes(nil)); got != "abcdef" {
+		t.Fatalf("expected abcdef, got %q", got)
+	}
+}
+
+func TestRingBuffer_SwapBufferKeepsRewindAndPending(t *testing.T) {
+	rb := New(8)
+	rb.Write([]byte("abc"))
+	rb.WriteAtOffset(5, []byte("fg"))
+	if _, err := rb.SwapBuffer(make([]byte, 10)); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	rb.WriteAtOffset(3, []byte("de"))
+	if got := string(rb.Bytes(nil)); got != "abcdefg" {
+		t.Fatalf("expected abcdefg, got %q", got)
+	}
+
+	rb.Read(make([]byte, 4))
+	rb.Seal()
+	rb.Read(make([]byte, 2))
+	if _, err := rb.SwapBuffer(make([]byte, 3)); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if err := rb.Rewind(); err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if got := string(rb.Bytes(nil)); got != "efg" {
+		t.Fatalf("expected efg, got %q", got)
+	}
+}
+
+func TestRingBuffer_SwapBufferReleased(t *testing.T) {
+	rb := New(8)
+	rb.Release()
+	if _, err := rb.SwapBuffer(make([]byte, 8)); err != ErrReleased {
+		t.Fatalf("expected ErrReleased, got %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "io"
+
+// TeeWrites sets a writer that receives a copy of all bytes written to the ring buffer,
+// for example to capture the traffic of a Copy for debugging.
+// Like the hash of WithHash, it sees the data of all write methods including ReadFrom,
+// and the data dropped in overwrite mode.
+// w is called with the ring buffer locked, so it should not block
+// and must not call back into the ring buffer. Its errors are ignored.
+// A nil w removes the tee.
+func (r *RingBuffer) TeeWrites(w io.Writer) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.wTee = w
+	return r
+}
+
+// TeeReads sets a writer that receives a copy of all bytes read from the ring buffer.
+// Like the hash of WithReadHash, it sees the data of all read methods including WriteTo,
+// but not the data evicted in overwrite mode.
+// w is called with the ring buffer locked, so it should not block
+// and must not call back into the ring buffer. Its errors are ignored.
+// A nil w removes the tee.
+func (r *RingBuffer) TeeReads(w io.Writer) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.rTee = w
+	return r
+}
@@ -0,0 +1,45 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_Tee(t *testing.T) {
+	rb := New(8)
+	var wtap, rtap bytes.Buffer
+	rb.TeeWrites(&wtap).TeeReads(&rtap)
+	rb.Write([]byte("abc"))
+	rb.WriteByte('d')
+	rb.Read(make([]byte, 2))
+	rb.ReadByte()
+	rb.Advance(1)
+	if wtap.String() != "abcd" {
+		t.Fatalf("expected abcd written, got %q", wtap.String())
+	}
+	if rtap.String() != "abcd" {
+		t.Fatalf("expected abcd read, got %q", rtap.String())
+	}
+
+	rb.TeeWrites(nil)
+	rb.Write([]byte("e"))
+	if wtap.String() != "abcd" {
+		t.Fatalf("expected the tee to be removed, got %q", wtap.String())
+	}
+}
+
+func TestRingBuffer_TeeCopy(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	data := strings.Repeat("0123456789", 100)
+	rb := New(64)
+	var wtap, rtap, dst bytes.Buffer
+	rb.TeeWrites(&wtap).TeeReads(&rtap)
+	if _, err := rb.Copy(&dst, strings.NewReader(data)); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if dst.String() != data || wtap.String() != data || rtap.String() != data {
+		t.Fatalf("expected all data to be mirrored, got %d, %d and %d bytes", dst.Len(), wtap.Len(), rtap.Len())
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "errors"
+
+// ErrInvalidUnreadByte is returned by UnreadByte when the last byte read
+// cannot be pushed back.
+var ErrInvalidUnreadByte = errors.New("invalid use of UnreadByte")
+
+// UnreadByte pushes back the last byte read, so the next read returns it again,
+// which together with ReadByte implements io.ByteScanner.
+// Only one byte can be pushed back after a read.
+// ErrInvalidUnreadByte is returned if nothing has been read since the last unread,
+// if the byte has been overwritten since it was read because the buffer became full,
+// or if secure wipe is enabled, since read bytes are zeroed.
+// Hashes set with WithReadHash still include the byte.
+func (r *RingBuffer) UnreadByte() error {
+	r.mu.Lock()
+	defer r.unlock()
+	if !r.pushBack(1) {
+		return ErrInvalidUnreadByte
+	}
+	return nil
+}
+
+// pushBack moves the read position back by n bytes of the last read,
+// and reports whether they could be pushed back.
+// Must be called when locked.
+func (r *RingBuffer) pushBack(n int) bool {
+	if n > r.unreadable || r.isFull || r.wipe {
+		return false
+	}
+	r.r = (r.r - n + r.size) % r.size
+	if r.r == r.w {
+		r.isFull = true
+	}
+	r.unreadable = 0
+	r.runeSize = 0
+	r.storeHeader()
+	r.checkWatermarks()
+	if r.block {
+		r.writeCond.Broadcast()
+	}
+	notify(r.readableC)
+	return true
+}
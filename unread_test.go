@@ -0,0 +1,54 @@
+package ringbuffer
+
+import (
+	"io"
+	"testing"
+)
+
+var _ io.ByteScanner = (*RingBuffer)(nil)
+
+func TestRingBuffer_UnreadByte(t *testing.T) {
+	rb := New(4)
+	if err := rb.UnreadByte(); err != ErrInvalidUnreadByte {
+		t.Fatalf("expected ErrInvalidUnreadByte, got %v", err)
+	}
+	rb.Write([]byte("abcd"))
+	b, _ := rb.ReadByte()
+	if err := rb.UnreadByte(); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if err := rb.UnreadByte(); err != ErrInvalidUnreadByte {
+		t.Fatalf("expected ErrInvalidUnreadByte, got %v", err)
+	}
+	if !rb.IsFull() {
+		t.Fatalf("expected the buffer to be full again")
+	}
+	if c, _ := rb.ReadByte(); c != b {
+		t.Fatalf("expected %c, got %c", b, c)
+	}
+
+	// The read wraps around: the last byte read is at the end of the backing array.
+	rb.Read(make([]byte, 3))
+	rb.Write([]byte("ef"))
+	rb.UnreadByte()
+	buf := make([]byte, 4)
+	n, _ := rb.Read(buf)
+	if string(buf[:n]) != "def" {
+		t.Fatalf("expected def, got %q", buf[:n])
+	}
+
+	// Filling the buffer overwrites the byte.
+	rb.Write([]byte("ghij"))
+	if err := rb.UnreadByte(); err != ErrInvalidUnreadByte {
+		t.Fatalf("expected ErrInvalidUnreadByte, got %v", err)
+	}
+}
+
+func TestRingBuffer_UnreadByteWipe(t *testing.T) {
+	rb := New(4).SetSecureWipe(true)
+	rb.Write([]byte("ab"))
+	rb.ReadByte()
+	if err := rb.UnreadByte(); err != ErrInvalidUnreadByte {
+		t.Fatalf("expected ErrInvalidUnreadByte, got %v", err)
+	}
+}
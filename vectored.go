@@ -0,0 +1,113 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"net"
+)
+
+// WriteV writes the slices of bufs in order, as a single write,
+// so a header and a payload can be written without concatenating them first.
+// The data of concurrent writers is never interleaved with it:
+// in blocking mode WriteV waits until there is enough free space for all of bufs,
+// and otherwise it returns ErrIsFull without writing anything.
+// ErrTooMuchDataToWrite is returned if bufs can never fit in the buffer.
+// In overwrite mode WriteV never waits, and evicts the oldest data instead.
+// bufs is not modified.
+func (r *RingBuffer) WriteV(bufs net.Buffers) (n int64, err error) {
+	total := 0
+	for _, b := range bufs {
+		total += len(b)
+	}
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.writeErr(); err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	if err := r.waitSpace(total, r.block, nil); err != nil {
+		return 0, err
+	}
+	for _, b := range bufs {
+		if len(b) == 0 {
+			continue
+		}
+		var nn int
+		nn, err = r.write(b)
+		n += int64(nn)
+		if err != nil {
+			break
+		}
+	}
+	if r.block && n > 0 {
+		r.writeCond.Broadcast()
+	}
+	return n, r.setErr(err, true)
+}
+
+// ReadV reads into the slices of bufs in order, under a single lock acquisition,
+// so a header and a body can be read into separate buffers with one call.
+// Like Read, it returns the data available instead of waiting for more:
+// a slice is only filled after the previous ones are full.
+// In blocking mode it waits for data if there is none,
+// and otherwise it returns ErrIsEmpty.
+func (r *RingBuffer) ReadV(bufs [][]byte) (n int64, err error) {
+	total := 0
+	for _, b := range bufs {
+		total += len(b)
+	}
+	if total == 0 {
+		return 0, r.readErr(false)
+	}
+
+	r.mu.Lock()
+	defer r.unlock()
+	if err := r.readErr(true); err != nil {
+		return 0, err
+	}
+
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	n, err = r.readv(bufs)
+	for err == ErrIsEmpty && r.block {
+		if !r.waitWrite() {
+			return 0, context.DeadlineExceeded
+		}
+		if err = r.readErr(true); err != nil {
+			break
+		}
+		n, err = r.readv(bufs)
+	}
+	if r.block && n > 0 {
+		r.readCond.Broadcast()
+	}
+	return n, err
+}
+
+// readv reads into bufs in order until they are full or the buffer is empty.
+// It returns ErrIsEmpty only if nothing was read.
+// Must be called when locked.
+func (r *RingBuffer) readv(bufs [][]byte) (n int64, err error) {
+	for _, b := range bufs {
+		if len(b) == 0 {
+			continue
+		}
+		if n > 0 && r.w == r.r && !r.isFull {
+			break
+		}
+		var nn int
+		nn, err = r.read(b)
+		n += int64(nn)
+		if err != nil || nn < len(b) {
+			break
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,116 @@
+package ringbuffer
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_WriteV(t *testing.T) {
+	rb := New(8)
+	n, err := rb.WriteV(net.Buffers{[]byte("ab"), nil, []byte("cde")})
+	if err != nil || n != 5 {
+		t.Fatalf("expected 5 bytes, got %d, %v", n, err)
+	}
+	if s := string(rb.Bytes(nil)); s != "abcde" {
+		t.Fatalf("expected abcde, got %q", s)
+	}
+	n, err = rb.WriteV(net.Buffers{[]byte("fg"), []byte("hi")})
+	if err != ErrIsFull || n != 0 {
+		t.Fatalf("expected ErrIsFull and nothing written, got %d, %v", n, err)
+	}
+	if rb.Length() != 5 {
+		t.Fatalf("expected length 5, got %d", rb.Length())
+	}
+	if _, err := rb.WriteV(net.Buffers{make([]byte, 5), make([]byte, 4)}); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+
+	rb = New(4).SetOverwrite(true)
+	rb.WriteV(net.Buffers{[]byte("abc"), []byte("def")})
+	if s := string(rb.Bytes(nil)); s != "cdef" {
+		t.Fatalf("expected cdef, got %q", s)
+	}
+}
+
+func TestRingBuffer_WriteVBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8).SetBlocking(true)
+	var wg sync.WaitGroup
+	for _, c := range []byte("abcd") {
+		wg.Add(1)
+		go func(c byte) {
+			defer wg.Done()
+			hdr, body := []byte{c}, []byte{c, c, c}
+			for i := 0; i < 100; i++ {
+				if _, err := rb.WriteV(net.Buffers{hdr, body}); err != nil {
+					t.Errorf("expected no error, got %v", err)
+					return
+				}
+			}
+		}(c)
+	}
+	go func() {
+		wg.Wait()
+		rb.CloseWriter()
+	}()
+
+	msg := make([]byte, 4)
+	for {
+		_, err := rb.ReadFull(msg)
+		if err != nil {
+			break
+		}
+		if msg[0] != msg[1] || msg[0] != msg[2] || msg[0] != msg[3] {
+			t.Fatalf("expected no interleaving, got %q", msg)
+		}
+	}
+}
+
+func TestRingBuffer_ReadV(t *testing.T) {
+	rb := New(8)
+	hdr, body := make([]byte, 2), make([]byte, 4)
+	if _, err := rb.ReadV([][]byte{hdr, body}); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	rb.Write([]byte("abcdef"))
+	rb.Read(make([]byte, 4))
+	rb.Write([]byte("ghij"))
+
+	n, err := rb.ReadV([][]byte{hdr, nil, body})
+	if err != nil || n != 6 {
+		t.Fatalf("expected 6 bytes, got %d, %v", n, err)
+	}
+	if string(hdr) != "ef" || string(body) != "ghij" {
+		t.Fatalf("expected ef and ghij, got %q and %q", hdr, body)
+	}
+
+	rb.Write([]byte("klm"))
+	rb.CloseWriter()
+	n, err = rb.ReadV([][]byte{hdr, body})
+	if n != 3 || string(hdr) != "kl" || body[0] != 'm' {
+		t.Fatalf("expected klm, got %d bytes, %q and %q", n, hdr, body)
+	}
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, err := rb.ReadV([][]byte{hdr, body}); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+}
+
+func TestRingBuffer_ReadVBlocking(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8).SetBlocking(true)
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		rb.Write([]byte("abc"))
+	}()
+	hdr, body := make([]byte, 1), make([]byte, 4)
+	n, err := rb.ReadV([][]byte{hdr, body})
+	if err != nil || n != 3 || string(hdr) != "a" || string(body[:2]) != "bc" {
+		t.Fatalf("expected abc, got %d, %v", n, err)
+	}
+}
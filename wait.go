@@ -0,0 +1,88 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import (
+	"context"
+	"io"
+)
+
+// WaitForData waits until at least n bytes can be read, without reading them,
+// so a consumer can batch its reads without polling Length.
+// If ctx is done first, ctx.Err() is returned; ctx must not be nil.
+// If not blocking ErrIsEmpty will be returned if fewer than n bytes are buffered.
+// If the writer is closed before n bytes are buffered, io.EOF is returned,
+// and ErrInvalidLength is returned if n is more than the buffer can ever hold.
+func (r *RingBuffer) WaitForData(ctx context.Context, n int) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if n < 0 || (n > r.size && n > r.maxSize) {
+		return ErrInvalidLength
+	}
+	r.begin(OpRead)
+	defer r.end(OpRead)
+	if r.block {
+		defer r.wakeOnDone(ctx, r.writeCond, r.wLabels)()
+	}
+	for {
+		if err := r.readErr(true); err != nil {
+			return err
+		}
+		if r.length() >= n {
+			return nil
+		}
+		if r.err == io.EOF || r.sealed || r.rClosed != nil {
+			return io.EOF
+		}
+		if !r.block {
+			return ErrIsEmpty
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.writeCond.Wait()
+	}
+}
+
+// WaitForSpace waits until at least n bytes can be written, without writing them.
+// In overwrite mode there is always space, and with SetAutoGrow the buffer grows as needed.
+// If ctx is done first, ctx.Err() is returned; ctx must not be nil.
+// If not blocking ErrIsFull will be returned if fewer than n bytes are free.
+// Errors that would fail a write, like ErrWriteOnClosed, are returned as well,
+// and ErrTooMuchDataToWrite is returned if n is more than the buffer can ever hold.
+func (r *RingBuffer) WaitForSpace(ctx context.Context, n int) error {
+	r.mu.Lock()
+	defer r.unlock()
+	if n < 0 {
+		return ErrInvalidLength
+	}
+	if n > r.size && n > r.maxSize {
+		return ErrTooMuchDataToWrite
+	}
+	r.begin(OpWrite)
+	defer r.end(OpWrite)
+	if r.block {
+		defer r.wakeReadOnDone(ctx)()
+	}
+	for {
+		if err := r.writeErr(); err != nil {
+			return err
+		}
+		if r.remaining() < int64(n) {
+			return ErrWriteOnClosed
+		}
+		r.grow(n)
+		if r.overwrite || r.free() >= n {
+			return nil
+		}
+		if !r.block {
+			return ErrIsFull
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		r.readCond.Wait()
+	}
+}
@@ -0,0 +1,90 @@
+package ringbuffer
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestRingBuffer_WaitForData(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8).SetBlocking(true)
+	go func() {
+		for _, s := range []string{"ab", "cd", "ef"} {
+			time.Sleep(5 * time.Millisecond)
+			rb.Write([]byte(s))
+		}
+	}()
+	if err := rb.WaitForData(context.Background(), 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n := rb.Length(); n < 5 {
+		t.Fatalf("expected at least 5 bytes, got %d", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := rb.WaitForData(ctx, 8); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := rb.WaitForData(context.Background(), 9); err != ErrInvalidLength {
+		t.Fatalf("expected ErrInvalidLength, got %v", err)
+	}
+
+	rb.CloseWriter()
+	if err := rb.WaitForData(context.Background(), 8); err != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", err)
+	}
+
+	rb = New(8)
+	rb.Write([]byte("abc"))
+	if err := rb.WaitForData(context.Background(), 4); err != ErrIsEmpty {
+		t.Fatalf("expected ErrIsEmpty, got %v", err)
+	}
+	if err := rb.WaitForData(context.Background(), 3); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestRingBuffer_WaitForSpace(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	rb := New(8).SetBlocking(true)
+	rb.Write([]byte("abcdefgh"))
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(5 * time.Millisecond)
+			rb.Read(make([]byte, 2))
+		}
+	}()
+	if err := rb.WaitForSpace(context.Background(), 5); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if n := rb.Free(); n < 5 {
+		t.Fatalf("expected at least 5 free bytes, got %d", n)
+	}
+
+	rb.Write([]byte("ijklmn"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := rb.WaitForSpace(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := rb.WaitForSpace(context.Background(), 9); err != ErrTooMuchDataToWrite {
+		t.Fatalf("expected ErrTooMuchDataToWrite, got %v", err)
+	}
+	rb.CloseWriter()
+	if err := rb.WaitForSpace(context.Background(), 1); err != ErrWriteOnClosed {
+		t.Fatalf("expected ErrWriteOnClosed, got %v", err)
+	}
+
+	rb = New(4)
+	rb.Write([]byte("abcd"))
+	if err := rb.WaitForSpace(context.Background(), 1); err != ErrIsFull {
+		t.Fatalf("expected ErrIsFull, got %v", err)
+	}
+	rb.SetOverwrite(true)
+	if err := rb.WaitForSpace(context.Background(), 4); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+// watermarks holds the thresholds and callbacks set with SetWatermarks.
+type watermarks struct {
+	low, high     int
+	onLow, onHigh func()
+	above         bool // Length reached high and has not fallen to low since.
+}
+
+// SetWatermarks sets callbacks that are called when the number of buffered bytes
+// crosses thresholds, for example to pause a producer when the buffer is nearly full
+// and resume it when it has drained.
+// onHigh is called when the length rises to high or more, and onLow is called
+// when it then falls to low or less. Each is called once per crossing:
+// onHigh is not called again until onLow has been called, and vice versa.
+// low must be less than high. If the length is already high or more,
+// onHigh is called immediately.
+//
+// The callbacks are called with the ring buffer locked, so they must not call back
+// into the ring buffer; they can signal another goroutine instead.
+// Nil callbacks remove the watermarks.
+func (r *RingBuffer) SetWatermarks(low, high int, onLow, onHigh func()) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	if onLow == nil && onHigh == nil {
+		r.wm = nil
+		return r
+	}
+	r.wm = &watermarks{low: low, high: high, onLow: onLow, onHigh: onHigh}
+	r.checkWatermarks()
+	return r
+}
+
+// checkWatermarks calls the watermark callbacks if the length crossed a threshold.
+// Must be called when locked, after the length changed.
+func (r *RingBuffer) checkWatermarks() {
+	wm := r.wm
+	if wm == nil {
+		return
+	}
+	n := r.length()
+	switch {
+	case !wm.above && n >= wm.high:
+		wm.above = true
+		if wm.onHigh != nil {
+			wm.onHigh()
+		}
+	case wm.above && n <= wm.low:
+		wm.above = false
+		if wm.onLow != nil {
+			wm.onLow()
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package ringbuffer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRingBuffer_SetWatermarks(t *testing.T) {
+	var events []string
+	rb := New(10).SetWatermarks(2, 8,
+		func() { events = append(events, "low") },
+		func() { events = append(events, "high") })
+
+	buf := make([]byte, 10)
+	rb.Write([]byte("abcdefg"))
+	rb.Write([]byte("h")) // 8: high
+	rb.Write([]byte("i")) // 9: still high
+	rb.Read(buf[:5])      // 4
+	rb.Write([]byte("j")) // 5
+	rb.Read(buf[:3])      // 2: low
+	rb.Read(buf[:1])      // 1: still low
+	rb.Write([]byte("abcdefg"))
+	rb.Reset() // 0: low
+
+	if got := strings.Join(events, ","); got != "high,low,high,low" {
+		t.Fatalf("expected high,low,high,low, got %s", got)
+	}
+
+	// The callbacks are removed.
+	rb.SetWatermarks(0, 0, nil, nil)
+	rb.Write([]byte("abcdefghij"))
+	if len(events) != 4 {
+		t.Fatalf("expected no more events, got %v", events)
+	}
+
+	// onHigh is called immediately if the length is already high.
+	rb.SetWatermarks(2, 8, nil, func() { events = append(events, "high") })
+	if len(events) != 5 {
+		t.Fatalf("expected a high event, got %v", events)
+	}
+}
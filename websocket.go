@@ -0,0 +1,86 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "io"
+
+// WebSocketConn is the part of a websocket connection used by
+// PumpToWebSocket and PumpFromWebSocket.
+// It is implemented by the Conn of github.com/gorilla/websocket,
+// and is easily wrapped around the connections of other websocket packages.
+type WebSocketConn interface {
+	ReadMessage() (messageType int, p []byte, err error)
+	WriteMessage(messageType int, data []byte) error
+}
+
+// wsBinaryMessage is the type of binary websocket messages, as defined by RFC 6455.
+const wsBinaryMessage = 2
+
+// PumpToWebSocket reads the data of the ring buffer and sends it to c
+// as binary messages, until the writer of the ring buffer is closed.
+// If framed is true, each record written with WriteMsg is sent as one message,
+// so message boundaries are preserved.
+// Otherwise the data is sent in chunks of up to 32KiB as it becomes available.
+// The ring buffer is set to blocking mode.
+//
+// PumpToWebSocket returns nil once all data has been sent after the writer is closed.
+// If c fails, the read side of the ring buffer is closed with its error
+// using CloseRead, so writers stop, and the error is returned.
+func (r *RingBuffer) PumpToWebSocket(c WebSocketConn, framed bool) error {
+	r.SetBlocking(true)
+	buf := make([]byte, 32*1024)
+	for {
+		var n int
+		var err error
+		if framed {
+			n, err = r.ReadMsg(buf)
+			if err == io.ErrShortBuffer {
+				buf = make([]byte, n)
+				continue
+			}
+		} else {
+			n, err = r.Read(buf)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := c.WriteMessage(wsBinaryMessage, buf[:n]); err != nil {
+			r.CloseRead(err)
+			return err
+		}
+	}
+}
+
+// PumpFromWebSocket receives the messages of c and writes them to the ring buffer,
+// until c fails, for example because it was closed.
+// If framed is true, each message is written as a record with WriteMsg,
+// to be read with ReadMsg, so message boundaries are preserved.
+// Otherwise the messages are written as a byte stream.
+// The ring buffer is set to blocking mode.
+//
+// When c fails, the writer of the ring buffer is closed,
+// so readers read the remaining data and then io.EOF, and the error of c is returned.
+// If a write fails, its error is returned.
+func (r *RingBuffer) PumpFromWebSocket(c WebSocketConn, framed bool) error {
+	r.SetBlocking(true)
+	for {
+		_, p, err := c.ReadMessage()
+		if err != nil {
+			r.CloseWriter()
+			return err
+		}
+		if framed {
+			err = r.WriteMsg(p)
+		} else {
+			_, err = r.Write(p)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
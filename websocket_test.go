@@ -0,0 +1,80 @@
+package ringbuffer
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// chanWebSocket is a WebSocketConn exchanging messages through channels.
+type chanWebSocket struct {
+	in  chan []byte
+	out chan []byte
+	err error // Returned by WriteMessage, if set.
+}
+
+func (c *chanWebSocket) ReadMessage() (int, []byte, error) {
+	p, ok := <-c.in
+	if !ok {
+		return 0, nil, io.ErrUnexpectedEOF
+	}
+	return wsBinaryMessage, p, nil
+}
+
+func (c *chanWebSocket) WriteMessage(messageType int, data []byte) error {
+	if c.err != nil {
+		return c.err
+	}
+	if messageType != wsBinaryMessage {
+		return errors.New("not a binary message")
+	}
+	c.out <- append([]byte(nil), data...)
+	return nil
+}
+
+func TestRingBuffer_PumpWebSocket(t *testing.T) {
+	defer timeout(5 * time.Second)()
+	c := &chanWebSocket{in: make(chan []byte, 4), out: make(chan []byte, 4)}
+
+	// Framed messages keep their boundaries on both sides.
+	rx := New(64)
+	c.in <- []byte("hello")
+	c.in <- []byte("world")
+	close(c.in)
+	if err := rx.PumpFromWebSocket(c, true); err != io.ErrUnexpectedEOF {
+		t.Fatalf("expected io.ErrUnexpectedEOF, got %v", err)
+	}
+	if err := rx.PumpToWebSocket(c, true); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	for _, want := range []string{"hello", "world"} {
+		if got := string(<-c.out); got != want {
+			t.Fatalf("expected %s, got %s", want, got)
+		}
+	}
+
+	// Without framing, the data is sent as a stream.
+	tx := New(64)
+	tx.Write([]byte("abc"))
+	tx.Write([]byte("def"))
+	tx.CloseWriter()
+	if err := tx.PumpToWebSocket(c, false); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if got := string(<-c.out); got != "abcdef" {
+		t.Fatalf("expected abcdef, got %s", got)
+	}
+
+	// A failing connection closes the read side.
+	testErr := errors.New("test")
+	c.err = testErr
+	tx = New(64)
+	tx.Write([]byte("abc"))
+	if err := tx.PumpToWebSocket(c, false); err != testErr {
+		t.Fatalf("expected %v, got %v", testErr, err)
+	}
+	if _, err := tx.Write([]byte("x")); err != testErr {
+		t.Fatalf("expected %v, got %v", testErr, err)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2019 smallnest. All rights reserved.
+// Use of this source code is governed by a MIT-style
+// license that can be found in the LICENSE file.
+
+package ringbuffer
+
+import "io"
+
+// SetSecureWipe sets whether data is zeroed in the buffer as soon as it is consumed,
+// so secrets such as keys and tokens don't linger in memory.
+// When enabled, the whole buffer is also zeroed on Reset and Release,
+// when the ring buffer is closed with an error other than io.EOF,
+// since the buffered data can no longer be read,
+// and the old buffer returned by SwapBuffer is zeroed.
+// While the ring buffer is sealed, read data is kept so it can be read again after Rewind.
+func (r *RingBuffer) SetSecureWipe(wipe bool) *RingBuffer {
+	r.mu.Lock()
+	defer r.unlock()
+	r.wipe = wipe
+	return r
+}
+
+// wipeRead zeroes the next n buffered bytes, which are about to be consumed.
+// Must be called when locked.
+func (r *RingBuffer) wipeRead(n int) {
+	if !r.wipe || r.sealed || n <= 0 {
+		return
+	}
+	if r.r+n <= r.size {
+		zero(r.buf[r.r : r.r+n])
+		return
+	}
+	zero(r.buf[r.r:])
+	zero(r.buf[:n-(r.size-r.r)])
+}
+
+// wipeClosed zeroes the whole buffer if the ring buffer has been closed
+// with an error other than io.EOF and no unlocked reads or writes use it.
+// Must be called when locked.
+func (r *RingBuffer) wipeClosed() {
+	if r.wipe && r.lent == 0 && r.err != nil && r.err != io.EOF {
+		zero(r.buf)
+	}
+}
+
+// zero sets all bytes of b to 0.
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
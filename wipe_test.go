@@ -0,0 +1,77 @@
+package ringbuffer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestRingBuffer_SecureWipe(t *testing.T) {
+	rb := New(8).SetSecureWipe(true)
+	rb.Write([]byte("secret"))
+	rb.Read(make([]byte, 4))
+	if !bytes.Equal(rb.buf[:4], make([]byte, 4)) {
+		t.Fatalf("expected consumed bytes to be wiped, got %q", rb.buf)
+	}
+	if string(rb.buf[4:6]) != "et" {
+		t.Fatalf("expected unread bytes to be kept, got %q", rb.buf)
+	}
+
+	rb.Write([]byte("abcd")) // wraps
+	rb.ReadByte()
+	rb.CompleteRead(2)
+	if got := string(rb.Bytes(nil)); got != "bcd" {
+		t.Fatalf("expected bcd, got %q", got)
+	}
+	var buf bytes.Buffer
+	rb.WriteToAvailable(&buf)
+	if !bytes.Equal(rb.buf, make([]byte, 8)) {
+		t.Fatalf("expected buffer to be wiped, got %q", rb.buf)
+	}
+
+	rb.Write([]byte("secret"))
+	rb.Reset()
+	if !bytes.Equal(rb.buf, make([]byte, 8)) {
+		t.Fatalf("expected buffer to be wiped on Reset, got %q", rb.buf)
+	}
+
+	rb.Write([]byte("secret"))
+	rb.CloseWithError(errors.New("closed"))
+	if !bytes.Equal(rb.buf, make([]byte, 8)) {
+		t.Fatalf("expected buffer to be wiped on close, got %q", rb.buf)
+	}
+}
+
+func TestRingBuffer_SecureWipeKeepsSealed(t *testing.T) {
+	rb := New(8).SetSecureWipe(true)
+	rb.Write([]byte("secret"))
+	rb.CloseWriter()
+	rb.Read(make([]byte, 2))
+	if !bytes.Equal(rb.buf[:2], make([]byte, 2)) {
+		t.Fatalf("expected consumed bytes to be wiped after CloseWriter, got %q", rb.buf)
+	}
+
+	rb = New(8).SetSecureWipe(true)
+	rb.Write([]byte("secret"))
+	rb.Seal()
+	rb.Read(make([]byte, 6))
+	rb.Rewind()
+	if got := string(rb.Bytes(nil)); got != "secret" {
+		t.Fatalf("expected secret after Rewind, got %q", got)
+	}
+}
+
+func TestRingBuffer_SecureWipeSwap(t *testing.T) {
+	rb := New(8).SetSecureWipe(true)
+	rb.Write([]byte("secret"))
+	old, err := rb.SwapBuffer(make([]byte, 8))
+	if err != nil {
+		t.Fatalf("expected nil, got %v", err)
+	}
+	if !bytes.Equal(old, make([]byte, 8)) {
+		t.Fatalf("expected old buffer to be wiped, got %q", old)
+	}
+	if got := string(rb.Bytes(nil)); got != "secret" {
+		t.Fatalf("expected secret, got %q", got)
+	}
+}